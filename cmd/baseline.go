@@ -2,19 +2,20 @@ package cmd
 
 import (
 	"bloomdb/loader"
+	"context"
+	"fmt"
 )
 
 type BaselineCommand struct{}
 
-func (b *BaselineCommand) Run() {
+func (b *BaselineCommand) Run(ctx context.Context) error {
 	// Initialize printer first to ensure verbose output works
 	InitPrinter()
 
 	// Detect migration directories (root or subdirectories)
-	migrationDirs, err := loader.DetectMigrationDirectories(migrationPath)
+	migrationDirs, err := loader.DetectMigrationLocations(GetMigrationLocations(), IsRecursive())
 	if err != nil {
-		PrintError("Error detecting migration directories: %v", err)
-		return
+		return configError(fmt.Errorf("error detecting migration directories: %w", err))
 	}
 
 	// Process each migration directory
@@ -26,42 +27,48 @@ func (b *BaselineCommand) Run() {
 		}
 
 		// Process baseline for this directory
-		err := b.processBaselineDirectory(migDir)
+		err := b.processBaselineDirectory(ctx, migDir)
 		if err != nil {
-			PrintError("Error processing baseline for directory %s: %v", migDir.Path, err)
-			return
+			return fmt.Errorf("error processing baseline for directory %s: %w", migDir.Path, err)
 		}
 	}
 
 	PrintSuccess("All migration directories baselined successfully")
+	return nil
 }
 
-func (b *BaselineCommand) processBaselineDirectory(migDir loader.MigrationDirectory) error {
+func (b *BaselineCommand) processBaselineDirectory(ctx context.Context, migDir loader.MigrationDirectory) error {
 	// Setup database connection with appropriate table name
-	var setup *DatabaseSetup
-	if migDir.VersionTable != "" {
-		setup = SetupDatabaseWithTableName(migDir.VersionTable)
-	} else {
-		setup = SetupDatabase()
+	setup, err := SetupDatabaseForDirectory(ctx, migDir)
+	if err != nil {
+		return err
+	}
+
+	// Serialize with concurrent migrations, then bring an existing version table up to date
+	release, err := setup.AcquireLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := setup.UpgradeMigrationTable(ctx); err != nil {
+		return err
 	}
 
 	// Resolve baseline version with correct priority:
 	// 1. Existing baseline in DB, 2. CLI flag, 3. Env var, 4. Default
-	version := ResolveBaselineVersion(setup, baselineVersion)
+	version := ResolveBaselineVersion(ctx, setup, baselineVersion)
 
 	// Check if migration table exists
-	tableExists, err := setup.Database.TableExists(setup.TableName)
+	tableExists, err := setup.Database.TableExists(ctx, setup.TableName)
 	if err != nil {
-		PrintError("Error checking table existence: " + err.Error())
-		return err
+		return fmt.Errorf("error checking table existence: %w", err)
 	}
 
 	if tableExists {
 		// Table exists, check if baseline record already exists
-		baselineExists, existingBaselineVersion, err := setup.CheckBaselineRecordExists()
+		baselineExists, existingBaselineVersion, err := setup.CheckBaselineRecordExists(ctx)
 		if err != nil {
-			PrintError("Error checking baseline record: " + err.Error())
-			return err
+			return fmt.Errorf("error checking baseline record: %w", err)
 		}
 
 		if baselineExists {
@@ -75,18 +82,16 @@ func (b *BaselineCommand) processBaselineDirectory(migDir loader.MigrationDirect
 	} else {
 		// Table doesn't exist, create it
 		PrintInfo("Migration table '" + setup.TableName + "' does not exist, creating it")
-		err := setup.CreateMigrationTable()
+		err := setup.CreateMigrationTable(ctx)
 		if err != nil {
-			PrintError("Error creating migration table: " + err.Error())
-			return err
+			return fmt.Errorf("error creating migration table: %w", err)
 		}
 	}
 
 	// Insert baseline record
-	err = setup.InsertBaselineRecord(version)
+	err = setup.InsertBaselineRecord(ctx, version)
 	if err != nil {
-		PrintError("Error inserting baseline record: " + err.Error())
-		return err
+		return fmt.Errorf("error inserting baseline record: %w", err)
 	}
 
 	PrintSuccess("Baseline completed successfully for directory: %s", migDir.Path)
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bloomdb/config"
+	"bloomdb/db"
+	"bloomdb/loader"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// bundleOutput is the file written by the bundle command
+var bundleOutput string
+
+type BundleCommand struct{}
+
+func (b *BundleCommand) Run(ctx context.Context) error {
+	if bundleOutput == "" {
+		return configError(fmt.Errorf("bundle file is required (use --output)"))
+	}
+
+	locations := GetMigrationLocations()
+	for _, location := range locations {
+		info, err := os.Stat(location)
+		if err != nil {
+			return configError(fmt.Errorf("failed to read migration directory: %w", err))
+		}
+		if !info.IsDir() {
+			return configError(fmt.Errorf("migration location is not a directory: %s", location))
+		}
+	}
+
+	// Bundling an invalid migration set would only fail at deploy time
+	migrationDirs, err := loader.DetectMigrationLocations(locations, IsRecursive())
+	if err != nil {
+		return configError(fmt.Errorf("error detecting migration directories: %w", err))
+	}
+	for _, migDir := range migrationDirs {
+		if _, err := loader.NewVersionedMigrationLoaderForDirectory(migDir).LoadMigrations(); err != nil {
+			return validationError(fmt.Errorf("error loading versioned migrations: %w", err))
+		}
+		if _, err := loader.NewRepeatableMigrationLoaderForDirectory(migDir).LoadRepeatableMigrations(); err != nil {
+			return validationError(fmt.Errorf("error loading repeatable migrations: %w", err))
+		}
+	}
+
+	options := loader.BundleOptions{
+		Locations:      locations,
+		BloomDBVersion: db.BloomDBVersion,
+	}
+	// Relative post-migration scripts are found in the migration directories, which are bundled anyway
+	if filepath.IsAbs(postMigrationScript) {
+		options.PostMigrationScript = postMigrationScript
+	}
+	options.SourceCommit, options.SourceDirty = sourceCommit(locations[0])
+
+	manifest, err := loader.CreateBundle(bundleOutput, options)
+	if err != nil {
+		return configError(fmt.Errorf("error creating bundle: %w", err))
+	}
+
+	commit := manifest.SourceCommit
+	if commit == "" {
+		commit = "unknown"
+	} else if manifest.SourceDirty {
+		commit += " (with uncommitted changes)"
+	}
+	PrintSuccess("Bundled %d file(s) from %s into %s (commit: %s)", len(manifest.Files), strings.Join(locations, ", "), bundleOutput, commit)
+	return nil
+}
+
+// sourceCommit returns the git commit checked out in dir and whether the working tree
+// has uncommitted changes below dir. The commit is empty outside a git repository.
+func sourceCommit(dir string) (string, bool) {
+	output, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", false
+	}
+	status, err := exec.Command("git", "-C", dir, "status", "--porcelain", "--", ".").Output()
+	return strings.TrimSpace(string(output)), err == nil && len(strings.TrimSpace(string(status))) > 0
+}
+
+// openBundle extracts the bundle selected with --bundle to a temporary directory and points
+// the migration path, and the post-migration script unless one is set, at its content.
+// Commands without a --bundle flag ignore the setting.
+func openBundle(cmd *cobra.Command) error {
+	if bundlePath == "" || cmd.Flags().Lookup("bundle") == nil {
+		return nil
+	}
+	if settings.Lookup(config.KeyPath).Source != "default" {
+		return configError(fmt.Errorf("configuration error: bundle and path cannot be combined, a bundle replaces the migration path"))
+	}
+	if _, err := os.Stat(bundlePath); err != nil {
+		return configError(fmt.Errorf("failed to read bundle: %w", err))
+	}
+
+	dir, err := os.MkdirTemp("", "bloomdb-bundle-")
+	if err != nil {
+		return fmt.Errorf("failed to create directory for bundle: %w", err)
+	}
+	bundleDir = dir
+
+	manifest, err := loader.ExtractBundle(bundlePath, dir)
+	if err != nil {
+		return validationError(err)
+	}
+
+	var locations []string
+	for _, location := range manifest.Locations {
+		locations = append(locations, filepath.Join(dir, filepath.FromSlash(location)))
+	}
+	migrationPath = strings.Join(locations, ",")
+	if manifest.PostMigrationScript != "" && postMigrationScript == "" {
+		postMigrationScript = filepath.Join(dir, filepath.FromSlash(manifest.PostMigrationScript))
+	}
+
+	commit := manifest.SourceCommit
+	if commit == "" {
+		commit = "unknown"
+	}
+	PrintInfo("Using bundle %s (commit: %s, created: %s)", bundlePath, commit, manifest.CreatedAt)
+	return nil
+}
+
+// cleanupBundle removes the directory a bundle was extracted to
+func cleanupBundle() {
+	if bundleDir != "" {
+		os.RemoveAll(bundleDir)
+		bundleDir = ""
+	}
+}
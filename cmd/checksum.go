@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bloomdb/db"
+	"fmt"
+)
+
+// ChecksumMode decides whether applied migrations may change cosmetically
+type ChecksumMode string
+
+const (
+	ChecksumStrict     ChecksumMode = "strict"     // Any change to an applied migration is an error (default)
+	ChecksumNormalized ChecksumMode = "normalized" // Changes to comments, whitespace and keyword case are accepted
+)
+
+// parseChecksumMode validates a checksum_mode setting
+func parseChecksumMode(value string) (ChecksumMode, error) {
+	switch mode := ChecksumMode(value); mode {
+	case "":
+		return ChecksumStrict, nil
+	case ChecksumStrict, ChecksumNormalized:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("checksum_mode must be strict or normalized, got %q", value)
+	}
+}
+
+// checksumChange classifies how a migration file differs from its applied record
+type checksumChange int
+
+const (
+	checksumUnchanged checksumChange = iota
+	checksumCosmetic                 // Only comments, whitespace or keyword case differ
+	checksumSemantic                 // The statements differ, or the record has no normalized hash
+)
+
+// String describes the change in validation messages
+func (c checksumChange) String() string {
+	switch c {
+	case checksumCosmetic:
+		return "cosmetic change"
+	case checksumSemantic:
+		return "semantic change"
+	default:
+		return "unchanged"
+	}
+}
+
+// compareChecksums classifies the difference between a record and the checksums of its file.
+// Records without a checksum, such as baselines, match any file.
+func compareChecksums(record db.MigrationRecord, checksum int64, checksumSHA256, checksumNormalized string) checksumChange {
+	if (record.Checksum == nil || *record.Checksum == checksum) && sha256Matches(record, checksumSHA256) {
+		return checksumUnchanged
+	}
+	if record.ChecksumNormalized != nil && *record.ChecksumNormalized != "" && *record.ChecksumNormalized == checksumNormalized {
+		return checksumCosmetic
+	}
+	return checksumSemantic
+}
+
+// accepts reports whether a change passes validation in this mode
+func (m ChecksumMode) accepts(change checksumChange) bool {
+	return change == checksumUnchanged || (change == checksumCosmetic && m == ChecksumNormalized)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"bloomdb/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChecksumMode(t *testing.T) {
+	mode, err := parseChecksumMode("")
+	require.NoError(t, err)
+	assert.Equal(t, ChecksumStrict, mode)
+
+	mode, err = parseChecksumMode("normalized")
+	require.NoError(t, err)
+	assert.Equal(t, ChecksumNormalized, mode)
+
+	_, err = parseChecksumMode("loose")
+	assert.EqualError(t, err, `checksum_mode must be strict or normalized, got "loose"`)
+}
+
+func TestCompareChecksums(t *testing.T) {
+	record := db.MigrationRecord{Checksum: int64Ptr(1), ChecksumSHA256: stringPtr("aa"), ChecksumNormalized: stringPtr("nn")}
+
+	assert.Equal(t, checksumUnchanged, compareChecksums(record, 1, "aa", "nn"))
+	assert.Equal(t, checksumCosmetic, compareChecksums(record, 2, "bb", "nn"))
+	assert.Equal(t, checksumCosmetic, compareChecksums(record, 1, "bb", "nn"))
+	assert.Equal(t, checksumSemantic, compareChecksums(record, 2, "bb", "mm"))
+
+	// Records without a normalized hash cannot tell cosmetic changes apart
+	legacy := db.MigrationRecord{Checksum: int64Ptr(1)}
+	assert.Equal(t, checksumUnchanged, compareChecksums(legacy, 1, "bb", "nn"))
+	assert.Equal(t, checksumSemantic, compareChecksums(legacy, 2, "bb", "nn"))
+
+	assert.True(t, ChecksumNormalized.accepts(checksumCosmetic))
+	assert.False(t, ChecksumStrict.accepts(checksumCosmetic))
+	assert.False(t, ChecksumNormalized.accepts(checksumSemantic))
+}
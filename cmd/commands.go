@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 )
 
@@ -8,9 +10,9 @@ var migrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Run database migrations",
 	Long:  "Apply all pending database migrations",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		migrate := &MigrateCommand{}
-		migrate.Run()
+		return migrate.Run(cmd.Context())
 	},
 }
 
@@ -18,9 +20,19 @@ var infoCmd = &cobra.Command{
 	Use:   "info",
 	Short: "Show migration information",
 	Long:  "Display current migration status and information",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		info := &InfoCommand{}
-		info.Run()
+		return info.Run(cmd.Context())
+	},
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate applied migrations",
+	Long:  "Check that applied migrations succeeded and that their files were not modified, without running anything",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		validate := &ValidateCommand{}
+		return validate.Run(cmd.Context())
 	},
 }
 
@@ -28,9 +40,9 @@ var repairCmd = &cobra.Command{
 	Use:   "repair",
 	Short: "Repair migration state",
 	Long:  "Fix inconsistent migration state",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		repair := &RepairCommand{}
-		repair.Run()
+		return repair.Run(cmd.Context())
 	},
 }
 
@@ -38,12 +50,22 @@ var baselineCmd = &cobra.Command{
 	Use:   "baseline",
 	Short: "Baseline database",
 	Long:  "Mark all migrations as applied without running them",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		baseline := &BaselineCommand{}
-		baseline.Run()
+		return baseline.Run(cmd.Context())
 	},
 }
 
+func init() {
+	for _, cmd := range []*cobra.Command{migrateCmd, infoCmd} {
+		cmd.Flags().StringVar(&includeTags, "tags", "", "Run tagged migrations with any of these comma-separated tags (env: BLOOMDB_TAGS)")
+		cmd.Flags().StringVar(&excludeTags, "exclude-tags", "", "Never run migrations with any of these comma-separated tags (env: BLOOMDB_EXCLUDE_TAGS)")
+	}
+	for _, cmd := range []*cobra.Command{migrateCmd, infoCmd, validateCmd} {
+		cmd.Flags().StringVar(&checksumModeValue, "checksum-mode", "strict", "strict, or normalized to accept changes to comments, whitespace and keyword case (env: BLOOMDB_CHECKSUM_MODE)")
+	}
+}
+
 func init() {
 	baselineCmd.Flags().StringVar(&baselineVersion, "version", "", "Baseline version (env: BLOOMDB_BASELINE_VERSION)")
 }
@@ -52,8 +74,79 @@ var destroyCmd = &cobra.Command{
 	Use:   "destroy",
 	Short: "Destroy all database objects",
 	Long:  "Remove all database objects (tables, views, indexes, etc.) - DANGEROUS OPERATION",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		destroy := &DestroyCommand{}
-		destroy.Run()
+		return destroy.Run(cmd.Context())
 	},
 }
+
+var signCmd = &cobra.Command{
+	Use:         "sign",
+	Short:       "Sign migration files",
+	Long:        "Write a manifest with the SHA-256 of every migration file to each migration location, signed with an Ed25519 key",
+	Annotations: map[string]string{annotationConnection: connectionOptional},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sign := &SignCommand{}
+		return sign.Run(cmd.Context())
+	},
+}
+
+func init() {
+	signCmd.Flags().StringVar(&signingKey, "key", "", "Ed25519 private key in PKCS #8 PEM format (env: BLOOMDB_SIGNING_KEY)")
+	for _, cmd := range []*cobra.Command{migrateCmd, validateCmd} {
+		cmd.Flags().StringVar(&verifyKey, "verify-key", "", "Ed25519 public key in PEM format; refuse migrations that do not match their signed manifest (env: BLOOMDB_VERIFY_KEY)")
+	}
+}
+
+var bundleCmd = &cobra.Command{
+	Use:         "bundle",
+	Short:       "Package migrations into a bundle",
+	Long:        "Package the migration path into a single .tar.gz or .zip file with a manifest of checksums and the source git commit",
+	Annotations: map[string]string{annotationConnection: connectionOptional},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bundle := &BundleCommand{}
+		return bundle.Run(cmd.Context())
+	},
+}
+
+func init() {
+	bundleCmd.Flags().StringVarP(&bundleOutput, "output", "o", "", "Bundle file to write, ending in .tar.gz, .tgz or .zip")
+	for _, cmd := range []*cobra.Command{migrateCmd, infoCmd, validateCmd} {
+		cmd.Flags().StringVar(&bundlePath, "bundle", "", "Read migrations from a bundle written by bloomdb bundle instead of --path (env: BLOOMDB_BUNDLE)")
+	}
+}
+
+var waitCmd = &cobra.Command{
+	Use:   "wait",
+	Short: "Wait for the database",
+	Long:  "Wait until the database accepts connections and the credentials work, for example while a container starts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wait := &WaitCommand{}
+		return wait.Run(cmd.Context())
+	},
+}
+
+func init() {
+	waitCmd.Flags().DurationVar(&waitTimeout, "timeout", 60*time.Second, "Give up after this duration, 0 waits indefinitely")
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect configuration",
+	Long:  "Inspect the configuration resolved from flags, environment variables and bloomdb.yaml",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:         "show",
+	Short:       "Show resolved configuration",
+	Long:        "Print every resolved setting together with the flag, environment variable or config file it came from",
+	Annotations: map[string]string{annotationConnection: connectionOptional},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		show := &ConfigShowCommand{}
+		return show.Run(cmd.Context())
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+}
@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strings"
 
+	"bloomdb/config"
 	"bloomdb/db"
+	"bloomdb/loader"
 )
 
 // DatabaseSetup holds the common database connection and configuration
@@ -13,20 +17,28 @@ type DatabaseSetup struct {
 	ConnStr   string
 	DBType    db.DatabaseType
 	TableName string
+	Installer db.Installer // Identity recorded with migrations and baselines
 }
 
 // SetupDatabaseWithTableName performs database setup with a custom table name
-// Returns DatabaseSetup on success, exits on error
-func SetupDatabaseWithTableName(tableName string) *DatabaseSetup {
+// Returns a connection error if the database cannot be reached
+func SetupDatabaseWithTableName(ctx context.Context, tableName string) (*DatabaseSetup, error) {
 	// Validate connection string
 	if dbConnStr == "" {
-		PrintError("connection string is required")
-		os.Exit(1)
+		return nil, configError(fmt.Errorf("connection string is required"))
+	}
+
+	// Reject unsafe version table names before they can reach any SQL
+	if _, err := db.ParseTableIdentifier(tableName); err != nil {
+		return nil, configError(fmt.Errorf("invalid version table name: %w", err))
+	}
+
+	connection, err := db.ParseConfig(dbConnStr)
+	if err != nil {
+		return nil, configError(err)
 	}
 
 	var database db.Database
-	var connStr string
-	var dbType db.DatabaseType
 
 	// Use defer to ensure cleanup on any error during setup
 	defer func() {
@@ -39,120 +51,127 @@ func SetupDatabaseWithTableName(tableName string) *DatabaseSetup {
 		}
 	}()
 
-	// Create database instance
-	database, err := db.NewDatabaseFromConnectionString(dbConnStr)
-	if err != nil {
-		PrintError(fmt.Sprintf("Error creating database: %v", err))
-		os.Exit(1)
-	}
-
-	// Extract connection string
-	connStr, extractErr := db.ExtractConnectionString(dbConnStr)
-	if extractErr != nil {
-		if database != nil {
-			database.Close()
-		}
-		PrintError(fmt.Sprintf("Error extracting connection string: %v", extractErr))
-		os.Exit(1)
-	}
-
-	// Connect to database
-	err = database.Connect(connStr)
-	if err != nil {
-		if database != nil {
-			database.Close()
-		}
-		PrintError(fmt.Sprintf("Error connecting to database: %v", err))
-		os.Exit(1)
-	}
-
-	// Test connection
-	err = database.Ping()
+	// Connect to database, retrying while it starts up
+	PrintInfo("Connecting to %s", connection)
+	database, err = connectWithRetry(ctx, connection, connectRetries, connectRetryInterval)
 	if err != nil {
-		if database != nil {
-			database.Close()
-		}
-		PrintError(fmt.Sprintf("Error pinging database: %v", err))
-		os.Exit(1)
+		return nil, err
 	}
 	PrintInfo("Database connection test successful")
 
-	// Get database type
-	dbType, parseErr := db.ParseDatabaseType(dbConnStr)
-	if parseErr != nil {
-		if database != nil {
-			database.Close()
-		}
-		PrintError(fmt.Sprintf("Error parsing database type: %v", parseErr))
-		os.Exit(1)
-	}
-
 	setup := &DatabaseSetup{
 		Database:  database,
-		ConnStr:   connStr,
-		DBType:    dbType,
+		ConnStr:   connection.DSN,
+		DBType:    connection.Type,
 		TableName: tableName,
+		Installer: resolveInstaller(ctx, database),
 	}
 
 	// Register this setup for global cleanup
 	SetGlobalDatabaseSetup(setup)
 
-	return setup
+	return setup, nil
 }
 
 // SetupDatabase performs the common database setup steps used across commands
-// Returns DatabaseSetup on success, exits on error
-func SetupDatabase() *DatabaseSetup {
+// Returns a connection error if the database cannot be reached
+func SetupDatabase(ctx context.Context) (*DatabaseSetup, error) {
 	// Get table name from command configuration
 	tableName := GetVersionTableName()
-	return SetupDatabaseWithTableName(tableName)
+	return SetupDatabaseWithTableName(ctx, tableName)
+}
+
+// SetupDatabaseForDirectory sets up the database using the version table of a migration directory
+func SetupDatabaseForDirectory(ctx context.Context, migDir loader.MigrationDirectory) (*DatabaseSetup, error) {
+	if migDir.VersionTable != "" {
+		return SetupDatabaseWithTableName(ctx, migDir.VersionTable)
+	}
+	return SetupDatabase(ctx)
 }
 
-func (ds *DatabaseSetup) CreateMigrationTable() error {
-	err := ds.Database.CreateMigrationTable(ds.TableName)
+func (ds *DatabaseSetup) CreateMigrationTable(ctx context.Context) error {
+	err := ds.Database.CreateMigrationTable(ctx, ds.TableName)
 	if err != nil {
-		PrintError(fmt.Sprintf("Failed to create migration table %s: %v", ds.TableName, err))
 		return fmt.Errorf("failed to create migration table %s: %w", ds.TableName, err)
 	}
 	return nil
 }
 
+// AcquireLock serializes commands that change the version table on databases that support
+// locking. The returned function releases the lock; it does nothing if no lock was taken.
+func (ds *DatabaseSetup) AcquireLock(ctx context.Context) (func(), error) {
+	locker, ok := ds.Database.(db.Locker)
+	if !ok {
+		return func() {}, nil
+	}
+	if err := locker.AcquireLock(ctx, ds.TableName, lockTimeout); err != nil {
+		return nil, err
+	}
+	return func() { locker.ReleaseLock(context.WithoutCancel(ctx), ds.TableName) }, nil
+}
+
+// UpgradeMigrationTable brings an existing version table up to this build's layout.
+// Only commands that change the version table call it, while holding the lock.
+func (ds *DatabaseSetup) UpgradeMigrationTable(ctx context.Context) error {
+	if err := ds.Database.UpgradeMigrationTable(ctx, ds.TableName); err != nil {
+		return fmt.Errorf("error upgrading migration table %s: %w", ds.TableName, err)
+	}
+	return nil
+}
+
+// CheckMigrationTable warns about a version table that still needs an upgrade without
+// changing it, and refuses a table written by a newer bloomdb
+func (ds *DatabaseSetup) CheckMigrationTable(ctx context.Context) error {
+	version, err := ds.Database.MigrationTableSchemaVersion(ctx, ds.TableName)
+	if err != nil {
+		return fmt.Errorf("error checking migration table %s: %w", ds.TableName, err)
+	}
+
+	switch {
+	case version > db.HistorySchemaVersion:
+		return &db.SchemaVersionError{Table: ds.TableName, Found: version, Supported: db.HistorySchemaVersion}
+	case version > 0 && version < db.HistorySchemaVersion:
+		PrintWarning("Migration table '%s' has schema version %d and needs an upgrade to version %d - run migrate, baseline or repair to upgrade it",
+			ds.TableName, version, db.HistorySchemaVersion)
+	}
+	return nil
+}
+
 // InsertBaselineRecord inserts a baseline record into the migration table
-func (ds *DatabaseSetup) InsertBaselineRecord(version string) error {
-	err := ds.Database.InsertBaselineRecord(ds.TableName, version)
+func (ds *DatabaseSetup) InsertBaselineRecord(ctx context.Context, version string) error {
+	err := ds.Database.InsertBaselineRecord(ctx, ds.TableName, version, ds.Installer)
 	if err != nil {
-		PrintError(fmt.Sprintf("Failed to insert baseline record: %v", err))
 		return fmt.Errorf("failed to insert baseline record: %w", err)
 	}
 	return nil
 }
 
 // GetMigrationRecords retrieves all migration records from the database
-func (ds *DatabaseSetup) GetMigrationRecords() ([]db.MigrationRecord, error) {
-	return ds.Database.GetMigrationRecords(ds.TableName)
+func (ds *DatabaseSetup) GetMigrationRecords(ctx context.Context) ([]db.MigrationRecord, error) {
+	return ds.Database.GetMigrationRecords(ctx, ds.TableName)
 }
 
 // InsertMigrationRecord inserts a migration record into the database
-func (ds *DatabaseSetup) InsertMigrationRecord(record db.MigrationRecord) error {
-	return ds.Database.InsertMigrationRecord(ds.TableName, record)
+func (ds *DatabaseSetup) InsertMigrationRecord(ctx context.Context, record db.MigrationRecord) error {
+	return ds.Database.InsertMigrationRecord(ctx, ds.TableName, record)
 }
 
 // DeleteFailedMigrationRecords removes all unsuccessful migration records from the version table
-func (ds *DatabaseSetup) UpdateMigrationRecord(installedRank int, version, description string, checksum int64) error {
-	return ds.Database.UpdateMigrationRecord(ds.TableName, installedRank, version, description, checksum)
+func (ds *DatabaseSetup) UpdateMigrationRecord(ctx context.Context, installedRank int, version, description string, checksum int64, checksumSHA256, checksumNormalized string) error {
+	return ds.Database.UpdateMigrationRecord(ctx, ds.TableName, installedRank, version, description, checksum, checksumSHA256, checksumNormalized)
 }
 
-func (ds *DatabaseSetup) UpdateMigrationRecordFull(record db.MigrationRecord) error {
-	return ds.Database.UpdateMigrationRecordFull(ds.TableName, record)
+func (ds *DatabaseSetup) UpdateMigrationRecordFull(ctx context.Context, record db.MigrationRecord) error {
+	return ds.Database.UpdateMigrationRecordFull(ctx, ds.TableName, record)
 }
 
-func (ds *DatabaseSetup) DeleteFailedMigrationRecords() error {
-	return ds.Database.DeleteFailedMigrationRecords(ds.TableName)
+func (ds *DatabaseSetup) DeleteFailedMigrationRecords(ctx context.Context) error {
+	return ds.Database.DeleteFailedMigrationRecords(ctx, ds.TableName)
 }
 
 // CheckBaselineRecordExists checks if a baseline record exists in the version table
-func (ds *DatabaseSetup) CheckBaselineRecordExists() (bool, string, error) {
-	records, err := ds.Database.GetMigrationRecords(ds.TableName)
+func (ds *DatabaseSetup) CheckBaselineRecordExists(ctx context.Context) (bool, string, error) {
+	records, err := ds.Database.GetMigrationRecords(ctx, ds.TableName)
 	if err != nil {
 		return false, "", fmt.Errorf("failed to get migration records: %w", err)
 	}
@@ -169,40 +188,34 @@ func (ds *DatabaseSetup) CheckBaselineRecordExists() (bool, string, error) {
 }
 
 // EnsureTableAndBaselineExist checks if both the migration table and baseline record exist
-func (ds *DatabaseSetup) EnsureTableAndBaselineExist() {
-	tableExists, err := ds.Database.TableExists(ds.TableName)
+// Returns a validation error if either is missing
+func (ds *DatabaseSetup) EnsureTableAndBaselineExist(ctx context.Context) error {
+	tableExists, err := ds.Database.TableExists(ctx, ds.TableName)
 	if err != nil {
-		PrintError(fmt.Sprintf("Error checking table existence: %v", err))
-		ds.Database.Close()
-		os.Exit(1)
+		return fmt.Errorf("error checking table existence: %w", err)
 	}
 
 	if !tableExists {
-		PrintError(fmt.Sprintf("Migration table '%s' does not exist - have you run the baseline command?", ds.TableName))
-		ds.Database.Close()
-		os.Exit(1)
+		return validationError(fmt.Errorf("migration table '%s' does not exist - have you run the baseline command?", ds.TableName))
 	}
 
 	// Check for baseline record
-	baselineExists, baselineVersion, err := ds.CheckBaselineRecordExists()
+	baselineExists, baselineVersion, err := ds.CheckBaselineRecordExists(ctx)
 	if err != nil {
-		PrintError(fmt.Sprintf("Error checking baseline record: %v", err))
-		ds.Database.Close()
-		os.Exit(1)
+		return fmt.Errorf("error checking baseline record: %w", err)
 	}
 
 	if !baselineExists {
-		PrintError(fmt.Sprintf("No baseline record found in migration table '%s' - have you run the baseline command?", ds.TableName))
-		ds.Database.Close()
-		os.Exit(1)
+		return validationError(fmt.Errorf("no baseline record found in migration table '%s' - have you run the baseline command?", ds.TableName))
 	}
 
 	PrintInfo(fmt.Sprintf("Migration table '%s' exists with baseline record version %s", ds.TableName, baselineVersion))
+	return nil
 }
 
 // ExecuteMigration executes a migration SQL script
-func (ds *DatabaseSetup) ExecuteMigration(content string) error {
-	return ds.Database.ExecuteMigration(content)
+func (ds *DatabaseSetup) ExecuteMigration(ctx context.Context, content string) error {
+	return ds.Database.ExecuteMigration(ctx, content)
 }
 
 // Close closes the database connection
@@ -215,16 +228,16 @@ func (ds *DatabaseSetup) Close() {
 // ResolveBaselineVersion determines the baseline version using the correct priority:
 // 1. Existing baseline record in database (if table and record exist)
 // 2. CLI flag value (if provided)
-// 3. Environment variable (BLOOMDB_BASELINE_VERSION)
+// 3. Environment variable (BLOOMDB_BASELINE_VERSION) or config file profile
 // 4. Default value ("1")
-func ResolveBaselineVersion(setup *DatabaseSetup, flagValue string) string {
+func ResolveBaselineVersion(ctx context.Context, setup *DatabaseSetup, flagValue string) string {
 	// Priority 1: Check for existing baseline record in database
-	tableExists, err := setup.Database.TableExists(setup.TableName)
+	tableExists, err := setup.Database.TableExists(ctx, setup.TableName)
 	if err != nil {
 		PrintWarning("Error checking table existence during baseline resolution: %v", err)
 	} else if tableExists {
 		// Table exists, check for baseline record
-		baselineExists, existingVersion, err := setup.CheckBaselineRecordExists()
+		baselineExists, existingVersion, err := setup.CheckBaselineRecordExists(ctx)
 		if err != nil {
 			PrintWarning("Error checking baseline record during resolution: %v", err)
 		} else if baselineExists {
@@ -239,8 +252,17 @@ func ResolveBaselineVersion(setup *DatabaseSetup, flagValue string) string {
 		return flagValue
 	}
 
-	// Priority 3: Environment variable
-	if envVersion := os.Getenv("BLOOMDB_BASELINE_VERSION"); envVersion != "" {
+	// Priority 3: Environment variable or config file
+	if settings != nil {
+		if value := settings.Lookup(config.KeyBaselineVersion); value.Source != "default" && value.Value != "" {
+			if strings.HasPrefix(value.Source, "env ") {
+				PrintInfo("Using baseline version from environment: " + value.Value)
+			} else {
+				PrintInfo("Using baseline version from config file %s: %s", value.Source, value.Value)
+			}
+			return value.Value
+		}
+	} else if envVersion := os.Getenv("BLOOMDB_BASELINE_VERSION"); envVersion != "" {
 		PrintInfo("Using baseline version from environment: " + envVersion)
 		return envVersion
 	}
@@ -270,3 +292,11 @@ func CalculateNextRank(records []db.MigrationRecord) int {
 	}
 	return maxRank + 1
 }
+
+// currentEnvironment names the environment recorded with migrations: the selected config profile
+func currentEnvironment() string {
+	if settings == nil {
+		return ""
+	}
+	return settings.Profile
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"bloomdb/config"
+	"bloomdb/db"
+	"context"
+)
+
+type ConfigShowCommand struct{}
+
+func (c *ConfigShowCommand) Run(ctx context.Context) error {
+	resolved := GetSettings()
+
+	var entries []ConfigEntry
+	for _, value := range resolved.Values() {
+		switch {
+		case value.Key == config.KeyConnect || value.Key == config.KeyConnectCmd:
+			value.Value = db.RedactCredentials(value.Value)
+		case value.Key == config.KeyPassword && value.Value != "":
+			value.Value = db.RedactedPassword
+		}
+		entries = append(entries, ConfigEntry{
+			Key:    value.Key,
+			Value:  value.Value,
+			Source: value.Source,
+		})
+	}
+
+	DisplayConfig(resolved.File, resolved.Profile, entries)
+	return nil
+}
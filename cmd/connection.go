@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bloomdb/config"
+	"bloomdb/db"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maxConnectRetryDelay caps the exponential backoff between connection attempts
+const maxConnectRetryDelay = 30 * time.Second
+
+// resolveConnectionString finds the connection string, merges a separately stored password
+// and validates the result. Sources in order of precedence:
+// connect (--conn), connect_file, connect_cmd and PG* environment variables.
+func resolveConnectionString() error {
+	if dbConnStr == "" {
+		if file := settings.Get(config.KeyConnectFile); file != "" {
+			content, err := config.ReadSecretFile(file)
+			if err != nil {
+				return configError(fmt.Errorf("failed to read connection string file: %w", err))
+			}
+			dbConnStr = strings.TrimSpace(content)
+			if dbConnStr == "" {
+				return configError(fmt.Errorf("connection string file %s is empty", file))
+			}
+		}
+	}
+
+	if dbConnStr == "" {
+		// Try to get connection string from command
+		if connectCmd := settings.Get(config.KeyConnectCmd); connectCmd != "" {
+			output, err := exec.Command("sh", "-c", connectCmd).Output()
+			if err != nil {
+				return configError(fmt.Errorf("failed to execute connection string command: %w", err))
+			}
+			dbConnStr = strings.TrimSpace(string(output))
+			if dbConnStr == "" {
+				return configError(fmt.Errorf("connection string command returned empty output"))
+			}
+		}
+	}
+
+	// Like psql, PGHOST or PGDATABASE alone select PostgreSQL configured by PG* variables
+	if dbConnStr == "" && db.PostgresEnvironmentSet() {
+		dbConnStr = "postgresql://"
+	}
+
+	if dbConnStr == "" {
+		return configError(fmt.Errorf("connection string is required (use --conn, BLOOMDB_CONNECT_STRING, BLOOMDB_CONNECT_STRING_FILE, BLOOMDB_CONNECT_STRING_CMD env var, connect in bloomdb.yaml, or PG* env vars)"))
+	}
+
+	password, err := resolvePassword()
+	if err != nil {
+		return err
+	}
+	if password != "" {
+		if dbConnStr, err = db.SetPassword(dbConnStr, password); err != nil {
+			return configError(fmt.Errorf("cannot apply password: %w", err))
+		}
+	}
+
+	// Malformed connection strings fail before any command runs
+	connection, err := db.ParseConfig(dbConnStr)
+	if err != nil {
+		return configError(err)
+	}
+
+	if connection.Type == db.PostgreSQL && connection.Password == "" {
+		return applyPgpass(connection)
+	}
+	return nil
+}
+
+// resolvePassword returns the password set with BLOOMDB_PASSWORD or BLOOMDB_PASSWORD_FILE
+func resolvePassword() (string, error) {
+	password := settings.Get(config.KeyPassword)
+	file := settings.Get(config.KeyPasswordFile)
+	if password != "" && file != "" {
+		return "", configError(fmt.Errorf("password and password_file cannot be combined"))
+	}
+	if file != "" {
+		content, err := config.ReadSecretFile(file)
+		if err != nil {
+			return "", configError(fmt.Errorf("failed to read password file: %w", err))
+		}
+		if content == "" {
+			return "", configError(fmt.Errorf("password file %s is empty", file))
+		}
+		return content, nil
+	}
+	return password, nil
+}
+
+// applyPgpass sets the password of a PostgreSQL connection from the .pgpass file, if any line matches
+func applyPgpass(connection *db.Config) error {
+	file := db.PgpassFile()
+	if file == "" {
+		return nil
+	}
+
+	password, err := db.LookupPgpass(file, connection)
+	var permissionErr *db.PgpassPermissionError
+	if errors.As(err, &permissionErr) {
+		PrintWarning("%v", err)
+		return nil
+	}
+	if err != nil {
+		return configError(err)
+	}
+	if password == "" {
+		return nil
+	}
+
+	PrintInfo("Using password from %s", file)
+	if dbConnStr, err = db.SetPassword(dbConnStr, password); err != nil {
+		return configError(fmt.Errorf("cannot apply password from %s: %w", file, err))
+	}
+	return nil
+}
+
+// connectWithRetry connects to the database and tests the connection. Failed attempts
+// are retried up to retries times, waiting interval before the first retry and twice
+// as long after each further one, up to maxConnectRetryDelay. Negative retries keep
+// trying until ctx is done.
+func connectWithRetry(ctx context.Context, connection *db.Config, retries int, interval time.Duration) (db.Database, error) {
+	database, err := db.NewDatabase(connection.Type)
+	if err != nil {
+		return nil, configError(fmt.Errorf("error creating database: %w", err))
+	}
+
+	delay := interval
+	for attempt := 1; ; attempt++ {
+		err := connect(ctx, database, connection)
+		if err == nil {
+			return database, nil
+		}
+		database.Close()
+
+		if ctx.Err() == nil && (retries < 0 || attempt <= retries) {
+			if retries >= 0 {
+				PrintWarning("Database not reachable, retrying in %s (%d/%d): %v", delay, attempt, retries, err)
+			} else {
+				PrintWarning("Database not reachable, retrying in %s (attempt %d): %v", delay, attempt, err)
+			}
+			select {
+			case <-time.After(delay):
+				delay = min(delay*2, maxConnectRetryDelay)
+				continue
+			case <-ctx.Done():
+			}
+		}
+
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, cancelledError(fmt.Errorf("connecting to the database was cancelled: %w", ctx.Err()))
+		}
+		return nil, connectionError(err)
+	}
+}
+
+// connect opens the database and pings it, which also checks the credentials
+func connect(ctx context.Context, database db.Database, connection *db.Config) error {
+	if err := database.Connect(ctx, connection.DSN); err != nil {
+		return fmt.Errorf("error connecting to database: %w", err)
+	}
+	if err := database.Ping(ctx); err != nil {
+		return fmt.Errorf("error pinging database: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -9,11 +10,14 @@ import (
 
 type DestroyCommand struct{}
 
-func (d *DestroyCommand) Run() {
+func (d *DestroyCommand) Run(ctx context.Context) error {
 	PrintWarning("Starting destroy command - this is a destructive operation")
 
 	// Setup database connection
-	setup := SetupDatabase()
+	setup, err := SetupDatabase(ctx)
+	if err != nil {
+		return err
+	}
 
 	PrintWarning("This will destroy ALL database objects in " + string(setup.DBType) + " database!")
 	PrintWarning("This includes tables, views, indexes, triggers, and all data.")
@@ -22,21 +26,20 @@ func (d *DestroyCommand) Run() {
 
 	// Get confirmation from user
 	if !getConfirmation() {
-		PrintInfo("Destroy operation cancelled.")
-		return
+		return cancelledError(fmt.Errorf("destroy operation cancelled"))
 	}
 
 	PrintInfo("User confirmed destroy operation - proceeding with destruction")
 	PrintInfo("Destroying all database objects...")
 
 	// Drop all objects based on database type
-	err := setup.Database.DestroyAllObjects()
+	err = setup.Database.DestroyAllObjects(ctx)
 	if err != nil {
-		PrintError("Error destroying database objects: " + err.Error())
-		return
+		return fmt.Errorf("error destroying database objects: %w", err)
 	}
 
 	PrintSuccess("Successfully destroyed all database objects.")
+	return nil
 }
 
 // getConfirmation gets user confirmation before proceeding with destroy
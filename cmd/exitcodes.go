@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"bloomdb/db"
+)
+
+// Process exit codes returned by bloomdb commands
+const (
+	ExitSuccess           = 0 // Command completed successfully
+	ExitGeneralError      = 1 // Unclassified error
+	ExitConfigError       = 2 // Invalid flags, config file, connection string or migration path
+	ExitConnectionError   = 3 // Database could not be reached or authentication failed
+	ExitMigrationFailure  = 4 // A migration or its history record failed
+	ExitValidationFailure = 5 // Checksum mismatch, failed migrations in history, missing baseline, invalid files
+	ExitLockTimeout       = 6 // The migration lock could not be acquired in time
+	ExitUserCancelled     = 7 // The user cancelled the operation or interrupted it with SIGINT/SIGTERM
+)
+
+// CommandError is an error carrying the exit code the process should terminate with
+type CommandError struct {
+	Code int
+	Err  error
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// configError marks an error as a configuration error
+func configError(err error) error {
+	return &CommandError{Code: ExitConfigError, Err: err}
+}
+
+// connectionError marks an error as a database connection error
+func connectionError(err error) error {
+	return &CommandError{Code: ExitConnectionError, Err: err}
+}
+
+// migrationError marks an error as a migration failure
+func migrationError(err error) error {
+	return &CommandError{Code: ExitMigrationFailure, Err: err}
+}
+
+// validationError marks an error as a validation failure
+func validationError(err error) error {
+	return &CommandError{Code: ExitValidationFailure, Err: err}
+}
+
+// cancelledError marks an error as cancelled by the user
+func cancelledError(err error) error {
+	return &CommandError{Code: ExitUserCancelled, Err: err}
+}
+
+// ExitCode returns the process exit code for an error returned by Execute
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	// An interrupted command was cancelled by the user, whatever it was doing
+	if errors.Is(err, context.Canceled) {
+		return ExitUserCancelled
+	}
+
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code
+	}
+
+	// Fall back to the typed errors of the db package
+	var lockErr *db.LockTimeoutError
+	var schemaErr *db.SchemaVersionError
+	var rankErr *db.DuplicateRankError
+	switch {
+	case errors.As(err, &lockErr):
+		return ExitLockTimeout
+	case errors.As(err, &schemaErr), errors.As(err, &rankErr):
+		return ExitValidationFailure
+	case errors.Is(err, db.ErrNotConnected):
+		return ExitConnectionError
+	case errors.Is(err, db.ErrTableNotFound):
+		return ExitValidationFailure
+	case errors.Is(err, db.ErrInvalidIdentifier):
+		return ExitConfigError
+	}
+
+	return ExitGeneralError
+}
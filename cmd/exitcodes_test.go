@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"bloomdb/db"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{
+			name:     "No error",
+			err:      nil,
+			expected: ExitSuccess,
+		},
+		{
+			name:     "Plain error",
+			err:      errors.New("boom"),
+			expected: ExitGeneralError,
+		},
+		{
+			name:     "Config error",
+			err:      configError(errors.New("missing connection string")),
+			expected: ExitConfigError,
+		},
+		{
+			name:     "Connection error",
+			err:      connectionError(errors.New("refused")),
+			expected: ExitConnectionError,
+		},
+		{
+			name:     "Migration error wrapped by directory context",
+			err:      fmt.Errorf("error processing migration directory .: %w", migrationError(errors.New("bad sql"))),
+			expected: ExitMigrationFailure,
+		},
+		{
+			name:     "Validation error",
+			err:      validationError(errors.New("checksum mismatch")),
+			expected: ExitValidationFailure,
+		},
+		{
+			name:     "Lock timeout from db package",
+			err:      fmt.Errorf("migrate: %w", &db.LockTimeoutError{Table: "BLOOMDB_VERSION", Timeout: time.Second}),
+			expected: ExitLockTimeout,
+		},
+		{
+			name:     "Not connected from db package",
+			err:      fmt.Errorf("setup: %w", db.ErrNotConnected),
+			expected: ExitConnectionError,
+		},
+		{
+			name:     "Version table newer than the binary",
+			err:      fmt.Errorf("setup: %w", &db.SchemaVersionError{Table: "BLOOMDB_VERSION", Found: 3, Supported: 2}),
+			expected: ExitValidationFailure,
+		},
+		{
+			name:     "Duplicate ranks in a legacy version table",
+			err:      fmt.Errorf("setup: %w", &db.DuplicateRankError{Table: "BLOOMDB_VERSION", Ranks: []int{3}}),
+			expected: ExitValidationFailure,
+		},
+		{
+			name:     "Invalid table name from db package",
+			err:      fmt.Errorf("baseline: %w", db.ErrInvalidIdentifier),
+			expected: ExitConfigError,
+		},
+		{
+			name:     "Interrupted migration",
+			err:      migrationError(fmt.Errorf("failed to execute statement 1 (line 1): %w", context.Canceled)),
+			expected: ExitUserCancelled,
+		},
+		{
+			name:     "Cancelled",
+			err:      cancelledError(errors.New("destroy operation cancelled")),
+			expected: ExitUserCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestCommandErrorUnwrap(t *testing.T) {
+	inner := errors.New("inner")
+	err := validationError(inner)
+
+	assert.True(t, errors.Is(err, inner))
+	assert.Equal(t, "inner", err.Error())
+}
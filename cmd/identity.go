@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+
+	"bloomdb/db"
+)
+
+// Sizes of the installed_by and installed_from columns of the version table
+const (
+	maxInstalledByLength   = 100
+	maxInstalledFromLength = 255
+)
+
+// ciJobVariables identify the job of common CI systems, checked in order
+var ciJobVariables = []struct {
+	provider string
+	variable string
+}{
+	{"github", "GITHUB_RUN_ID"},
+	{"gitlab", "CI_JOB_ID"},
+	{"jenkins", "BUILD_TAG"},
+	{"circleci", "CIRCLE_WORKFLOW_JOB_ID"},
+	{"buildkite", "BUILDKITE_JOB_ID"},
+	{"azure", "BUILD_BUILDID"},
+	{"bitbucket", "BITBUCKET_BUILD_NUMBER"},
+	{"travis", "TRAVIS_JOB_ID"},
+}
+
+// validateInstalledBy checks a configured installed_by value against the column size
+func validateInstalledBy(value string) error {
+	if len(value) > maxInstalledByLength {
+		return fmt.Errorf("installed_by must be at most %d characters, got %d", maxInstalledByLength, len(value))
+	}
+	return nil
+}
+
+// resolveInstaller determines who applies migrations and from where.
+// installed_by is taken from --installed-by, BLOOMDB_INSTALLED_BY or the config file,
+// then the database session user, then the OS user.
+func resolveInstaller(ctx context.Context, database db.Database) db.Installer {
+	return db.Installer{
+		By:          resolveInstalledBy(ctx, database),
+		From:        installedFrom(),
+		Environment: currentEnvironment(),
+	}
+}
+
+// resolveInstalledBy returns the first available identity, falling back to "bloomdb"
+func resolveInstalledBy(ctx context.Context, database db.Database) string {
+	if installedBy != "" {
+		return installedBy
+	}
+
+	sessionUser, err := database.SessionUser(ctx)
+	if err != nil {
+		PrintInfo("Could not determine the database user, using the OS user: %v", err)
+	} else if sessionUser != "" {
+		return truncate(sessionUser, maxInstalledByLength)
+	}
+
+	if osUser := currentOSUser(); osUser != "" {
+		return truncate(osUser, maxInstalledByLength)
+	}
+	return "bloomdb"
+}
+
+// currentOSUser returns the name of the user running bloomdb
+func currentOSUser() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// installedFrom describes where migrations are applied from: the host name,
+// followed by the CI job in parentheses when running in a known CI system
+func installedFrom() string {
+	host, _ := os.Hostname()
+	job := ciJob()
+	switch {
+	case job == "":
+		return truncate(host, maxInstalledFromLength)
+	case host == "":
+		return truncate(job, maxInstalledFromLength)
+	default:
+		return truncate(fmt.Sprintf("%s (%s)", host, job), maxInstalledFromLength)
+	}
+}
+
+// ciJob returns the CI job identifier as provider:id, or an empty string outside CI
+func ciJob() string {
+	for _, ci := range ciJobVariables {
+		if id := os.Getenv(ci.variable); id != "" {
+			return ci.provider + ":" + id
+		}
+	}
+	return ""
+}
+
+// truncate shortens value to at most limit bytes
+func truncate(value string, limit int) string {
+	if len(value) > limit {
+		return value[:limit]
+	}
+	return value
+}
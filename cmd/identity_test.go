@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"bloomdb/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearCIJobVariables hides the CI system the tests may be running in
+func clearCIJobVariables(t *testing.T) {
+	for _, ci := range ciJobVariables {
+		t.Setenv(ci.variable, "")
+	}
+}
+
+func TestCIJob(t *testing.T) {
+	clearCIJobVariables(t)
+	assert.Empty(t, ciJob())
+
+	t.Setenv("CI_JOB_ID", "4711")
+	assert.Equal(t, "gitlab:4711", ciJob())
+
+	// Earlier entries win
+	t.Setenv("GITHUB_RUN_ID", "99")
+	assert.Equal(t, "github:99", ciJob())
+}
+
+func TestInstalledFrom(t *testing.T) {
+	clearCIJobVariables(t)
+	assert.NotContains(t, installedFrom(), "(")
+
+	t.Setenv("BUILDKITE_JOB_ID", "abc")
+	assert.Contains(t, installedFrom(), "(buildkite:abc)")
+	assert.LessOrEqual(t, len(installedFrom()), maxInstalledFromLength)
+}
+
+func TestResolveInstalledBy(t *testing.T) {
+	ctx := context.Background()
+	database := db.NewSQLiteDatabase()
+	require.NoError(t, database.Connect(ctx, ":memory:"))
+	defer database.Close()
+
+	previous := installedBy
+	defer func() { installedBy = previous }()
+
+	// The configured value wins
+	installedBy = "deployer"
+	assert.Equal(t, "deployer", resolveInstalledBy(ctx, database))
+
+	// SQLite has no users, so the OS user is used
+	installedBy = ""
+	assert.Equal(t, currentOSUser(), resolveInstalledBy(ctx, database))
+	assert.NotEmpty(t, resolveInstalledBy(ctx, database))
+}
+
+func TestValidateInstalledBy(t *testing.T) {
+	assert.NoError(t, validateInstalledBy(""))
+	assert.NoError(t, validateInstalledBy("deployer"))
+	assert.Error(t, validateInstalledBy(string(make([]byte, maxInstalledByLength+1))))
+}
@@ -3,20 +3,18 @@ package cmd
 import (
 	"bloomdb/db"
 	"bloomdb/loader"
+	"context"
 	"fmt"
+	"strings"
 )
 
 type InfoCommand struct{}
 
-func (i *InfoCommand) Run() {
-	// Get migration path from root command
-	migrationPath := GetMigrationPath()
-
-	// Detect migration directories (root or subdirectories)
-	migrationDirs, err := loader.DetectMigrationDirectories(migrationPath)
+func (i *InfoCommand) Run(ctx context.Context) error {
+	// Detect migration directories (root, subdirectories or multiple locations)
+	migrationDirs, err := loader.DetectMigrationLocations(GetMigrationLocations(), IsRecursive())
 	if err != nil {
-		PrintError("Error detecting migration directories: %v", err)
-		return
+		return configError(fmt.Errorf("error detecting migration directories: %w", err))
 	}
 
 	// Process each migration directory
@@ -28,41 +26,47 @@ func (i *InfoCommand) Run() {
 		}
 
 		// Process info for this directory
-		err := i.processInfoDirectory(migDir)
+		err := i.processInfoDirectory(ctx, migDir)
 		if err != nil {
-			PrintError("Error processing info for directory %s: %v", migDir.Path, err)
-			return
+			return fmt.Errorf("error processing info for directory %s: %w", migDir.Path, err)
 		}
 	}
+
+	return nil
 }
 
-func (i *InfoCommand) processInfoDirectory(migDir loader.MigrationDirectory) error {
+func (i *InfoCommand) processInfoDirectory(ctx context.Context, migDir loader.MigrationDirectory) error {
 	// Setup database connection with appropriate table name
-	var setup *DatabaseSetup
-	if migDir.VersionTable != "" {
-		setup = SetupDatabaseWithTableName(migDir.VersionTable)
-	} else {
-		setup = SetupDatabase()
+	setup, err := SetupDatabaseForDirectory(ctx, migDir)
+	if err != nil {
+		return err
+	}
+
+	// Only report an outdated version table; upgrading it is left to the commands that write to it
+	if err := setup.CheckMigrationTable(ctx); err != nil {
+		return err
 	}
 
 	// Ensure migration table and baseline record exist
-	setup.EnsureTableAndBaselineExist()
+	if err := setup.EnsureTableAndBaselineExist(ctx); err != nil {
+		return err
+	}
 
 	// Load migrations from filesystem
-	versionedLoader := loader.NewVersionedMigrationLoader(migDir.Path)
+	versionedLoader := loader.NewVersionedMigrationLoaderForDirectory(migDir)
 	versionedMigrations, err := versionedLoader.LoadMigrations()
 	if err != nil {
-		return fmt.Errorf("error loading versioned migrations: %w", err)
+		return validationError(fmt.Errorf("error loading versioned migrations: %w", err))
 	}
 
-	repeatableLoader := loader.NewRepeatableMigrationLoader(migDir.Path)
+	repeatableLoader := loader.NewRepeatableMigrationLoaderForDirectory(migDir)
 	repeatableMigrations, err := repeatableLoader.LoadRepeatableMigrations()
 	if err != nil {
-		return fmt.Errorf("error loading repeatable migrations: %w", err)
+		return validationError(fmt.Errorf("error loading repeatable migrations: %w", err))
 	}
 
 	// Get existing migration records from database
-	existingRecords, err := setup.GetMigrationRecords()
+	existingRecords, err := setup.GetMigrationRecords(ctx)
 	if err != nil {
 		return fmt.Errorf("error reading migration records: %w", err)
 	}
@@ -71,7 +75,7 @@ func (i *InfoCommand) processInfoDirectory(migDir loader.MigrationDirectory) err
 	baselineVersion := FindBaselineVersion(existingRecords)
 
 	// Build migration status list
-	statuses := buildMigrationStatuses(versionedMigrations, repeatableMigrations, existingRecords, baselineVersion)
+	statuses := buildMigrationStatuses(versionedMigrations, repeatableMigrations, existingRecords, baselineVersion, GetTagSelection(), GetChecksumMode())
 
 	// Display the table
 	DisplayMigrationTable(setup.DBType, setup.TableName, statuses)
@@ -79,8 +83,9 @@ func (i *InfoCommand) processInfoDirectory(migDir loader.MigrationDirectory) err
 	return nil
 }
 
-// buildMigrationStatuses creates a comprehensive list of migration statuses
-func buildMigrationStatuses(versionedMigrations []*loader.VersionedMigration, repeatableMigrations []*loader.RepeatableMigration, records []db.MigrationRecord, baselineVersion string) []MigrationStatus {
+// buildMigrationStatuses creates a comprehensive list of migration statuses.
+// Unapplied migrations that the tag selection does not select are reported as "excluded".
+func buildMigrationStatuses(versionedMigrations []*loader.VersionedMigration, repeatableMigrations []*loader.RepeatableMigration, records []db.MigrationRecord, baselineVersion string, selection loader.TagSelection, mode ChecksumMode) []MigrationStatus {
 	var statuses []MigrationStatus
 
 	// Create a map of existing records for quick lookup
@@ -111,6 +116,7 @@ func buildMigrationStatuses(versionedMigrations []*loader.VersionedMigration, re
 			Version:     migration.Version,
 			Description: migration.Description,
 			Type:        "versioned",
+			Tags:        strings.Join(migration.Tags, ","),
 		}
 
 		// Check if below or equal to baseline first
@@ -118,7 +124,7 @@ func buildMigrationStatuses(versionedMigrations []*loader.VersionedMigration, re
 			status.Status = "below baseline"
 		} else if record, exists := recordMap[migration.Version]; exists {
 			// Check for checksum mismatch using actual file path
-			if validationStatus := validateVersionedMigration(record, migration); validationStatus != "" {
+			if validationStatus := validateVersionedMigration(record, migration, mode); validationStatus != "" {
 				status.Status = validationStatus
 			} else {
 				// Only show as "success" if it was actually applied, not just baselined
@@ -131,15 +137,14 @@ func buildMigrationStatuses(versionedMigrations []*loader.VersionedMigration, re
 						status.Status = "pending"
 					}
 				} else {
-					// Convert success flag to status string
-					if record.Success == 1 {
-						status.Status = "success"
-					} else {
-						status.Status = "failed"
-					}
+					status.Status = recordStatus(record)
 				}
 			}
 			status.InstalledOn = record.InstalledOn
+			status.InstalledBy = record.InstalledBy
+			status.InstalledFrom = record.InstalledFrom
+		} else if !selection.Selects(migration.Tags) {
+			status.Status = "excluded"
 		} else {
 			status.Status = "pending"
 		}
@@ -168,37 +173,49 @@ func buildMigrationStatuses(versionedMigrations []*loader.VersionedMigration, re
 
 		// Create status for missing migration
 		status := MigrationStatus{
-			Version:     version,
-			Description: record.Description,
-			Type:        "versioned",
-			Status:      "missing",
-			InstalledOn: record.InstalledOn,
+			Version:       version,
+			Description:   record.Description,
+			Type:          "versioned",
+			Status:        "missing",
+			InstalledOn:   record.InstalledOn,
+			InstalledBy:   record.InstalledBy,
+			InstalledFrom: record.InstalledFrom,
 		}
 
 		statuses = append(statuses, status)
 	}
 
+	// Changed repeatable migrations and their dependents run again on the next migrate
+	rerun := make(map[string]bool)
+	for _, migration := range findPendingRepeatableMigrations(repeatableMigrations, records) {
+		if !migration.RunAlways {
+			rerun[migration.Description] = true
+		}
+	}
+
 	// Process repeatable migrations
 	for _, migration := range repeatableMigrations {
 		status := MigrationStatus{
 			Version:     "",
 			Description: migration.Description,
 			Type:        "repeatable",
+			Tags:        strings.Join(migration.Tags, ","),
+		}
+		if migration.RunAlways {
+			// Installed on is the time of the last run
+			status.Type = "always"
 		}
 
 		if record, exists := recordMap[migration.Description]; exists {
-			// Check for checksum mismatch using actual file path
-			if validationStatus := validateRepeatableMigration(record, migration); validationStatus != "" {
-				status.Status = validationStatus
-			} else {
-				// Convert success flag to status string
-				if record.Success == 1 {
-					status.Status = "success"
-				} else {
-					status.Status = "failed"
-				}
+			status.Status = recordStatus(record)
+			if record.Success == 1 && rerun[migration.Description] {
+				status.Status = "pending"
 			}
 			status.InstalledOn = record.InstalledOn
+			status.InstalledBy = record.InstalledBy
+			status.InstalledFrom = record.InstalledFrom
+		} else if !selection.Selects(migration.Tags) {
+			status.Status = "excluded"
 		} else {
 			status.Status = "pending"
 		}
@@ -234,24 +251,31 @@ func buildMigrationStatuses(versionedMigrations []*loader.VersionedMigration, re
 	return sortedStatuses
 }
 
-// validateVersionedMigration checks if the versioned migration checksum matches
-func validateVersionedMigration(record db.MigrationRecord, migration *loader.VersionedMigration) string {
+// validateVersionedMigration checks if the versioned migration checksum matches.
+// Cosmetic changes accepted by the checksum mode are reported as "cosmetic".
+func validateVersionedMigration(record db.MigrationRecord, migration *loader.VersionedMigration, mode ChecksumMode) string {
 	// File existence is already guaranteed by the migration being loaded
-	// Check if checksum matches
-	if record.Checksum != nil && *record.Checksum != migration.Checksum {
+	change := compareChecksums(record, migration.Checksum, migration.ChecksumSHA256, migration.ChecksumNormalized)
+	switch {
+	case change == checksumUnchanged:
+		return ""
+	case mode.accepts(change):
+		return "cosmetic"
+	default:
 		return "checksum"
 	}
-
-	return ""
 }
 
-// validateRepeatableMigration checks if the repeatable migration checksum matches
-func validateRepeatableMigration(record db.MigrationRecord, migration *loader.RepeatableMigration) string {
-	// File existence is already guaranteed by the migration being loaded
-	// Check if checksum matches
-	if record.Checksum != nil && *record.Checksum != migration.Checksum {
-		return "checksum"
+// recordStatus converts the outcome of an applied migration to a status string.
+// Migrations skipped or run despite a false precondition are told apart from plain successes.
+func recordStatus(record db.MigrationRecord) string {
+	if record.Success != 1 {
+		return "failed"
+	}
+	switch record.Precondition {
+	case "skipped", "warned":
+		return record.Precondition
+	default:
+		return "success"
 	}
-
-	return ""
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBuildMigrationStatuses(t *testing.T) {
@@ -123,11 +124,50 @@ func TestBuildMigrationStatuses(t *testing.T) {
 			expectedCount:       1,
 			expectedFirstStatus: "success",
 		},
+		{
+			name: "Changed repeatable migration",
+			versionedMigs: []*loader.VersionedMigration{},
+			repeatableMigs: []*loader.RepeatableMigration{
+				{Description: "test_view", Checksum: 99999},
+			},
+			records: []db.MigrationRecord{
+				{Version: nil, Description: "test_view", Type: "repeatable", Checksum: int64Ptr(12345), Success: 1},
+			},
+			baselineVersion:     "",
+			expectedCount:       1,
+			expectedFirstStatus: "pending",
+		},
+		{
+			name: "Repeatable record without checksum",
+			versionedMigs: []*loader.VersionedMigration{},
+			repeatableMigs: []*loader.RepeatableMigration{
+				{Description: "test_view", Checksum: 12345},
+			},
+			records: []db.MigrationRecord{
+				{Version: nil, Description: "test_view", Type: "repeatable", Checksum: nil, Success: 1},
+			},
+			baselineVersion:     "",
+			expectedCount:       1,
+			expectedFirstStatus: "pending",
+		},
+		{
+			name: "Changed repeatable migration that failed",
+			versionedMigs: []*loader.VersionedMigration{},
+			repeatableMigs: []*loader.RepeatableMigration{
+				{Description: "test_view", Checksum: 99999},
+			},
+			records: []db.MigrationRecord{
+				{Version: nil, Description: "test_view", Type: "repeatable", Checksum: int64Ptr(12345), Success: 0},
+			},
+			baselineVersion:     "",
+			expectedCount:       1,
+			expectedFirstStatus: "failed",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := buildMigrationStatuses(tt.versionedMigs, tt.repeatableMigs, tt.records, tt.baselineVersion)
+			result := buildMigrationStatuses(tt.versionedMigs, tt.repeatableMigs, tt.records, tt.baselineVersion, loader.TagSelection{}, ChecksumStrict)
 			assert.Equal(t, tt.expectedCount, len(result))
 			if tt.expectedCount > 0 && tt.expectedFirstStatus != "" {
 				assert.Equal(t, tt.expectedFirstStatus, result[0].Status)
@@ -167,6 +207,20 @@ func TestValidateVersionedMigration(t *testing.T) {
 			},
 			expectedStatus: "checksum",
 		},
+		{
+			name: "SHA-256 mismatch",
+			record: db.MigrationRecord{
+				Version:        stringPtr("1.0"),
+				Checksum:       int64Ptr(12345),
+				ChecksumSHA256: stringPtr("aa"),
+			},
+			migration: &loader.VersionedMigration{
+				Version:        "1.0",
+				Checksum:       12345,
+				ChecksumSHA256: "bb",
+			},
+			expectedStatus: "checksum",
+		},
 		{
 			name: "Nil checksum in record",
 			record: db.MigrationRecord{
@@ -183,61 +237,73 @@ func TestValidateVersionedMigration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := validateVersionedMigration(tt.record, tt.migration)
+			result := validateVersionedMigration(tt.record, tt.migration, ChecksumStrict)
 			assert.Equal(t, tt.expectedStatus, result)
 		})
 	}
 }
 
-func TestValidateRepeatableMigration(t *testing.T) {
-	tests := []struct {
-		name           string
-		record         db.MigrationRecord
-		migration      *loader.RepeatableMigration
-		expectedStatus string
-	}{
-		{
-			name: "Checksum matches",
-			record: db.MigrationRecord{
-				Description: "test_view",
-				Checksum:    int64Ptr(12345),
-			},
-			migration: &loader.RepeatableMigration{
-				Description: "test_view",
-				Checksum:    12345,
-			},
-			expectedStatus: "",
-		},
-		{
-			name: "Checksum mismatch",
-			record: db.MigrationRecord{
-				Description: "test_view",
-				Checksum:    int64Ptr(12345),
-			},
-			migration: &loader.RepeatableMigration{
-				Description: "test_view",
-				Checksum:    99999,
-			},
-			expectedStatus: "checksum",
-		},
-		{
-			name: "Nil checksum in record",
-			record: db.MigrationRecord{
-				Description: "test_view",
-				Checksum:    nil,
-			},
-			migration: &loader.RepeatableMigration{
-				Description: "test_view",
-				Checksum:    12345,
-			},
-			expectedStatus: "",
-		},
+func TestBuildMigrationStatuses_ExcludedByTags(t *testing.T) {
+	versioned := []*loader.VersionedMigration{
+		{Version: "2", Description: "seed", Checksum: 1, Tags: []string{"seed"}},
+		{Version: "3", Description: "users", Checksum: 1},
+	}
+	repeatable := []*loader.RepeatableMigration{
+		{Description: "stats", Checksum: 1, Tags: []string{"backfill"}},
+	}
+	records := []db.MigrationRecord{
+		{Version: stringPtr("1"), Type: "BASELINE", Success: 1},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := validateRepeatableMigration(tt.record, tt.migration)
-			assert.Equal(t, tt.expectedStatus, result)
-		})
+	statuses := buildMigrationStatuses(versioned, repeatable, records, "1", loader.TagSelection{}, ChecksumStrict)
+	require.Len(t, statuses, 3)
+	assert.Equal(t, "excluded", statuses[0].Status)
+	assert.Equal(t, "seed", statuses[0].Tags)
+	assert.Equal(t, "pending", statuses[1].Status)
+	assert.Equal(t, "excluded", statuses[2].Status)
+
+	selection, err := loader.ParseTagSelection("seed,backfill", "")
+	require.NoError(t, err)
+	statuses = buildMigrationStatuses(versioned, repeatable, records, "1", selection, ChecksumStrict)
+	assert.Equal(t, "pending", statuses[0].Status)
+	assert.Equal(t, "pending", statuses[2].Status)
+}
+
+func TestRecordStatus(t *testing.T) {
+	assert.Equal(t, "success", recordStatus(db.MigrationRecord{Success: 1}))
+	assert.Equal(t, "success", recordStatus(db.MigrationRecord{Success: 1, Precondition: "passed"}))
+	assert.Equal(t, "skipped", recordStatus(db.MigrationRecord{Success: 1, Precondition: "skipped"}))
+	assert.Equal(t, "warned", recordStatus(db.MigrationRecord{Success: 1, Precondition: "warned"}))
+	assert.Equal(t, "failed", recordStatus(db.MigrationRecord{Success: 0, Precondition: "warned"}))
+}
+
+func TestBuildMigrationStatuses_RepeatableDependents(t *testing.T) {
+	repeatable := []*loader.RepeatableMigration{
+		{Description: "base_view", Checksum: 2},
+		{Description: "summary", Checksum: 3, Depends: []string{"base_view"}},
+		{Description: "grants", Checksum: 4, RunAlways: true},
 	}
+	records := []db.MigrationRecord{
+		{Description: "base_view", Type: "repeatable", Checksum: int64Ptr(1), Success: 1},
+		{Description: "summary", Type: "repeatable", Checksum: int64Ptr(3), Success: 1},
+		{Description: "grants", Type: "repeatable", Checksum: int64Ptr(1), Success: 1, InstalledOn: "2026-10-18 09:00:00"},
+	}
+
+	statuses := buildMigrationStatuses(nil, repeatable, records, "", loader.TagSelection{}, ChecksumStrict)
+	require.Len(t, statuses, 3)
+	assert.Equal(t, "pending", statuses[0].Status)
+	assert.Equal(t, "pending", statuses[1].Status)
+	assert.Equal(t, "success", statuses[2].Status)
+	assert.Equal(t, "always", statuses[2].Type)
+	assert.Equal(t, "2026-10-18 09:00:00", statuses[2].InstalledOn)
+}
+
+func TestValidateVersionedMigration_ChecksumMode(t *testing.T) {
+	record := db.MigrationRecord{Version: stringPtr("1"), Checksum: int64Ptr(1), ChecksumSHA256: stringPtr("aa"), ChecksumNormalized: stringPtr("nn")}
+	reformatted := &loader.VersionedMigration{Version: "1", Checksum: 2, ChecksumSHA256: "bb", ChecksumNormalized: "nn"}
+	changed := &loader.VersionedMigration{Version: "1", Checksum: 2, ChecksumSHA256: "bb", ChecksumNormalized: "mm"}
+
+	assert.Equal(t, "checksum", validateVersionedMigration(record, reformatted, ChecksumStrict))
+	assert.Equal(t, "cosmetic", validateVersionedMigration(record, reformatted, ChecksumNormalized))
+	assert.Equal(t, "checksum", validateVersionedMigration(record, changed, ChecksumNormalized))
 }
@@ -4,6 +4,8 @@ import (
 	"bloomdb/db"
 	"bloomdb/loader"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -23,12 +25,16 @@ type PostMigrationData struct {
 	TableName      string
 }
 
-func (m *MigrateCommand) Run() {
+func (m *MigrateCommand) Run(ctx context.Context) error {
 	// Detect migration directories (root or subdirectories)
-	migrationDirs, err := loader.DetectMigrationDirectories(migrationPath)
+	migrationDirs, err := loader.DetectMigrationLocations(GetMigrationLocations(), IsRecursive())
 	if err != nil {
-		PrintError("Error detecting migration directories: %v", err)
-		return
+		return configError(fmt.Errorf("error detecting migration directories: %w", err))
+	}
+
+	// Refuse unsigned or modified migration files before touching any database
+	if err := verifySignatures(migrationDirs); err != nil {
+		return err
 	}
 
 	// Process each migration directory
@@ -40,77 +46,68 @@ func (m *MigrateCommand) Run() {
 		}
 
 		// Process migrations for this directory
-		err := m.processMigrationDirectory(migDir)
+		err := m.processMigrationDirectory(ctx, migDir)
 		if err != nil {
-			PrintError("Error processing migration directory %s: %v", migDir.Path, err)
-			return
+			return fmt.Errorf("error processing migration directory %s: %w", migDir.Path, err)
 		}
 	}
 
 	PrintSuccess("All migration directories processed successfully")
+	return nil
 }
 
-func (m *MigrateCommand) processMigrationDirectory(migDir loader.MigrationDirectory) error {
+func (m *MigrateCommand) processMigrationDirectory(ctx context.Context, migDir loader.MigrationDirectory) error {
 	// Setup database connection with appropriate table name
-	var setup *DatabaseSetup
-	if migDir.VersionTable != "" {
-		setup = SetupDatabaseWithTableName(migDir.VersionTable)
-	} else {
-		setup = SetupDatabase()
+	setup, err := SetupDatabaseForDirectory(ctx, migDir)
+	if err != nil {
+		return err
 	}
 
 	// Ensure migration table and baseline record exist
-	setup.EnsureTableAndBaselineExist()
+	if err := setup.EnsureTableAndBaselineExist(ctx); err != nil {
+		return err
+	}
+
+	// Serialize concurrent migrations on databases that support locking
+	release, err := setup.AcquireLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// Bring an existing version table up to this build's layout
+	if err := setup.UpgradeMigrationTable(ctx); err != nil {
+		return err
+	}
 
 	// Get initial database state for tracking deleted objects
-	initialObjects, err := setup.Database.GetDatabaseObjects()
+	initialObjects, err := setup.Database.GetDatabaseObjects(ctx)
 	if err != nil {
 		initialObjects = []db.DatabaseObject{} // Use empty slice as fallback
 	}
 
 	// Load migrations from filesystem
-	versionedLoader := loader.NewVersionedMigrationLoader(migDir.Path)
+	versionedLoader := loader.NewVersionedMigrationLoaderForDirectory(migDir)
 	versionedMigrations, err := versionedLoader.LoadMigrations()
 	if err != nil {
-		return fmt.Errorf("error loading versioned migrations: %w", err)
+		return validationError(fmt.Errorf("error loading versioned migrations: %w", err))
 	}
 
-	repeatableLoader := loader.NewRepeatableMigrationLoader(migDir.Path)
+	repeatableLoader := loader.NewRepeatableMigrationLoaderForDirectory(migDir)
 	repeatableMigrations, err := repeatableLoader.LoadRepeatableMigrations()
 	if err != nil {
-		return fmt.Errorf("error loading repeatable migrations: %w", err)
+		return validationError(fmt.Errorf("error loading repeatable migrations: %w", err))
 	}
 
 	// Read existing migration records
-	records, err := setup.GetMigrationRecords()
+	records, err := setup.GetMigrationRecords(ctx)
 	if err != nil {
 		return fmt.Errorf("error reading migration records: %w", err)
 	}
 
-	// Check for failed migrations (success = 0)
-	for _, record := range records {
-		if record.Success == 0 {
-			PrintError("Found failed migration: %s (version: %s)", record.Description, func() string {
-				if record.Version != nil {
-					return *record.Version
-				}
-				return "repeatable"
-			}())
-			PrintWarning("Please run the repair command to fix failed migrations before continuing.")
-			return fmt.Errorf("failed migration found")
-		}
-	}
-
-	// Validate checksums of applied migrations
-	checksumErrors := validateMigrationChecksums(versionedMigrations, repeatableMigrations, records)
-	if len(checksumErrors) > 0 {
-		PrintError("Checksum validation failed for %d migration(s):", len(checksumErrors))
-		for _, errMsg := range checksumErrors {
-			PrintError("  - %s", errMsg)
-		}
-		PrintWarning("Migration files have been modified after being applied.")
-		PrintWarning("Please run the repair command to update checksums, or restore the original files.")
-		return fmt.Errorf("checksum validation failed")
+	// Check for failed migrations and validate checksums of applied migrations
+	if err := validateAppliedMigrations(versionedMigrations, records, GetChecksumMode()); err != nil {
+		return err
 	}
 
 	// Find the greatest version in the database
@@ -119,6 +116,16 @@ func (m *MigrateCommand) processMigrationDirectory(migDir loader.MigrationDirect
 	// Find pending versioned migrations (versions greater than greatest version)
 	pendingMigrations := findPendingMigrations(versionedMigrations, greatestVersion)
 
+	// Tagged migrations skipped by earlier runs are applied once their tags are selected
+	pendingMigrations = append(findSkippedTaggedMigrations(versionedMigrations, records, greatestVersion), pendingMigrations...)
+	pendingMigrations, excludedMigrations := selectVersionedByTags(pendingMigrations, GetTagSelection())
+	if len(excludedMigrations) > 0 {
+		PrintInfo("Skipping %d versioned migration(s) not selected by tags", len(excludedMigrations))
+		for _, migration := range excludedMigrations {
+			PrintMigration(migration.Version, migration.Description, "excluded")
+		}
+	}
+
 	if len(pendingMigrations) == 0 {
 		PrintInfo("No pending versioned migrations to execute")
 	} else {
@@ -126,15 +133,21 @@ func (m *MigrateCommand) processMigrationDirectory(migDir loader.MigrationDirect
 		for _, migration := range pendingMigrations {
 			PrintMigration(migration.Version, migration.Description, "pending")
 		}
+		warnNonTransactionalDDL(setup)
 
 		// Execute pending versioned migrations
 		for i, migration := range pendingMigrations {
 			PrintCommand(fmt.Sprintf("Executing migration %d/%d: %s", i+1, len(pendingMigrations), migration))
-			executionTime, err := executeVersionedMigration(setup, migration)
+			executionTime, err := executeVersionedMigration(ctx, setup, migration)
+			if errors.Is(err, errPreconditionSkipped) {
+				PrintWarning("Skipped migration %s: precondition not met", migration)
+				continue
+			}
 			if err != nil {
 				PrintError("Migration %s failed: %v", migration, err)
+				printFailedStatement(err)
 				PrintError("Migration process stopped due to failure at step %d/%d", i+1, len(pendingMigrations))
-				return fmt.Errorf("migration %s failed: %w", migration, err)
+				return migrationError(fmt.Errorf("migration %s failed: %w", migration, err))
 			}
 			PrintSuccess("Successfully executed migration: %s (%dms)", migration, executionTime)
 		}
@@ -143,13 +156,20 @@ func (m *MigrateCommand) processMigrationDirectory(migDir loader.MigrationDirect
 	// Handle repeatable migrations
 	if len(repeatableMigrations) > 0 {
 		// Get updated migration records after versioned migrations
-		updatedRecords, err := setup.GetMigrationRecords()
+		updatedRecords, err := setup.GetMigrationRecords(ctx)
 		if err != nil {
 			return fmt.Errorf("error reading updated migration records: %w", err)
 		}
 
 		// Find repeatable migrations that need to be executed
 		pendingRepeatable := findPendingRepeatableMigrations(repeatableMigrations, updatedRecords)
+		pendingRepeatable, excludedRepeatable := selectRepeatableByTags(pendingRepeatable, GetTagSelection())
+		if len(excludedRepeatable) > 0 {
+			PrintInfo("Skipping %d repeatable migration(s) not selected by tags", len(excludedRepeatable))
+			for _, migration := range excludedRepeatable {
+				PrintMigration("", migration.Description, "excluded")
+			}
+		}
 
 		if len(pendingRepeatable) == 0 {
 			PrintInfo("No repeatable migrations need to be executed")
@@ -158,15 +178,23 @@ func (m *MigrateCommand) processMigrationDirectory(migDir loader.MigrationDirect
 			for _, migration := range pendingRepeatable {
 				PrintMigration("", migration.Description, "pending")
 			}
+			if len(pendingMigrations) == 0 {
+				warnNonTransactionalDDL(setup)
+			}
 
 			// Execute pending repeatable migrations
 			for i, migration := range pendingRepeatable {
 				PrintCommand(fmt.Sprintf("Executing repeatable migration %d/%d: %s", i+1, len(pendingRepeatable), migration.Description))
-				executionTime, err := executeRepeatableMigration(setup, migration)
+				executionTime, err := executeRepeatableMigration(ctx, setup, migration)
+				if errors.Is(err, errPreconditionSkipped) {
+					PrintWarning("Skipped repeatable migration %s: precondition not met", migration.Description)
+					continue
+				}
 				if err != nil {
 					PrintError("Repeatable migration %s failed: %v", migration.Description, err)
+					printFailedStatement(err)
 					PrintError("Migration process stopped due to failure at step %d/%d", i+1, len(pendingRepeatable))
-					return fmt.Errorf("repeatable migration %s failed: %w", migration.Description, err)
+					return migrationError(fmt.Errorf("repeatable migration %s failed: %w", migration.Description, err))
 				}
 				PrintSuccess("Successfully executed repeatable migration: %s (%dms)", migration.Description, executionTime)
 			}
@@ -176,7 +204,7 @@ func (m *MigrateCommand) processMigrationDirectory(migDir loader.MigrationDirect
 	PrintSuccess("Migration process completed for directory: %s", migDir.Path)
 
 	// Execute post-migration script if it exists
-	if err := executePostMigrationScript(setup, migDir.Path, postMigrationScript, initialObjects); err != nil {
+	if err := executePostMigrationScript(ctx, setup, migDir.Path, postMigrationScript, initialObjects); err != nil {
 		PrintWarning("Post-migration script failed: %v", err)
 	}
 
@@ -213,10 +241,59 @@ func findPendingMigrations(migrations []*loader.VersionedMigration, greatestVers
 	return pending
 }
 
+// findSkippedTaggedMigrations returns tagged versioned migrations at or below the greatest
+// applied version that were never applied, because earlier runs did not select their tags
+func findSkippedTaggedMigrations(migrations []*loader.VersionedMigration, records []db.MigrationRecord, greatestVersion string) []*loader.VersionedMigration {
+	baselineVersion := FindBaselineVersion(records)
+	applied := make(map[string]bool)
+	for _, record := range records {
+		if record.Version != nil {
+			applied[*record.Version] = true
+		}
+	}
+
+	var skipped []*loader.VersionedMigration
+	for _, migration := range migrations {
+		if len(migration.Tags) == 0 || applied[migration.Version] || greatestVersion == "" ||
+			loader.CompareVersions(migration.Version, greatestVersion) > 0 {
+			continue
+		}
+		if baselineVersion != "" && loader.CompareVersions(migration.Version, baselineVersion) <= 0 {
+			continue
+		}
+		skipped = append(skipped, migration)
+	}
+	return skipped
+}
+
+// selectVersionedByTags splits migrations into those selected by the tag selection and the rest
+func selectVersionedByTags(migrations []*loader.VersionedMigration, selection loader.TagSelection) (selected, excluded []*loader.VersionedMigration) {
+	for _, migration := range migrations {
+		if selection.Selects(migration.Tags) {
+			selected = append(selected, migration)
+		} else {
+			excluded = append(excluded, migration)
+		}
+	}
+	return selected, excluded
+}
+
+// selectRepeatableByTags splits migrations into those selected by the tag selection and the rest
+func selectRepeatableByTags(migrations []*loader.RepeatableMigration, selection loader.TagSelection) (selected, excluded []*loader.RepeatableMigration) {
+	for _, migration := range migrations {
+		if selection.Selects(migration.Tags) {
+			selected = append(selected, migration)
+		} else {
+			excluded = append(excluded, migration)
+		}
+	}
+	return selected, excluded
+}
+
 // executeVersionedMigration executes a versioned migration and records it
-func executeVersionedMigration(setup *DatabaseSetup, migration *loader.VersionedMigration) (int, error) {
+func executeVersionedMigration(ctx context.Context, setup *DatabaseSetup, migration *loader.VersionedMigration) (int, error) {
 	// Get current migration records to find the next installed rank
-	records, err := setup.GetMigrationRecords()
+	records, err := setup.GetMigrationRecords(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("error reading migration records for rank calculation: %w", err)
 	}
@@ -231,16 +308,21 @@ func executeVersionedMigration(setup *DatabaseSetup, migration *loader.Versioned
 	nextRank := maxRank + 1
 
 	record := db.MigrationRecord{
-		InstalledRank: nextRank,
-		Version:       &migration.Version,
-		Description:   migration.Description,
-		Type:          "versioned",
-		Script:        migration.String(),
-		Checksum:      &migration.Checksum,
-		InstalledBy:   "bloomdb",
-	}
-
-	return executeMigrationCommon(setup, migration.Content, migration.Description, record)
+		InstalledRank:      nextRank,
+		Version:            &migration.Version,
+		Description:        migration.Description,
+		Type:               "versioned",
+		Script:             migration.String(),
+		Checksum:           &migration.Checksum,
+		ChecksumSHA256:     &migration.ChecksumSHA256,
+		ChecksumNormalized: &migration.ChecksumNormalized,
+		Tags:               strings.Join(migration.Tags, ","),
+		InstalledBy:        setup.Installer.By,
+		InstalledFrom:      setup.Installer.From,
+		Environment:        setup.Installer.Environment,
+	}
+
+	return executeMigrationCommon(ctx, setup, migration.Content, migration.Description, migration.Directives, record)
 }
 
 // findCreatedObjects compares before and after object lists to find newly created objects
@@ -284,7 +366,7 @@ func findDeletedObjects(before, after []db.DatabaseObject) []db.DatabaseObject {
 }
 
 // executeMigrationCommon contains the shared logic for executing migrations
-func executeMigrationCommon(setup *DatabaseSetup, content, description string, record db.MigrationRecord) (int, error) {
+func executeMigrationCommon(ctx context.Context, setup *DatabaseSetup, content, description string, directives loader.Directives, record db.MigrationRecord) (int, error) {
 	var (
 		beforeObjects  []db.DatabaseObject
 		afterObjects   []db.DatabaseObject
@@ -300,7 +382,7 @@ func executeMigrationCommon(setup *DatabaseSetup, content, description string, r
 
 	// Check if this is a repeatable migration that already exists
 	if record.Type == "repeatable" {
-		records, err := setup.GetMigrationRecords()
+		records, err := setup.GetMigrationRecords(ctx)
 		if err != nil {
 			return 0, fmt.Errorf("error reading migration records: %w", err)
 		}
@@ -311,22 +393,45 @@ func executeMigrationCommon(setup *DatabaseSetup, content, description string, r
 				break
 			}
 		}
+
+		// The record of an earlier run is updated in place, keeping its rank
+		if existingRecord != nil {
+			record.InstalledRank = existingRecord.InstalledRank
+		}
+	}
+
+	// A false precondition aborts, skips the migration or only warns, as on-fail directs
+	if directives.Precondition != "" {
+		record.Precondition, err = evaluatePrecondition(ctx, setup, description, directives)
+		if err != nil {
+			return 0, err
+		}
+		if record.Precondition == "skipped" {
+			record.Success = 1
+			if err := saveMigrationRecord(ctx, setup, record, existingRecord != nil); err != nil {
+				return 0, fmt.Errorf("failed to record migration: %w", err)
+			}
+			return 0, errPreconditionSkipped
+		}
 	}
 
 	// Get database objects before migration
-	beforeObjects, err = setup.Database.GetDatabaseObjects()
+	beforeObjects, err = setup.Database.GetDatabaseObjects(ctx)
 
 	// Measure execution time
 	startTime = time.Now()
 
-	// Execute migration SQL
-	err = setup.ExecuteMigration(content)
+	// Execute migration SQL within the configured timeouts, retrying as directed
+	err = executeWithRetry(ctx, setup, description, content, directives)
 
 	// Calculate execution time in milliseconds
 	executionTime = time.Since(startTime).Milliseconds()
 
+	// Bookkeeping must still happen when the migration was cancelled or timed out
+	ctx = context.WithoutCancel(ctx)
+
 	// Get database objects after migration
-	afterObjects, _ = setup.Database.GetDatabaseObjects()
+	afterObjects, _ = setup.Database.GetDatabaseObjects(ctx)
 
 	// Find and print newly created and deleted objects
 	if beforeObjects != nil && afterObjects != nil {
@@ -364,16 +469,7 @@ func executeMigrationCommon(setup *DatabaseSetup, content, description string, r
 	record.ExecutionTime = int(executionTime)
 	record.Success = successFlag
 
-	var recordErr error
-	if existingRecord != nil {
-		// Update existing repeatable migration record
-		recordErr = setup.UpdateMigrationRecordFull(record)
-	} else {
-		// Insert new migration record
-		recordErr = setup.InsertMigrationRecord(record)
-	}
-
-	if recordErr != nil {
+	if recordErr := saveMigrationRecord(ctx, setup, record, existingRecord != nil); recordErr != nil {
 		// If we can't record the migration, that's a critical error
 		if err != nil {
 			return int(executionTime), fmt.Errorf("migration failed AND failed to record: %v (recording error: %v)", err, recordErr)
@@ -389,19 +485,107 @@ func executeMigrationCommon(setup *DatabaseSetup, content, description string, r
 	return int(executionTime), nil
 }
 
-// executePostMigrationScript looks for and executes a post-migration SQL script with Go templating
-func executePostMigrationScript(setup *DatabaseSetup, migrationPath string, customScriptPath string, initialObjects []db.DatabaseObject) error {
-	var postScriptPath string
+// saveMigrationRecord updates the record of a repeatable migration that ran before, or inserts a new one
+func saveMigrationRecord(ctx context.Context, setup *DatabaseSetup, record db.MigrationRecord, exists bool) error {
+	if exists {
+		return setup.UpdateMigrationRecordFull(ctx, record)
+	}
+	return setup.InsertMigrationRecord(ctx, record)
+}
 
-	// If custom script path is provided, use it
-	if customScriptPath != "" {
-		// Check if it's an absolute path or relative to migration path
-		if filepath.IsAbs(customScriptPath) {
-			postScriptPath = customScriptPath
-		} else {
-			postScriptPath = filepath.Join(migrationPath, customScriptPath)
+// errPreconditionSkipped reports a migration recorded as skipped because its precondition was false
+var errPreconditionSkipped = errors.New("precondition not met, migration skipped")
+
+// evaluatePrecondition runs the precondition of a migration and returns the outcome to
+// record: passed, skipped or warned. With on-fail=fail a false precondition is an error.
+func evaluatePrecondition(ctx context.Context, setup *DatabaseSetup, description string, directives loader.Directives) (string, error) {
+	if statementTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, statementTimeout)
+		defer cancel()
+	}
+
+	passed, err := setup.Database.EvaluatePrecondition(ctx, directives.Precondition)
+	if err != nil {
+		return "", err
+	}
+	if passed {
+		return "passed", nil
+	}
+
+	switch directives.OnFail {
+	case loader.OnFailSkip:
+		return "skipped", nil
+	case loader.OnFailWarn:
+		PrintWarning("Precondition of migration %s not met, running it anyway", description)
+		return "warned", nil
+	default:
+		return "", fmt.Errorf("precondition not met: %s", directives.Precondition)
+	}
+}
+
+// retryDelay is the pause before retrying a migration with a retry directive
+var retryDelay = time.Second
+
+// executeWithRetry runs migration SQL and, after a failure, runs it again up to
+// the number of times given by the retry directive
+func executeWithRetry(ctx context.Context, setup *DatabaseSetup, description, content string, directives loader.Directives) error {
+	err := executeWithTimeouts(ctx, setup, content, directives)
+	for attempt := 1; err != nil && attempt <= directives.Retry; attempt++ {
+		PrintWarning("Migration %s failed, retrying (%d/%d): %v", description, attempt, directives.Retry, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retryDelay):
 		}
+		err = executeWithTimeouts(ctx, setup, content, directives)
+	}
+	return err
+}
+
+// executeWithTimeouts runs migration SQL limited by --migration-timeout for the whole
+// script and by --statement-timeout for each of its statements. A timeout directive
+// replaces both limits and a no-transaction directive skips the surrounding transaction.
+func executeWithTimeouts(ctx context.Context, setup *DatabaseSetup, content string, directives loader.Directives) error {
+	scriptTimeout, stmtTimeout := migrationTimeout, statementTimeout
+	if directives.Timeout > 0 {
+		scriptTimeout, stmtTimeout = directives.Timeout, 0
+	}
+
+	if scriptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, scriptTimeout)
+		defer cancel()
+	}
+	if stmtTimeout > 0 {
+		ctx = db.WithStatementTimeout(ctx, stmtTimeout)
+	}
+	if directives.NoTransaction {
+		ctx = db.WithoutTransaction(ctx)
+	}
+
+	err := setup.ExecuteMigration(ctx, content)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("migration timed out after %s: %w", scriptTimeout, err)
+	}
+	return err
+}
+
+// resolvePostMigrationScript returns the path of the post-migration script run for a migration path,
+// empty if none is configured. Relative paths are relative to the migration path.
+func resolvePostMigrationScript(migrationPath string, customScriptPath string) string {
+	if customScriptPath == "" || filepath.IsAbs(customScriptPath) {
+		return customScriptPath
+	}
+	return filepath.Join(migrationPath, customScriptPath)
+}
+
+// executePostMigrationScript looks for and executes a post-migration SQL script with Go templating
+func executePostMigrationScript(ctx context.Context, setup *DatabaseSetup, migrationPath string, customScriptPath string, initialObjects []db.DatabaseObject) error {
+	postScriptPath := resolvePostMigrationScript(migrationPath, customScriptPath)
 
+	// If custom script path is provided, use it
+	if postScriptPath != "" {
 		// Verify the custom script exists
 		if _, err := os.Stat(postScriptPath); err != nil {
 			return fmt.Errorf("custom post-migration script not found: %s", postScriptPath)
@@ -421,7 +605,7 @@ func executePostMigrationScript(setup *DatabaseSetup, migrationPath string, cust
 	}
 
 	// Get current database objects for template
-	currentObjects, err := setup.Database.GetDatabaseObjects()
+	currentObjects, err := setup.Database.GetDatabaseObjects(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get current database objects: %w", err)
 	}
@@ -467,7 +651,7 @@ func executePostMigrationScript(setup *DatabaseSetup, migrationPath string, cust
 	PrintInfo("Executing post-migration script (%d characters)", len(renderedSQL))
 	PrintCommand("🔧 Executing post-migration script...")
 
-	err = setup.ExecuteMigration(renderedSQL)
+	err = setup.ExecuteMigration(ctx, renderedSQL)
 	if err != nil {
 		return fmt.Errorf("failed to execute post-migration SQL: %w", err)
 	}
@@ -481,8 +665,11 @@ func executePostMigrationScript(setup *DatabaseSetup, migrationPath string, cust
 // A repeatable migration needs to be executed if:
 // 1. No record exists for it in the migration table
 // 2. A record exists but the hash is different (content has changed)
+// 3. A migration it depends on is executed, since recreating that may drop dependent objects
+// The migrations are expected in dependency order, as returned by the loader.
 func findPendingRepeatableMigrations(migrations []*loader.RepeatableMigration, records []db.MigrationRecord) []*loader.RepeatableMigration {
-	var pending []*loader.RepeatableMigration
+	var pending, always []*loader.RepeatableMigration
+	executed := make(map[string]bool)
 
 	// Create a map of existing repeatable migration records by description
 	existingRecords := make(map[string]db.MigrationRecord)
@@ -494,21 +681,45 @@ func findPendingRepeatableMigrations(migrations []*loader.RepeatableMigration, r
 	}
 
 	for _, migration := range migrations {
+		if migration.RunAlways {
+			always = append(always, migration)
+			continue
+		}
+
 		existingRecord, exists := existingRecords[migration.Description]
 
-		// Execute if no record exists or checksum has changed
-		if !exists || (existingRecord.Checksum == nil || *existingRecord.Checksum != migration.Checksum) {
+		// Execute if no record exists, checksum has changed or a dependency is executed
+		if !exists || (existingRecord.Checksum == nil || *existingRecord.Checksum != migration.Checksum) ||
+			!sha256Matches(existingRecord, migration.ChecksumSHA256) || dependsOnAny(migration, executed) {
 			pending = append(pending, migration)
+			executed[migration.Description] = true
 		}
 	}
 
-	return pending
+	// Run-always migrations run on every migrate, after the other repeatable migrations
+	return append(pending, always...)
+}
+
+// sha256Matches reports whether the SHA-256 hash of a record matches the file.
+// Records written before hashes were recorded have none and match any file.
+func sha256Matches(record db.MigrationRecord, checksumSHA256 string) bool {
+	return record.ChecksumSHA256 == nil || *record.ChecksumSHA256 == "" || *record.ChecksumSHA256 == checksumSHA256
+}
+
+// dependsOnAny reports whether migration depends on one of the given migrations
+func dependsOnAny(migration *loader.RepeatableMigration, descriptions map[string]bool) bool {
+	for _, description := range migration.Depends {
+		if descriptions[description] {
+			return true
+		}
+	}
+	return false
 }
 
 // executeRepeatableMigration executes a repeatable migration and records it
-func executeRepeatableMigration(setup *DatabaseSetup, migration *loader.RepeatableMigration) (int, error) {
+func executeRepeatableMigration(ctx context.Context, setup *DatabaseSetup, migration *loader.RepeatableMigration) (int, error) {
 	// Get current migration records to find the next installed rank
-	records, err := setup.GetMigrationRecords()
+	records, err := setup.GetMigrationRecords(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("error reading migration records for rank calculation: %w", err)
 	}
@@ -523,22 +734,78 @@ func executeRepeatableMigration(setup *DatabaseSetup, migration *loader.Repeatab
 	nextRank := maxRank + 1
 
 	record := db.MigrationRecord{
-		InstalledRank: nextRank,
-		Version:       nil, // Empty version for repeatable migrations
-		Description:   migration.Description,
-		Type:          "repeatable",
-		Script:        migration.String(),
-		Checksum:      &migration.Checksum,
-		InstalledBy:   "bloomdb",
+		InstalledRank:      nextRank,
+		Version:            nil, // Empty version for repeatable migrations
+		Description:        migration.Description,
+		Type:               "repeatable",
+		Script:             migration.String(),
+		Checksum:           &migration.Checksum,
+		ChecksumSHA256:     &migration.ChecksumSHA256,
+		ChecksumNormalized: &migration.ChecksumNormalized,
+		Tags:               strings.Join(migration.Tags, ","),
+		InstalledBy:        setup.Installer.By,
+		InstalledFrom:      setup.Installer.From,
+		Environment:        setup.Installer.Environment,
+	}
+
+	return executeMigrationCommon(ctx, setup, migration.Content, migration.Description, migration.Directives, record)
+}
+
+// validateAppliedMigrations fails if an applied migration failed or its file was modified.
+// Cosmetic changes accepted by the checksum mode are reported as warnings.
+func validateAppliedMigrations(versionedMigrations []*loader.VersionedMigration, records []db.MigrationRecord, mode ChecksumMode) error {
+	// Check for failed migrations (success = 0)
+	for _, record := range records {
+		if record.Success == 0 {
+			PrintError("Found failed migration: %s (version: %s)", record.Description, func() string {
+				if record.Version != nil {
+					return *record.Version
+				}
+				return "repeatable"
+			}())
+			PrintWarning("Please run the repair command to fix failed migrations before continuing.")
+			return validationError(fmt.Errorf("failed migration found"))
+		}
 	}
 
-	return executeMigrationCommon(setup, migration.Content, migration.Description, record)
+	var checksumErrors []checksumMismatch
+	cosmeticErrors := 0
+	for _, mismatch := range validateMigrationChecksums(versionedMigrations, records) {
+		if mode.accepts(mismatch.change) {
+			PrintWarning("Accepted cosmetic change to applied migration %s", mismatch.message)
+			continue
+		}
+		if mismatch.change == checksumCosmetic {
+			cosmeticErrors++
+		}
+		checksumErrors = append(checksumErrors, mismatch)
+	}
+	if len(checksumErrors) > 0 {
+		PrintError("Checksum validation failed for %d migration(s):", len(checksumErrors))
+		for _, mismatch := range checksumErrors {
+			PrintError("  - %s", mismatch.message)
+		}
+		PrintWarning("Migration files have been modified after being applied.")
+		if cosmeticErrors > 0 {
+			PrintWarning("%d change(s) only affect comments, whitespace or keyword case and pass with --checksum-mode=normalized.", cosmeticErrors)
+		}
+		PrintWarning("Please run the repair command to update checksums, or restore the original files.")
+		return validationError(fmt.Errorf("checksum validation failed"))
+	}
+	return nil
 }
 
-// validateMigrationChecksums checks if any applied migrations have been modified
-// Returns a slice of error messages for any checksum mismatches found
-func validateMigrationChecksums(versionedMigrations []*loader.VersionedMigration, repeatableMigrations []*loader.RepeatableMigration, records []db.MigrationRecord) []string {
-	var errors []string
+// checksumMismatch is an applied migration whose file was modified
+type checksumMismatch struct {
+	message string
+	change  checksumChange
+}
+
+// validateMigrationChecksums checks if any applied versioned migrations have been modified
+// Returns a mismatch for each modified migration, classified as a cosmetic or semantic change
+// if the record has a normalized checksum. Changed repeatable migrations are not errors; they run again.
+func validateMigrationChecksums(versionedMigrations []*loader.VersionedMigration, records []db.MigrationRecord) []checksumMismatch {
+	var errors []checksumMismatch
 
 	// Create maps for quick lookup
 	versionedMap := make(map[string]*loader.VersionedMigration)
@@ -546,11 +813,6 @@ func validateMigrationChecksums(versionedMigrations []*loader.VersionedMigration
 		versionedMap[migration.Version] = migration
 	}
 
-	repeatableMap := make(map[string]*loader.RepeatableMigration)
-	for _, migration := range repeatableMigrations {
-		repeatableMap[migration.Description] = migration
-	}
-
 	// Check each applied migration record
 	for _, record := range records {
 		// Skip baseline records (they have NULL checksum)
@@ -567,21 +829,41 @@ func validateMigrationChecksums(versionedMigrations []*loader.VersionedMigration
 		if record.Version != nil && *record.Version != "" {
 			version := *record.Version
 			if migration, exists := versionedMap[version]; exists {
+				change := compareChecksums(record, migration.Checksum, migration.ChecksumSHA256, migration.ChecksumNormalized)
+				if change == checksumUnchanged {
+					continue
+				}
+
+				var message string
 				if *record.Checksum != migration.Checksum {
-					errors = append(errors, fmt.Sprintf("V%s - %s (expected: %d, found: %d)", version, record.Description, *record.Checksum, migration.Checksum))
+					message = fmt.Sprintf("V%s - %s (expected: %d, found: %d)", version, record.Description, *record.Checksum, migration.Checksum)
+				} else {
+					message = fmt.Sprintf("V%s - %s (SHA-256 expected: %s, found: %s)", version, record.Description, *record.ChecksumSHA256, migration.ChecksumSHA256)
 				}
+				if record.ChecksumNormalized != nil && *record.ChecksumNormalized != "" {
+					message += ": " + change.String()
+				}
+				errors = append(errors, checksumMismatch{message: message, change: change})
 			}
 			// Note: If migration file is missing, it won't be in the map, but that's a different issue
 			// The info command will show it as "missing"
-		} else {
-			// Check repeatable migrations
-			if migration, exists := repeatableMap[record.Description]; exists {
-				if *record.Checksum != migration.Checksum {
-					errors = append(errors, fmt.Sprintf("R - %s (expected: %d, found: %d)", record.Description, *record.Checksum, migration.Checksum))
-				}
-			}
 		}
 	}
 
 	return errors
 }
+
+// warnNonTransactionalDDL warns that a failed migration may leave partial changes behind
+func warnNonTransactionalDDL(setup *DatabaseSetup) {
+	if setup.DBType == db.MySQL {
+		PrintWarning("MySQL commits DDL statements implicitly: if a migration fails, statements that ran before the failure are NOT rolled back and must be cleaned up manually before running repair")
+	}
+}
+
+// printFailedStatement shows the statement that caused a migration to fail, if known
+func printFailedStatement(err error) {
+	var stmtErr *db.StatementError
+	if errors.As(err, &stmtErr) {
+		PrintError("Failing statement %d at line %d:\n%s", stmtErr.Index, stmtErr.Line, stmtErr.SQL)
+	}
+}
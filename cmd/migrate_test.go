@@ -3,9 +3,12 @@ package cmd
 import (
 	"bloomdb/db"
 	"bloomdb/loader"
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFindGreatestVersion(t *testing.T) {
@@ -202,6 +205,26 @@ func TestFindPendingRepeatableMigrations(t *testing.T) {
 			},
 			expectedCount: 1,
 		},
+		{
+			name: "Existing migration with same checksum but different SHA-256",
+			migrations: []*loader.RepeatableMigration{
+				{Description: "test_view", Checksum: 12345, ChecksumSHA256: "bb"},
+			},
+			records: []db.MigrationRecord{
+				{Version: nil, Description: "test_view", Type: "repeatable", Checksum: int64Ptr(12345), ChecksumSHA256: stringPtr("aa")},
+			},
+			expectedCount: 1,
+		},
+		{
+			name: "Run-always migration with same checksum",
+			migrations: []*loader.RepeatableMigration{
+				{Description: "grants", Checksum: 12345, RunAlways: true},
+			},
+			records: []db.MigrationRecord{
+				{Version: nil, Description: "grants", Type: "repeatable", Checksum: int64Ptr(12345)},
+			},
+			expectedCount: 1,
+		},
 	}
 
 	for _, tt := range tests {
@@ -212,18 +235,51 @@ func TestFindPendingRepeatableMigrations(t *testing.T) {
 	}
 }
 
+func TestFindPendingRepeatableMigrations_Dependents(t *testing.T) {
+	migrations := []*loader.RepeatableMigration{
+		{Description: "base_view", Checksum: 2},
+		{Description: "audit", Checksum: 3},
+		{Description: "totals", Checksum: 4, Depends: []string{"base_view"}},
+		{Description: "summary", Checksum: 5, Depends: []string{"totals"}},
+	}
+	records := []db.MigrationRecord{
+		{Description: "base_view", Type: "repeatable", Checksum: int64Ptr(1)},
+		{Description: "audit", Type: "repeatable", Checksum: int64Ptr(3)},
+		{Description: "totals", Type: "repeatable", Checksum: int64Ptr(4)},
+		{Description: "summary", Type: "repeatable", Checksum: int64Ptr(5)},
+	}
+
+	var descriptions []string
+	for _, migration := range findPendingRepeatableMigrations(migrations, records) {
+		descriptions = append(descriptions, migration.Description)
+	}
+	assert.Equal(t, []string{"base_view", "totals", "summary"}, descriptions)
+}
+
+func TestFindPendingRepeatableMigrations_RunAlwaysLast(t *testing.T) {
+	migrations := []*loader.RepeatableMigration{
+		{Description: "grants", RunAlways: true},
+		{Description: "views"},
+		{Description: "stats", RunAlways: true},
+	}
+
+	var descriptions []string
+	for _, migration := range findPendingRepeatableMigrations(migrations, nil) {
+		descriptions = append(descriptions, migration.Description)
+	}
+	assert.Equal(t, []string{"views", "grants", "stats"}, descriptions)
+}
+
 func TestValidateMigrationChecksums(t *testing.T) {
 	tests := []struct {
 		name               string
 		versionedMigs      []*loader.VersionedMigration
-		repeatableMigs     []*loader.RepeatableMigration
 		records            []db.MigrationRecord
 		expectedErrorCount int
 	}{
 		{
 			name:               "No migrations",
 			versionedMigs:      []*loader.VersionedMigration{},
-			repeatableMigs:     []*loader.RepeatableMigration{},
 			records:            []db.MigrationRecord{},
 			expectedErrorCount: 0,
 		},
@@ -232,7 +288,6 @@ func TestValidateMigrationChecksums(t *testing.T) {
 			versionedMigs: []*loader.VersionedMigration{
 				{Version: "1.0", Description: "test", Checksum: 12345},
 			},
-			repeatableMigs: []*loader.RepeatableMigration{},
 			records: []db.MigrationRecord{
 				{Version: stringPtr("1.0"), Description: "test", Type: "versioned", Checksum: int64Ptr(12345), Success: 1},
 			},
@@ -243,30 +298,56 @@ func TestValidateMigrationChecksums(t *testing.T) {
 			versionedMigs: []*loader.VersionedMigration{
 				{Version: "1.0", Description: "test", Checksum: 99999},
 			},
-			repeatableMigs: []*loader.RepeatableMigration{},
 			records: []db.MigrationRecord{
 				{Version: stringPtr("1.0"), Description: "test", Type: "versioned", Checksum: int64Ptr(12345), Success: 1},
 			},
 			expectedErrorCount: 1,
 		},
 		{
-			name: "Repeatable migration checksum matches",
-			versionedMigs: []*loader.VersionedMigration{},
-			repeatableMigs: []*loader.RepeatableMigration{
-				{Description: "test_view", Checksum: 12345},
+			name: "Versioned migration SHA-256 mismatch",
+			versionedMigs: []*loader.VersionedMigration{
+				{Version: "1.0", Description: "test", Checksum: 12345, ChecksumSHA256: "bb"},
 			},
 			records: []db.MigrationRecord{
-				{Version: nil, Description: "test_view", Type: "repeatable", Checksum: int64Ptr(12345), Success: 1},
+				{Version: stringPtr("1.0"), Description: "test", Type: "versioned", Checksum: int64Ptr(12345), ChecksumSHA256: stringPtr("aa"), Success: 1},
+			},
+			expectedErrorCount: 1,
+		},
+		{
+			name: "Versioned migration without recorded SHA-256",
+			versionedMigs: []*loader.VersionedMigration{
+				{Version: "1.0", Description: "test", Checksum: 12345, ChecksumSHA256: "bb"},
+			},
+			records: []db.MigrationRecord{
+				{Version: stringPtr("1.0"), Description: "test", Type: "versioned", Checksum: int64Ptr(12345), Success: 1},
 			},
 			expectedErrorCount: 0,
 		},
 		{
-			name: "Repeatable migration checksum mismatch",
+			name: "Versioned migration with cosmetic change",
+			versionedMigs: []*loader.VersionedMigration{
+				{Version: "1.0", Description: "test", Checksum: 99999, ChecksumSHA256: "bb", ChecksumNormalized: "nn"},
+			},
+			records: []db.MigrationRecord{
+				{Version: stringPtr("1.0"), Description: "test", Type: "versioned", Checksum: int64Ptr(12345), ChecksumSHA256: stringPtr("aa"), ChecksumNormalized: stringPtr("nn"), Success: 1},
+			},
+			expectedErrorCount: 1,
+		},
+		{
+			name: "Changed repeatable migration runs again",
 			versionedMigs: []*loader.VersionedMigration{},
-			repeatableMigs: []*loader.RepeatableMigration{
-				{Description: "test_view", Checksum: 99999},
+			records: []db.MigrationRecord{
+				{Version: nil, Description: "test_view", Type: "repeatable", Checksum: int64Ptr(12345), Success: 1},
+			},
+			expectedErrorCount: 0,
+		},
+		{
+			name: "Only the changed versioned migration is reported",
+			versionedMigs: []*loader.VersionedMigration{
+				{Version: "1.0", Description: "test", Checksum: 99999},
 			},
 			records: []db.MigrationRecord{
+				{Version: stringPtr("1.0"), Description: "test", Type: "versioned", Checksum: int64Ptr(12345), Success: 1},
 				{Version: nil, Description: "test_view", Type: "repeatable", Checksum: int64Ptr(12345), Success: 1},
 			},
 			expectedErrorCount: 1,
@@ -274,7 +355,6 @@ func TestValidateMigrationChecksums(t *testing.T) {
 		{
 			name: "Skip baseline records",
 			versionedMigs: []*loader.VersionedMigration{},
-			repeatableMigs: []*loader.RepeatableMigration{},
 			records: []db.MigrationRecord{
 				{Version: stringPtr("1.0"), Type: "baseline", Checksum: nil},
 			},
@@ -285,7 +365,6 @@ func TestValidateMigrationChecksums(t *testing.T) {
 			versionedMigs: []*loader.VersionedMigration{
 				{Version: "1.0", Description: "test", Checksum: 99999},
 			},
-			repeatableMigs: []*loader.RepeatableMigration{},
 			records: []db.MigrationRecord{
 				{Version: stringPtr("1.0"), Description: "test", Type: "versioned", Checksum: int64Ptr(12345), Success: 0},
 			},
@@ -295,12 +374,31 @@ func TestValidateMigrationChecksums(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			errors := validateMigrationChecksums(tt.versionedMigs, tt.repeatableMigs, tt.records)
+			errors := validateMigrationChecksums(tt.versionedMigs, tt.records)
 			assert.Equal(t, tt.expectedErrorCount, len(errors))
 		})
 	}
 }
 
+func TestValidateMigrationChecksums_ClassifiesChanges(t *testing.T) {
+	migrations := []*loader.VersionedMigration{
+		{Version: "1", Description: "reformatted", Checksum: 2, ChecksumSHA256: "bb", ChecksumNormalized: "nn"},
+		{Version: "2", Description: "changed", Checksum: 2, ChecksumSHA256: "bb", ChecksumNormalized: "mm"},
+		{Version: "3", Description: "legacy", Checksum: 2, ChecksumSHA256: "bb", ChecksumNormalized: "nn"},
+	}
+	records := []db.MigrationRecord{
+		{Version: stringPtr("1"), Description: "reformatted", Type: "versioned", Checksum: int64Ptr(1), ChecksumNormalized: stringPtr("nn"), Success: 1},
+		{Version: stringPtr("2"), Description: "changed", Type: "versioned", Checksum: int64Ptr(1), ChecksumNormalized: stringPtr("nn"), Success: 1},
+		{Version: stringPtr("3"), Description: "legacy", Type: "versioned", Checksum: int64Ptr(1), Success: 1},
+	}
+
+	mismatches := validateMigrationChecksums(migrations, records)
+	require.Len(t, mismatches, 3)
+	assert.Equal(t, checksumMismatch{message: "V1 - reformatted (expected: 1, found: 2): cosmetic change", change: checksumCosmetic}, mismatches[0])
+	assert.Equal(t, checksumMismatch{message: "V2 - changed (expected: 1, found: 2): semantic change", change: checksumSemantic}, mismatches[1])
+	assert.Equal(t, checksumMismatch{message: "V3 - legacy (expected: 1, found: 2)", change: checksumSemantic}, mismatches[2])
+}
+
 func TestFindCreatedObjects(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -427,3 +525,55 @@ func TestFindDeletedObjects(t *testing.T) {
 func int64Ptr(i int64) *int64 {
 	return &i
 }
+
+func TestExecuteWithRetry(t *testing.T) {
+	ctx := context.Background()
+	database := db.NewSQLiteDatabase()
+	require.NoError(t, database.Connect(ctx, ":memory:"))
+	defer database.Close()
+	database.GetDB().SetMaxOpenConns(1)
+	_, err := database.GetDB().Exec("CREATE TABLE attempts (id INTEGER)")
+	require.NoError(t, err)
+
+	previousDelay := retryDelay
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = previousDelay }()
+
+	setup := &DatabaseSetup{Database: database}
+	script := "INSERT INTO attempts VALUES (1);\nINSERT INTO missing VALUES (1);"
+	directives := loader.Directives{NoTransaction: true, Retry: 2}
+
+	err = executeWithRetry(ctx, setup, "flaky", script, directives)
+	require.Error(t, err)
+
+	var attempts int
+	require.NoError(t, database.GetDB().QueryRow("SELECT count(*) FROM attempts").Scan(&attempts))
+	assert.Equal(t, 3, attempts, "expected the first attempt and two retries")
+}
+
+func TestFindSkippedTaggedMigrations(t *testing.T) {
+	migrations := []*loader.VersionedMigration{
+		{Version: "1", Description: "init"},
+		{Version: "2", Description: "seed", Tags: []string{"seed"}},
+		{Version: "3", Description: "backfill", Tags: []string{"backfill"}},
+		{Version: "4", Description: "users"},
+		{Version: "5", Description: "demo", Tags: []string{"demo"}},
+	}
+	records := []db.MigrationRecord{
+		{Version: stringPtr("1"), Type: "BASELINE"},
+		{Version: stringPtr("3"), Type: "versioned"},
+		{Version: stringPtr("4"), Type: "versioned"},
+	}
+
+	skipped := findSkippedTaggedMigrations(migrations, records, findGreatestVersion(records))
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "2", skipped[0].Version)
+
+	selection, err := loader.ParseTagSelection("demo", "")
+	require.NoError(t, err)
+	selected, excluded := selectVersionedByTags(migrations, selection)
+	assert.Len(t, selected, 3)
+	assert.Len(t, excluded, 2)
+	assert.Equal(t, "2", excluded[0].Version)
+	assert.Equal(t, "3", excluded[1].Version)
+}
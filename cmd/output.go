@@ -3,12 +3,14 @@ package cmd
 import (
 	"bloomdb/db"
 	"bloomdb/printer"
+	"fmt"
 )
 
 // Re-export types from printer package for backward compatibility
 type OutputLevel = printer.OutputLevel
 type MigrationStatus = printer.MigrationStatus
 type Printer = printer.Printer
+type ConfigEntry = printer.ConfigEntry
 
 // Re-export constants from printer package
 const (
@@ -26,46 +28,43 @@ func InitPrinter() {
 	printerInstance = printer.New()
 }
 
+// redact formats a message and removes credentials of any connection string it contains
+func redact(message string, args ...interface{}) string {
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	return db.RedactCredentials(message)
+}
+
 // Package-level print functions that delegate to global printer
 
-// PrintOutput prints formatted output using the global printer
+// PrintOutput prints formatted output using the global printer.
+// Every message helper goes through it, so connection strings are always redacted.
 func PrintOutput(level OutputLevel, message string, args ...interface{}) {
 	if printerInstance == nil {
 		InitPrinter()
 	}
-	printerInstance.PrintOutput(level, message, args...)
+	printerInstance.PrintOutput(level, "%s", redact(message, args...))
 }
 
 // PrintSuccess prints a success message
 func PrintSuccess(message string, args ...interface{}) {
-	if printerInstance == nil {
-		InitPrinter()
-	}
-	printerInstance.PrintSuccess(message, args...)
+	PrintOutput(LevelSuccess, message, args...)
 }
 
 // PrintWarning prints a warning message
 func PrintWarning(message string, args ...interface{}) {
-	if printerInstance == nil {
-		InitPrinter()
-	}
-	printerInstance.PrintWarning(message, args...)
+	PrintOutput(LevelWarning, message, args...)
 }
 
 // PrintError prints an error message
 func PrintError(message string, args ...interface{}) {
-	if printerInstance == nil {
-		InitPrinter()
-	}
-	printerInstance.PrintError(message, args...)
+	PrintOutput(LevelError, message, args...)
 }
 
 // PrintInfo prints an info message (only in verbose mode)
 func PrintInfo(message string, args ...interface{}) {
-	if printerInstance == nil {
-		InitPrinter()
-	}
-	printerInstance.PrintInfo(message, args...)
+	PrintOutput(LevelInfo, message, args...)
 }
 
 // PrintSeparator prints a beautiful separator line
@@ -73,7 +72,7 @@ func PrintSeparator(title string) {
 	if printerInstance == nil {
 		InitPrinter()
 	}
-	printerInstance.PrintSeparator(title)
+	printerInstance.PrintSeparator(redact(title))
 }
 
 // PrintCommand prints a command being executed
@@ -81,7 +80,7 @@ func PrintCommand(cmd string) {
 	if printerInstance == nil {
 		InitPrinter()
 	}
-	printerInstance.PrintCommand(cmd)
+	printerInstance.PrintCommand(redact(cmd))
 }
 
 // PrintSection prints a section header
@@ -89,7 +88,7 @@ func PrintSection(title string) {
 	if printerInstance == nil {
 		InitPrinter()
 	}
-	printerInstance.PrintSection(title)
+	printerInstance.PrintSection(redact(title))
 }
 
 // PrintSectionEnd prints a section footer
@@ -105,7 +104,7 @@ func PrintMigration(version, description, status string) {
 	if printerInstance == nil {
 		InitPrinter()
 	}
-	printerInstance.PrintMigration(version, description, status)
+	printerInstance.PrintMigration(version, redact(description), status)
 }
 
 // PrintObject prints database object information
@@ -113,7 +112,7 @@ func PrintObject(objType, name string) {
 	if printerInstance == nil {
 		InitPrinter()
 	}
-	printerInstance.PrintObject(objType, name)
+	printerInstance.PrintObject(objType, redact(name))
 }
 
 // DisplayMigrationTable prints a formatted table of migration statuses
@@ -123,3 +122,11 @@ func DisplayMigrationTable(dbType db.DatabaseType, tableName string, statuses []
 	}
 	printerInstance.DisplayMigrationTable(dbType, tableName, statuses)
 }
+
+// DisplayConfig prints the resolved configuration values and their sources
+func DisplayConfig(configFile, profile string, entries []ConfigEntry) {
+	if printerInstance == nil {
+		InitPrinter()
+	}
+	printerInstance.DisplayConfig(configFile, profile, entries)
+}
@@ -2,17 +2,17 @@ package cmd
 
 import (
 	"bloomdb/loader"
+	"context"
 	"fmt"
 )
 
 type RepairCommand struct{}
 
-func (r *RepairCommand) Run() {
+func (r *RepairCommand) Run(ctx context.Context) error {
 	// Detect migration directories (root or subdirectories)
-	migrationDirs, err := loader.DetectMigrationDirectories(migrationPath)
+	migrationDirs, err := loader.DetectMigrationLocations(GetMigrationLocations(), IsRecursive())
 	if err != nil {
-		PrintError("Error detecting migration directories: %v", err)
-		return
+		return configError(fmt.Errorf("error detecting migration directories: %w", err))
 	}
 
 	// Process each migration directory
@@ -24,43 +24,52 @@ func (r *RepairCommand) Run() {
 		}
 
 		// Process repair for this directory
-		err := r.processRepairDirectory(migDir)
+		err := r.processRepairDirectory(ctx, migDir)
 		if err != nil {
-			PrintError("Error processing repair for directory %s: %v", migDir.Path, err)
-			return
+			return fmt.Errorf("error processing repair for directory %s: %w", migDir.Path, err)
 		}
 	}
 
 	PrintSuccess("All migration directories repaired successfully")
+	return nil
 }
 
-func (r *RepairCommand) processRepairDirectory(migDir loader.MigrationDirectory) error {
+func (r *RepairCommand) processRepairDirectory(ctx context.Context, migDir loader.MigrationDirectory) error {
 	// Setup database connection with appropriate table name
-	var setup *DatabaseSetup
-	if migDir.VersionTable != "" {
-		setup = SetupDatabaseWithTableName(migDir.VersionTable)
-	} else {
-		setup = SetupDatabase()
+	setup, err := SetupDatabaseForDirectory(ctx, migDir)
+	if err != nil {
+		return err
 	}
 
 	// Ensure migration table and baseline record exist (repair should only work on initialized databases)
-	setup.EnsureTableAndBaselineExist()
+	if err := setup.EnsureTableAndBaselineExist(ctx); err != nil {
+		return err
+	}
+
+	// Serialize with concurrent migrations, then bring the version table up to date
+	release, err := setup.AcquireLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := setup.UpgradeMigrationTable(ctx); err != nil {
+		return err
+	}
 
 	// Step 1: Remove all records from the version table that are not successful
 	PrintInfo("Step 1: Removing failed migration records...")
-	err := setup.DeleteFailedMigrationRecords()
+	err = setup.DeleteFailedMigrationRecords(ctx)
 	if err != nil {
-		PrintError("Error removing failed migration records: %v", err)
-		return err
+		return fmt.Errorf("error removing failed migration records: %w", err)
 	}
 	PrintSuccess("Failed migration records removed")
 
 	// Step 2: Align checksums and descriptions of versioned migration files to existing entries
+	// and backfill the SHA-256 and normalized hashes of rows written before they were recorded
 	PrintInfo("Step 2: Aligning checksums and descriptions...")
-	err = alignMigrationChecksumsAndDescriptions(setup, migDir.Path)
+	err = alignMigrationChecksumsAndDescriptions(ctx, setup, migDir)
 	if err != nil {
-		PrintError("Error aligning migration checksums and descriptions: %v", err)
-		return err
+		return fmt.Errorf("error aligning migration checksums and descriptions: %w", err)
 	}
 	PrintSuccess("Migration checksums and descriptions aligned")
 
@@ -69,16 +78,22 @@ func (r *RepairCommand) processRepairDirectory(migDir loader.MigrationDirectory)
 }
 
 // alignMigrationChecksumsAndDescriptions updates migration records to match current files
-func alignMigrationChecksumsAndDescriptions(setup *DatabaseSetup, migrationPath string) error {
+func alignMigrationChecksumsAndDescriptions(ctx context.Context, setup *DatabaseSetup, migDir loader.MigrationDirectory) error {
 	// Load versioned migrations from filesystem
-	versionedLoader := loader.NewVersionedMigrationLoader(migrationPath)
+	versionedLoader := loader.NewVersionedMigrationLoaderForDirectory(migDir)
 	versionedMigrations, err := versionedLoader.LoadMigrations()
 	if err != nil {
-		return fmt.Errorf("error loading versioned migrations: %w", err)
+		return validationError(fmt.Errorf("error loading versioned migrations: %w", err))
+	}
+
+	repeatableLoader := loader.NewRepeatableMigrationLoaderForDirectory(migDir)
+	repeatableMigrations, err := repeatableLoader.LoadRepeatableMigrations()
+	if err != nil {
+		return validationError(fmt.Errorf("error loading repeatable migrations: %w", err))
 	}
 
 	// Get existing migration records from database
-	records, err := setup.GetMigrationRecords()
+	records, err := setup.GetMigrationRecords(ctx)
 	if err != nil {
 		return fmt.Errorf("error reading migration records: %w", err)
 	}
@@ -123,7 +138,11 @@ func alignMigrationChecksumsAndDescriptions(setup *DatabaseSetup, migrationPath
 		// Check if checksum needs updating
 		checksumChanged := record.Checksum == nil || *record.Checksum != migration.Checksum
 
-		if descriptionChanged || checksumChanged {
+		// Rows written before SHA-256 hashes were recorded get one backfilled
+		hashChanged := record.ChecksumSHA256 == nil || *record.ChecksumSHA256 != migration.ChecksumSHA256
+		normalizedChanged := record.ChecksumNormalized == nil || *record.ChecksumNormalized != migration.ChecksumNormalized
+
+		if descriptionChanged || checksumChanged || hashChanged || normalizedChanged {
 			PrintInfo("Updating migration record for version %s:", version)
 
 			if descriptionChanged {
@@ -138,8 +157,16 @@ func alignMigrationChecksumsAndDescriptions(setup *DatabaseSetup, migrationPath
 				PrintInfo("  Checksum: %s -> %d", oldChecksum, migration.Checksum)
 			}
 
+			if hashChanged {
+				PrintInfo("  SHA-256: %s -> %s", orNone(record.ChecksumSHA256), migration.ChecksumSHA256)
+			}
+
+			if normalizedChanged {
+				PrintInfo("  Normalized: %s -> %s", orNone(record.ChecksumNormalized), migration.ChecksumNormalized)
+			}
+
 			// Update the record
-			err := setup.UpdateMigrationRecord(record.InstalledRank, version, migration.Description, migration.Checksum)
+			err := setup.UpdateMigrationRecord(ctx, record.InstalledRank, version, migration.Description, migration.Checksum, migration.ChecksumSHA256, migration.ChecksumNormalized)
 			if err != nil {
 				return fmt.Errorf("failed to update migration record for version %s: %w", version, err)
 			}
@@ -148,6 +175,28 @@ func alignMigrationChecksumsAndDescriptions(setup *DatabaseSetup, migrationPath
 		}
 	}
 
+	// Unchanged repeatable migrations only get their hashes; changed ones run again on migrate
+	repeatableMap := make(map[string]*loader.RepeatableMigration)
+	for _, migration := range repeatableMigrations {
+		repeatableMap[migration.Description] = migration
+	}
+	for _, record := range records {
+		if record.Type != "repeatable" || (record.ChecksumSHA256 != nil && record.ChecksumNormalized != nil) || record.Checksum == nil {
+			continue
+		}
+		migration, exists := repeatableMap[record.Description]
+		if !exists || *record.Checksum != migration.Checksum || !sha256Matches(record, migration.ChecksumSHA256) {
+			continue
+		}
+
+		PrintInfo("Backfilling hashes of repeatable migration %s", record.Description)
+		err := setup.UpdateMigrationRecord(ctx, record.InstalledRank, "", record.Description, migration.Checksum, migration.ChecksumSHA256, migration.ChecksumNormalized)
+		if err != nil {
+			return fmt.Errorf("failed to update migration record for repeatable migration %s: %w", record.Description, err)
+		}
+		updatesMade++
+	}
+
 	if updatesMade == 0 {
 		PrintInfo("No migration records needed updating")
 	} else {
@@ -156,3 +205,11 @@ func alignMigrationChecksumsAndDescriptions(setup *DatabaseSetup, migrationPath
 
 	return nil
 }
+
+// orNone returns an optional value for display
+func orNone(value *string) string {
+	if value == nil || *value == "" {
+		return "none"
+	}
+	return *value
+}
@@ -1,101 +1,245 @@
 package cmd
 
 import (
+	"bloomdb/config"
+	"bloomdb/db"
+	"bloomdb/loader"
+	"context"
+	"fmt"
 	"os"
-	"os/exec"
 	"os/signal"
-	"strings"
+	"strconv"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Command annotation controlling whether a database connection is required
+const (
+	annotationConnection = "connection"
+	connectionOptional   = "optional"
 )
 
 var (
-	dbConnStr           string
-	migrationPath       string
-	baselineVersion     string
-	logLevel            string
-	versionTableName    string
-	postMigrationScript string
-	verbose             bool
-	globalSetup         *DatabaseSetup
-	globalSetupMu       sync.RWMutex
+	dbConnStr            string
+	migrationPath        string
+	baselineVersion      string
+	logLevel             string
+	versionTableName     string
+	postMigrationScript  string
+	verbose              bool
+	logSQLArgs           bool
+	recursive            bool
+	statementTimeout     time.Duration
+	migrationTimeout     time.Duration
+	lockTimeout          time.Duration
+	connectRetries       int
+	connectRetryInterval time.Duration
+	installedBy          string
+	includeTags          string
+	excludeTags          string
+	tagSelection         loader.TagSelection
+	checksumModeValue    string
+	checksumMode         ChecksumMode
+	signingKey           string
+	verifyKey            string
+	bundlePath           string
+	bundleDir            string
+	configFile           string
+	profileName          string
+	settings             *config.Resolved
+	globalSetup          *DatabaseSetup
+	globalSetupMu        sync.RWMutex
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "bloomdb",
-	Short: "BloomDB CLI tool",
-	Long:  "A CLI tool for database migration management",
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		// Set verbose flag in environment for printer
-		if verbose {
-			os.Setenv("BLOOMDB_VERBOSE", "true")
+	Use:           "bloomdb",
+	Short:         "BloomDB CLI tool",
+	Long:          "A CLI tool for database migration management",
+	Version:       db.BloomDBVersion,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Resolve settings from flags, environment, config file profile and defaults
+		if err := resolveSettings(cmd); err != nil {
+			InitPrinter()
+			return configError(fmt.Errorf("configuration error: %w", err))
 		}
 
 		// Initialize printer based on BLOOMDB_PRINTER env var (json or human)
 		InitPrinter()
 
-		if dbConnStr == "" {
-			dbConnStr = os.Getenv("BLOOMDB_CONNECT_STRING")
+		// A bundle is extracted and replaces the migration path of the commands reading migrations
+		if err := openBundle(cmd); err != nil {
+			return err
 		}
 
-		if dbConnStr == "" {
-			// Try to get connection string from command
-			if connectCmd := os.Getenv("BLOOMDB_CONNECT_STRING_CMD"); connectCmd != "" {
-				output, err := exec.Command("sh", "-c", connectCmd).Output()
-				if err != nil {
-					PrintError("Failed to execute connection string command: " + err.Error())
-					os.Exit(1)
-				}
-				dbConnStr = strings.TrimSpace(string(output))
-				if dbConnStr == "" {
-					PrintError("Connection string command returned empty output")
-					os.Exit(1)
-				}
-			}
+		// Commands like "config show" work without a database connection
+		if cmd.Annotations[annotationConnection] == connectionOptional {
+			return nil
 		}
 
-		if dbConnStr == "" {
-			PrintError("connection string is required (use --conn, BLOOMDB_CONNECT_STRING, or BLOOMDB_CONNECT_STRING_CMD env var)")
-			os.Exit(1)
+		if err := resolveConnectionString(); err != nil {
+			return err
 		}
 
 		// Note: Baseline version resolution is now deferred until needed
 		// to allow checking for existing baseline records first.
 		// Use ResolveBaselineVersion() in commands that need baseline version.
+		return nil
+	},
+}
 
-		// Handle migration path: flag -> environment -> default
-		// Note: Cobra sets the default, so we need to check if it was explicitly set
-		if !cmd.Flags().Changed("path") {
-			if envPath := os.Getenv("BLOOMDB_PATH"); envPath != "" {
-				migrationPath = envPath
-			}
-		}
+// resolveSettings loads the config file and resolves all settings with the precedence
+// flag -> environment -> profile -> config file -> default
+func resolveSettings(cmd *cobra.Command) error {
+	if configFile == "" {
+		configFile = os.Getenv("BLOOMDB_CONFIG")
+	}
+	if profileName == "" {
+		profileName = os.Getenv("BLOOMDB_PROFILE")
+	}
 
-		// Handle version table name: flag -> environment -> default
-		// Note: Cobra sets the default, so we need to check if it was explicitly set
-		if !cmd.Flags().Changed("table-name") {
-			if envTableName := os.Getenv("BLOOMDB_VERSION_TABLE_NAME"); envTableName != "" {
-				versionTableName = envTableName
-			}
+	var file *config.File
+	if configFile == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to determine working directory: %w", err)
+		}
+		found, err := config.FindFile(wd)
+		if err != nil {
+			return err
 		}
+		configFile = found
+	}
+	if configFile != "" {
+		loaded, err := config.LoadFile(configFile)
+		if err != nil {
+			return err
+		}
+		file = loaded
+	}
+
+	// Collect explicitly set flags
+	flags := make(map[string]string)
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
 
-		// Handle post-migration script: flag -> environment -> default
-		// Note: Cobra sets the default, so we need to check if it was explicitly set
-		if !cmd.Flags().Changed("post-migration-script") {
-			if envPostScript := os.Getenv("BLOOMDB_POST_MIGRATION_SCRIPT"); envPostScript != "" {
-				postMigrationScript = envPostScript
-			}
+	resolved, err := config.Resolve(file, profileName, flags)
+	if err != nil {
+		return err
+	}
+	settings = resolved
+
+	dbConnStr = settings.Get(config.KeyConnect)
+	migrationPath = settings.Get(config.KeyPath)
+	recursive = settings.GetBool(config.KeyRecursive)
+	versionTableName = settings.Get(config.KeyTableName)
+	postMigrationScript = settings.Get(config.KeyPostMigrationScript)
+	logLevel = settings.Get(config.KeyLogLevel)
+	verbose = settings.GetBool(config.KeyVerbose)
+	logSQLArgs = settings.GetBool(config.KeyLogSQLArgs)
+
+	installedBy = settings.Get(config.KeyInstalledBy)
+	if err := validateInstalledBy(installedBy); err != nil {
+		return err
+	}
+	includeTags = settings.Get(config.KeyTags)
+	excludeTags = settings.Get(config.KeyExcludeTags)
+	if tagSelection, err = loader.ParseTagSelection(includeTags, excludeTags); err != nil {
+		return err
+	}
+
+	checksumModeValue = settings.Get(config.KeyChecksumMode)
+	if checksumMode, err = parseChecksumMode(checksumModeValue); err != nil {
+		return err
+	}
+
+	signingKey = settings.Get(config.KeySigningKey)
+	verifyKey = settings.Get(config.KeyVerifyKey)
+	bundlePath = settings.Get(config.KeyBundle)
+
+	if statementTimeout, err = settings.GetDuration(config.KeyStatementTimeout); err != nil {
+		return err
+	}
+	if migrationTimeout, err = settings.GetDuration(config.KeyMigrationTimeout); err != nil {
+		return err
+	}
+	if lockTimeout, err = settings.GetDuration(config.KeyLockTimeout); err != nil {
+		return err
+	}
+	if lockTimeout < 0 {
+		return fmt.Errorf("lock_timeout must not be negative, got %s", lockTimeout)
+	}
+	if connectRetries, err = settings.GetInt(config.KeyConnectRetries); err != nil {
+		return err
+	}
+	if connectRetryInterval, err = settings.GetDuration(config.KeyConnectRetryInterval); err != nil {
+		return err
+	}
+	if connectRetryInterval <= 0 {
+		return fmt.Errorf("connect_retry_interval must be positive, got %s", connectRetryInterval)
+	}
+
+	// Printer, verbose mode, SQL argument logging and filters are read from the environment
+	// by other packages, so values coming from the config file are exported there
+	if verbose {
+		os.Setenv("BLOOMDB_VERBOSE", "true")
+	}
+	os.Setenv("BLOOMDB_LOG_SQL_ARGS", strconv.FormatBool(logSQLArgs))
+	for _, key := range []string{config.KeyPrinter, config.KeyFilterHard, config.KeyFilterSoft} {
+		value := settings.Lookup(key)
+		if value.Source != "default" && value.Value != "" {
+			os.Setenv(settingEnvVar(key), value.Value)
 		}
+	}
 
-		// Setup global database cleanup on program exit
-		setupGlobalCleanup()
-	},
+	return nil
+}
+
+// settingEnvVar returns the environment variable of a setting
+func settingEnvVar(key string) string {
+	for _, setting := range config.Settings {
+		if setting.Key == key {
+			return setting.EnvVar
+		}
+	}
+	return ""
 }
 
+// Execute runs the root command and prints any returned error
+// Use ExitCode to map the returned error to the process exit code.
+// SIGINT and SIGTERM cancel the context passed to the running command.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			// A second signal terminates the process immediately
+			signal.Stop(signals)
+			PrintWarning("Received signal %v, cancelling the running operation...", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	cleanupGlobalDatabase()
+	cleanupBundle()
+	if err != nil {
+		PrintError("%v", err)
+	}
+	return err
 }
 
 // SetGlobalDatabaseSetup sets the global database setup for cleanup tracking
@@ -118,22 +262,6 @@ func GetGlobalDatabaseSetup() *DatabaseSetup {
 	return globalSetup
 }
 
-// setupGlobalCleanup sets up signal handlers for graceful shutdown
-func setupGlobalCleanup() {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-
-	go func() {
-		sig := <-c
-		PrintInfo("Received signal %v, shutting down gracefully...", sig)
-		cleanupGlobalDatabase()
-		os.Exit(0)
-	}()
-
-	// Register cleanup on exit
-	defer cleanupGlobalDatabase()
-}
-
 // cleanupGlobalDatabase cleans up the global database connection
 func cleanupGlobalDatabase() {
 	globalSetupMu.Lock()
@@ -155,6 +283,36 @@ func GetMigrationPath() string {
 	return migrationPath
 }
 
+// GetMigrationLocations returns the migration locations from the comma-separated path
+func GetMigrationLocations() []string {
+	return loader.SplitLocations(migrationPath)
+}
+
+// IsRecursive returns true if nested subdirectories form a single migration set
+func IsRecursive() bool {
+	return recursive
+}
+
+// GetSettings returns the resolved settings including their sources
+func GetSettings() *config.Resolved {
+	return settings
+}
+
+// GetTagSelection returns the tagged migrations selected with --tags and --exclude-tags
+func GetTagSelection() loader.TagSelection {
+	return tagSelection
+}
+
+// GetChecksumMode returns whether cosmetic changes to applied migrations pass validation
+func GetChecksumMode() ChecksumMode {
+	return checksumMode
+}
+
+// GetVerifyKey returns the path of the public key that migration signatures are verified with
+func GetVerifyKey() string {
+	return verifyKey
+}
+
 // GetVersionTableName returns the version table name
 func GetVersionTableName() string {
 	return versionTableName
@@ -167,17 +325,37 @@ func GetPostMigrationScript() string {
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&dbConnStr, "conn", "", "Database connection string (env: BLOOMDB_CONNECT_STRING)")
-	rootCmd.PersistentFlags().StringVar(&migrationPath, "path", ".", "Directory containing migration files (env: BLOOMDB_PATH)")
+	rootCmd.PersistentFlags().StringVar(&migrationPath, "path", ".", "Directory containing migration files, comma-separated for multiple locations (env: BLOOMDB_PATH)")
+	rootCmd.PersistentFlags().BoolVar(&recursive, "recursive", false, "Include nested subdirectories in a single migration sequence (env: BLOOMDB_RECURSIVE)")
 
 	rootCmd.PersistentFlags().StringVar(&versionTableName, "table-name", "BLOOMDB_VERSION", "Version table name (env: BLOOMDB_VERSION_TABLE_NAME)")
 	rootCmd.PersistentFlags().StringVar(&postMigrationScript, "post-migration-script", "", "Path to post-migration SQL script (env: BLOOMDB_POST_MIGRATION_SCRIPT)")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Path to config file, searched upwards from the current directory by default (env: BLOOMDB_CONFIG)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Config file profile to use (env: BLOOMDB_PROFILE)")
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "warn", "Log level (debug, info, warn, error, fatal, panic)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output (env: BLOOMDB_VERBOSE)")
+	rootCmd.PersistentFlags().BoolVar(&logSQLArgs, "log-sql-args", true, "Show bound argument values in verbose SQL logs (env: BLOOMDB_LOG_SQL_ARGS)")
+	rootCmd.PersistentFlags().DurationVar(&statementTimeout, "statement-timeout", 0, "Maximum duration of a single migration statement, e.g. 30s (env: BLOOMDB_STATEMENT_TIMEOUT)")
+	rootCmd.PersistentFlags().DurationVar(&migrationTimeout, "migration-timeout", 0, "Maximum duration of a single migration script, e.g. 10m (env: BLOOMDB_MIGRATION_TIMEOUT)")
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", 5*time.Minute, "How long migrate, baseline and repair wait for a migration lock held by another process (env: BLOOMDB_LOCK_TIMEOUT)")
+	rootCmd.PersistentFlags().IntVar(&connectRetries, "connect-retries", 0, "Retry connecting to an unreachable database this many times (env: BLOOMDB_CONNECT_RETRIES)")
+	rootCmd.PersistentFlags().DurationVar(&connectRetryInterval, "connect-retry-interval", time.Second, "Wait before the first connection retry, doubled after each one up to 30s (env: BLOOMDB_CONNECT_RETRY_INTERVAL)")
+	rootCmd.PersistentFlags().StringVar(&installedBy, "installed-by", "", "Name recorded as installed_by, defaults to the database or OS user (env: BLOOMDB_INSTALLED_BY)")
+
+	// Report invalid flags as configuration errors
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return configError(err)
+	})
 
 	// Add subcommands
 	rootCmd.AddCommand(migrateCmd)
 	rootCmd.AddCommand(infoCmd)
+	rootCmd.AddCommand(validateCmd)
 	rootCmd.AddCommand(repairCmd)
 	rootCmd.AddCommand(baselineCmd)
 	rootCmd.AddCommand(destroyCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(signCmd)
+	rootCmd.AddCommand(bundleCmd)
+	rootCmd.AddCommand(waitCmd)
 }
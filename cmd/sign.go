@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"bloomdb/loader"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+type SignCommand struct{}
+
+func (s *SignCommand) Run(ctx context.Context) error {
+	if signingKey == "" {
+		return configError(fmt.Errorf("signing key is required (use --key, BLOOMDB_SIGNING_KEY or signing_key in bloomdb.yaml)"))
+	}
+	key, err := loader.ReadPrivateKey(signingKey)
+	if err != nil {
+		return configError(err)
+	}
+
+	// Detect migration directories (root, subdirectories or multiple locations)
+	migrationDirs, err := loader.DetectMigrationLocations(GetMigrationLocations(), IsRecursive())
+	if err != nil {
+		return configError(fmt.Errorf("error detecting migration directories: %w", err))
+	}
+
+	// Every location gets its own manifest, covering nested subdirectories in recursive mode
+	// and the post-migration script that migrate runs after the location's migrations
+	for _, migDir := range migrationDirs {
+		script := resolvePostMigrationScript(migDir.Path, GetPostMigrationScript())
+		if script != "" {
+			if _, err := os.Stat(script); err != nil {
+				return configError(fmt.Errorf("post-migration script not found: %s", script))
+			}
+		}
+		for _, location := range migDir.GetLocations() {
+			count, err := loader.SignLocation(location, migDir.Recursive, script, key)
+			if err != nil {
+				return validationError(fmt.Errorf("error signing %s: %w", location, err))
+			}
+			PrintSuccess("Signed %d file(s) in %s", count, location)
+		}
+	}
+
+	return nil
+}
+
+// verifySignatures checks every migration location and the post-migration script against
+// the signed manifests if a verify key is configured. Nothing is verified without one.
+func verifySignatures(migrationDirs []loader.MigrationDirectory) error {
+	if GetVerifyKey() == "" {
+		return nil
+	}
+	key, err := loader.ReadPublicKey(GetVerifyKey())
+	if err != nil {
+		return configError(err)
+	}
+
+	for _, migDir := range migrationDirs {
+		script := resolvePostMigrationScript(migDir.Path, GetPostMigrationScript())
+		for _, location := range migDir.GetLocations() {
+			err := loader.VerifyLocation(location, migDir.Recursive, script, key)
+			var verifyErr *loader.VerificationError
+			if errors.As(err, &verifyErr) {
+				PrintError("Signature verification failed for %s:", location)
+				for _, problem := range verifyErr.Problems {
+					PrintError("  - %s", problem)
+				}
+				PrintWarning("Only migration files signed with bloomdb sign are allowed to run.")
+				return validationError(fmt.Errorf("signature verification failed for %s", location))
+			}
+			if err != nil {
+				return validationError(fmt.Errorf("error verifying %s: %w", location, err))
+			}
+			PrintInfo("Verified signed manifest of %s", location)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"bloomdb/loader"
+	"context"
+	"fmt"
+)
+
+type ValidateCommand struct{}
+
+func (v *ValidateCommand) Run(ctx context.Context) error {
+	// Detect migration directories (root, subdirectories or multiple locations)
+	migrationDirs, err := loader.DetectMigrationLocations(GetMigrationLocations(), IsRecursive())
+	if err != nil {
+		return configError(fmt.Errorf("error detecting migration directories: %w", err))
+	}
+
+	if err := verifySignatures(migrationDirs); err != nil {
+		return err
+	}
+
+	// Process each migration directory
+	for _, migDir := range migrationDirs {
+		if migDir.IsSubdirectory {
+			PrintInfo("Validating subdirectory: %s (table: %s)", migDir.Name, migDir.VersionTable)
+		} else {
+			PrintInfo("Validating migration directory: %s", migDir.Path)
+		}
+
+		err := v.processValidateDirectory(ctx, migDir)
+		if err != nil {
+			return fmt.Errorf("error validating directory %s: %w", migDir.Path, err)
+		}
+	}
+
+	PrintSuccess("All migration directories validated successfully")
+	return nil
+}
+
+func (v *ValidateCommand) processValidateDirectory(ctx context.Context, migDir loader.MigrationDirectory) error {
+	// Setup database connection with appropriate table name
+	setup, err := SetupDatabaseForDirectory(ctx, migDir)
+	if err != nil {
+		return err
+	}
+
+	// Only report an outdated version table; upgrading it is left to the commands that write to it
+	if err := setup.CheckMigrationTable(ctx); err != nil {
+		return err
+	}
+
+	// Ensure migration table and baseline record exist
+	if err := setup.EnsureTableAndBaselineExist(ctx); err != nil {
+		return err
+	}
+
+	// Load migrations from filesystem; invalid files and directives fail validation too
+	versionedLoader := loader.NewVersionedMigrationLoaderForDirectory(migDir)
+	versionedMigrations, err := versionedLoader.LoadMigrations()
+	if err != nil {
+		return validationError(fmt.Errorf("error loading versioned migrations: %w", err))
+	}
+
+	repeatableLoader := loader.NewRepeatableMigrationLoaderForDirectory(migDir)
+	if _, err := repeatableLoader.LoadRepeatableMigrations(); err != nil {
+		return validationError(fmt.Errorf("error loading repeatable migrations: %w", err))
+	}
+
+	records, err := setup.GetMigrationRecords(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading migration records: %w", err)
+	}
+
+	if err := validateAppliedMigrations(versionedMigrations, records, GetChecksumMode()); err != nil {
+		return err
+	}
+
+	PrintSuccess("Applied migrations match their files in directory: %s", migDir.Path)
+	return nil
+}
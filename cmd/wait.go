@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bloomdb/db"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// waitTimeout limits how long the wait command waits for the database, zero waits indefinitely
+var waitTimeout time.Duration
+
+type WaitCommand struct{}
+
+func (w *WaitCommand) Run(ctx context.Context) error {
+	if waitTimeout < 0 {
+		return configError(fmt.Errorf("timeout must not be negative, got %s", waitTimeout))
+	}
+
+	connection, err := db.ParseConfig(dbConnStr)
+	if err != nil {
+		return configError(err)
+	}
+
+	if waitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, waitTimeout)
+		defer cancel()
+	}
+
+	// Keep trying until the database accepts the connection and the credentials
+	PrintInfo("Waiting for %s", connection)
+	start := time.Now()
+	database, err := connectWithRetry(ctx, connection, -1, connectRetryInterval)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return connectionError(fmt.Errorf("database not ready within %s: %w", waitTimeout, err))
+		}
+		return err
+	}
+	database.Close()
+
+	PrintSuccess("Database is ready after %s", time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,407 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the config file names searched for, in order of preference
+var FileNames = []string{"bloomdb.yaml", "bloomdb.yml"}
+
+// Setting keys as used in the config file
+const (
+	KeyConnect              = "connect"
+	KeyConnectCmd           = "connect_cmd"
+	KeyConnectFile          = "connect_file"
+	KeyPassword             = "password"
+	KeyPasswordFile         = "password_file"
+	KeyConnectRetries       = "connect_retries"
+	KeyConnectRetryInterval = "connect_retry_interval"
+	KeyPath                 = "path"
+	KeyRecursive            = "recursive"
+	KeyTableName            = "table_name"
+	KeyBaselineVersion      = "baseline_version"
+	KeyPostMigrationScript  = "post_migration_script"
+	KeyFilterHard           = "filter_hard"
+	KeyFilterSoft           = "filter_soft"
+	KeyPrinter              = "printer"
+	KeyVerbose              = "verbose"
+	KeyLogLevel             = "log_level"
+	KeyStatementTimeout     = "statement_timeout"
+	KeyMigrationTimeout     = "migration_timeout"
+	KeyLockTimeout          = "lock_timeout"
+	KeyInstalledBy          = "installed_by"
+	KeyTags                 = "tags"
+	KeyExcludeTags          = "exclude_tags"
+	KeyChecksumMode         = "checksum_mode"
+	KeySigningKey           = "signing_key"
+	KeyVerifyKey            = "verify_key"
+	KeyBundle               = "bundle"
+	KeyLogSQLArgs           = "log_sql_args"
+)
+
+// Setting describes a configurable value and where it can be set
+type Setting struct {
+	Key     string // Key in the config file
+	Flag    string // Command-line flag (empty if there is none)
+	EnvVar  string // Environment variable (empty if there is none)
+	Default string // Built-in default value
+	IsPath  bool   // Relative values from the config file are resolved against its directory
+}
+
+// Settings lists all settings in display order
+var Settings = []Setting{
+	{Key: KeyConnect, Flag: "conn", EnvVar: "BLOOMDB_CONNECT_STRING"},
+	{Key: KeyConnectCmd, EnvVar: "BLOOMDB_CONNECT_STRING_CMD"},
+	{Key: KeyConnectFile, EnvVar: "BLOOMDB_CONNECT_STRING_FILE", IsPath: true},
+	{Key: KeyPassword, EnvVar: "BLOOMDB_PASSWORD"},
+	{Key: KeyPasswordFile, EnvVar: "BLOOMDB_PASSWORD_FILE", IsPath: true},
+	{Key: KeyConnectRetries, Flag: "connect-retries", EnvVar: "BLOOMDB_CONNECT_RETRIES", Default: "0"},
+	{Key: KeyConnectRetryInterval, Flag: "connect-retry-interval", EnvVar: "BLOOMDB_CONNECT_RETRY_INTERVAL", Default: "1s"},
+	{Key: KeyPath, Flag: "path", EnvVar: "BLOOMDB_PATH", Default: ".", IsPath: true},
+	{Key: KeyRecursive, Flag: "recursive", EnvVar: "BLOOMDB_RECURSIVE", Default: "false"},
+	{Key: KeyTableName, Flag: "table-name", EnvVar: "BLOOMDB_VERSION_TABLE_NAME", Default: "BLOOMDB_VERSION"},
+	{Key: KeyBaselineVersion, Flag: "version", EnvVar: "BLOOMDB_BASELINE_VERSION", Default: "1"},
+	{Key: KeyPostMigrationScript, Flag: "post-migration-script", EnvVar: "BLOOMDB_POST_MIGRATION_SCRIPT", IsPath: true},
+	{Key: KeyFilterHard, EnvVar: "BLOOMDB_FILTER_HARD"},
+	{Key: KeyFilterSoft, EnvVar: "BLOOMDB_FILTER_SOFT"},
+	{Key: KeyPrinter, EnvVar: "BLOOMDB_PRINTER", Default: "human"},
+	{Key: KeyVerbose, Flag: "verbose", EnvVar: "BLOOMDB_VERBOSE", Default: "false"},
+	{Key: KeyLogSQLArgs, Flag: "log-sql-args", EnvVar: "BLOOMDB_LOG_SQL_ARGS", Default: "true"},
+	{Key: KeyLogLevel, Flag: "log-level", EnvVar: "BLOOMDB_LOG_LEVEL", Default: "warn"},
+	{Key: KeyStatementTimeout, Flag: "statement-timeout", EnvVar: "BLOOMDB_STATEMENT_TIMEOUT", Default: "0s"},
+	{Key: KeyMigrationTimeout, Flag: "migration-timeout", EnvVar: "BLOOMDB_MIGRATION_TIMEOUT", Default: "0s"},
+	{Key: KeyLockTimeout, Flag: "lock-timeout", EnvVar: "BLOOMDB_LOCK_TIMEOUT", Default: "5m"},
+	{Key: KeyInstalledBy, Flag: "installed-by", EnvVar: "BLOOMDB_INSTALLED_BY"},
+	{Key: KeyTags, Flag: "tags", EnvVar: "BLOOMDB_TAGS"},
+	{Key: KeyExcludeTags, Flag: "exclude-tags", EnvVar: "BLOOMDB_EXCLUDE_TAGS"},
+	{Key: KeyChecksumMode, Flag: "checksum-mode", EnvVar: "BLOOMDB_CHECKSUM_MODE", Default: "strict"},
+	{Key: KeySigningKey, Flag: "key", EnvVar: "BLOOMDB_SIGNING_KEY", IsPath: true},
+	{Key: KeyVerifyKey, Flag: "verify-key", EnvVar: "BLOOMDB_VERIFY_KEY", IsPath: true},
+	{Key: KeyBundle, Flag: "bundle", EnvVar: "BLOOMDB_BUNDLE", IsPath: true},
+}
+
+// Profile holds the values of one profile (or the top level of the config file)
+type Profile map[string]string
+
+// File represents a parsed bloomdb.yaml config file
+// Top-level keys apply to every profile, named profiles override them.
+type File struct {
+	Path     string
+	Values   Profile
+	Profiles map[string]Profile
+}
+
+// Value is a resolved setting together with the place it came from
+type Value struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// Resolved holds all resolved settings
+type Resolved struct {
+	File    string // Path of the config file, empty if none was used
+	Profile string // Selected profile, empty if none
+	values  map[string]Value
+}
+
+// FindFile walks up from the given directory and returns the first config file found
+// Returns an empty string if no config file exists
+func FindFile(startDir string) (string, error) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory %s: %w", startDir, err)
+	}
+
+	for {
+		for _, name := range FileNames {
+			candidate := filepath.Join(dir, name)
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadFile reads and parses a config file
+func LoadFile(path string) (*File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	file := &File{
+		Path:     path,
+		Values:   Profile{},
+		Profiles: map[string]Profile{},
+	}
+
+	for key, value := range raw {
+		if key == "profiles" {
+			profiles, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid config file %s: profiles must be a mapping", path)
+			}
+			for name, profileValue := range profiles {
+				profileMap, ok := profileValue.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("invalid config file %s: profile %s must be a mapping", path, name)
+				}
+				profile, err := parseProfile(path, profileMap)
+				if err != nil {
+					return nil, err
+				}
+				file.Profiles[name] = profile
+			}
+			continue
+		}
+
+		values, err := parseProfile(path, map[string]interface{}{key: value})
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range values {
+			file.Values[k] = v
+		}
+	}
+
+	return file, nil
+}
+
+// parseProfile validates the keys of a profile and converts all values to strings
+func parseProfile(path string, raw map[string]interface{}) (Profile, error) {
+	profile := Profile{}
+	for key, value := range raw {
+		setting := lookupSetting(key)
+		if setting == nil {
+			return nil, fmt.Errorf("invalid config file %s: unknown setting %q", path, key)
+		}
+
+		switch v := value.(type) {
+		case nil:
+			continue
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("invalid config file %s: setting %q must be a scalar value", path, key)
+		default:
+			profile[key] = fmt.Sprintf("%v", v)
+		}
+	}
+	return profile, nil
+}
+
+// lookupSetting returns the setting with the given key, or nil if it is unknown
+func lookupSetting(key string) *Setting {
+	for i := range Settings {
+		if Settings[i].Key == key {
+			return &Settings[i]
+		}
+	}
+	return nil
+}
+
+// Resolve resolves all settings using the precedence
+// flag -> environment variable -> profile -> config file top level -> default.
+// flags contains the values of explicitly set command-line flags keyed by flag name.
+// file may be nil if no config file is used.
+func Resolve(file *File, profile string, flags map[string]string) (*Resolved, error) {
+	resolved := &Resolved{
+		Profile: profile,
+		values:  make(map[string]Value),
+	}
+
+	var profileValues Profile
+	if file != nil {
+		resolved.File = file.Path
+		if profile != "" {
+			values, exists := file.Profiles[profile]
+			if !exists {
+				return nil, fmt.Errorf("profile %q not found in config file %s", profile, file.Path)
+			}
+			profileValues = values
+		}
+	} else if profile != "" {
+		return nil, fmt.Errorf("profile %q requested but no config file found (looked for %s)", profile, strings.Join(FileNames, ", "))
+	}
+
+	for _, setting := range Settings {
+		value := Value{Key: setting.Key, Value: setting.Default, Source: "default"}
+
+		if flagValue, exists := flags[setting.Flag]; setting.Flag != "" && exists {
+			value.Value = flagValue
+			value.Source = "flag --" + setting.Flag
+		} else if envValue := os.Getenv(setting.EnvVar); setting.EnvVar != "" && envValue != "" {
+			value.Value = envValue
+			value.Source = "env " + setting.EnvVar
+		} else if profileValue, exists := profileValues[setting.Key]; exists {
+			interpolated, err := file.interpolate(setting.Key, profileValue)
+			if err != nil {
+				return nil, err
+			}
+			value.Value = file.resolvePath(setting, interpolated)
+			value.Source = fmt.Sprintf("%s (profile %s)", file.Path, profile)
+		} else if fileValue, exists := fileValues(file)[setting.Key]; exists {
+			interpolated, err := file.interpolate(setting.Key, fileValue)
+			if err != nil {
+				return nil, err
+			}
+			value.Value = file.resolvePath(setting, interpolated)
+			value.Source = file.Path
+		}
+
+		resolved.values[setting.Key] = value
+	}
+
+	return resolved, nil
+}
+
+// fileValues returns the top-level values of a config file, or nil if there is none
+func fileValues(file *File) Profile {
+	if file == nil {
+		return nil
+	}
+	return file.Values
+}
+
+// referencePattern matches ${env:NAME} and ${file:path} references in config file values,
+// and the escape $${ that stands for a literal ${
+var referencePattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z]*):([^}]*)\}`)
+
+// referenceEscape is written as $${ to keep a literal ${ in a config file value
+const referenceEscape = "$${"
+
+// interpolate replaces ${env:NAME} with the environment variable NAME and ${file:path}
+// with the content of a file, resolved against the config file directory, and $${ with ${.
+// It applies to the config file values in use, from the selected profile or the top level,
+// but not to flags and environment variables. Any other ${prefix:...} reference is an error.
+func (f *File) interpolate(key, value string) (string, error) {
+	var err error
+	result := referencePattern.ReplaceAllStringFunc(value, func(reference string) string {
+		if reference == referenceEscape {
+			return "${"
+		}
+		match := referencePattern.FindStringSubmatch(reference)
+		switch match[1] {
+		case "env":
+			envValue, set := os.LookupEnv(match[2])
+			if !set && err == nil {
+				err = fmt.Errorf("invalid config file %s: setting %q references environment variable %s, which is not set", f.Path, key, match[2])
+			}
+			return envValue
+		case "file":
+			path := match[2]
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(f.Path), path)
+			}
+			content, readErr := ReadSecretFile(path)
+			if readErr != nil && err == nil {
+				err = fmt.Errorf("invalid config file %s: setting %q: %w", f.Path, key, readErr)
+			}
+			return content
+		default:
+			if err == nil {
+				err = fmt.Errorf("invalid config file %s: setting %q has unknown reference %s, use ${env:NAME} or ${file:path}", f.Path, key, reference)
+			}
+			return reference
+		}
+	})
+	return result, err
+}
+
+// ReadSecretFile reads a secret such as a password or connection string from a file,
+// as mounted by Docker and Kubernetes secrets. Trailing line breaks are removed.
+func ReadSecretFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// resolvePath resolves relative paths from the config file against its directory
+// Comma-separated path lists are resolved element by element.
+func (f *File) resolvePath(setting Setting, value string) string {
+	if !setting.IsPath || value == "" {
+		return value
+	}
+
+	baseDir := filepath.Dir(f.Path)
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part != "" && !filepath.IsAbs(part) {
+			part = filepath.Join(baseDir, part)
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, ",")
+}
+
+// Get returns the resolved value of a setting
+func (r *Resolved) Get(key string) string {
+	return r.values[key].Value
+}
+
+// GetBool returns the resolved value of a setting interpreted as a boolean
+func (r *Resolved) GetBool(key string) bool {
+	value := strings.ToLower(r.Get(key))
+	return value == "true" || value == "1" || value == "yes"
+}
+
+// GetDuration returns the resolved value of a setting parsed as a duration such as "30s" or "5m"
+func (r *Resolved) GetDuration(key string) (time.Duration, error) {
+	value := r.Lookup(key)
+	if value.Value == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value.Value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q for %s (from %s): %w", value.Value, key, value.Source, err)
+	}
+	return duration, nil
+}
+
+// GetInt returns the resolved value of a setting parsed as a non-negative integer
+func (r *Resolved) GetInt(key string) (int, error) {
+	value := r.Lookup(key)
+	if value.Value == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(value.Value)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid number %q for %s (from %s), expected a non-negative integer", value.Value, key, value.Source)
+	}
+	return number, nil
+}
+
+// Lookup returns the resolved value of a setting including its source
+func (r *Resolved) Lookup(key string) Value {
+	return r.values[key]
+}
+
+// Values returns all resolved settings in display order
+func (r *Resolved) Values() []Value {
+	values := make([]Value, 0, len(Settings))
+	for _, setting := range Settings {
+		values = append(values, r.values[setting.Key])
+	}
+	return values
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "bloomdb.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestFindFile_WalksUp(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := writeConfigFile(t, tmpDir, "path: ./migrations\n")
+
+	nested := filepath.Join(tmpDir, "a", "b")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+
+	found, err := FindFile(nested)
+	require.NoError(t, err)
+	assert.Equal(t, path, found)
+}
+
+func TestFindFile_NotFound(t *testing.T) {
+	found, err := FindFile(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, "", found)
+}
+
+func TestLoadFile_UnknownSetting(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "conection: sqlite:test.db\n")
+
+	_, err := LoadFile(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown setting "conection"`)
+}
+
+func TestLoadFile_UnknownProfileSetting(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "profiles:\n  prod:\n    tabel_name: X\n")
+
+	_, err := LoadFile(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown setting "tabel_name"`)
+}
+
+func TestResolve_Precedence(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := writeConfigFile(t, tmpDir, `
+connect: sqlite:dev.db
+table_name: FILE_TABLE
+baseline_version: 2
+recursive: true
+profiles:
+  prod:
+    connect: postgres://prod/db
+    table_name: PROFILE_TABLE
+`)
+	file, err := LoadFile(path)
+	require.NoError(t, err)
+
+	t.Setenv("BLOOMDB_VERSION_TABLE_NAME", "ENV_TABLE")
+	t.Setenv("BLOOMDB_BASELINE_VERSION", "")
+
+	resolved, err := Resolve(file, "prod", map[string]string{"conn": "sqlite:flag.db"})
+	require.NoError(t, err)
+
+	// Flag wins over everything
+	assert.Equal(t, "sqlite:flag.db", resolved.Get(KeyConnect))
+	assert.Equal(t, "flag --conn", resolved.Lookup(KeyConnect).Source)
+
+	// Environment wins over the profile
+	assert.Equal(t, "ENV_TABLE", resolved.Get(KeyTableName))
+	assert.Equal(t, "env BLOOMDB_VERSION_TABLE_NAME", resolved.Lookup(KeyTableName).Source)
+
+	// Top-level file values apply when the profile does not override them
+	assert.Equal(t, "2", resolved.Get(KeyBaselineVersion))
+	assert.Equal(t, path, resolved.Lookup(KeyBaselineVersion).Source)
+	assert.True(t, resolved.GetBool(KeyRecursive))
+
+	// Defaults apply otherwise
+	assert.Equal(t, "warn", resolved.Get(KeyLogLevel))
+	assert.Equal(t, "default", resolved.Lookup(KeyLogLevel).Source)
+}
+
+func TestResolve_ProfileOverridesFile(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), `
+table_name: FILE_TABLE
+profiles:
+  prod:
+    table_name: PROFILE_TABLE
+`)
+	file, err := LoadFile(path)
+	require.NoError(t, err)
+
+	t.Setenv("BLOOMDB_VERSION_TABLE_NAME", "")
+
+	resolved, err := Resolve(file, "prod", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "PROFILE_TABLE", resolved.Get(KeyTableName))
+	assert.Equal(t, path+" (profile prod)", resolved.Lookup(KeyTableName).Source)
+
+	resolved, err = Resolve(file, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "FILE_TABLE", resolved.Get(KeyTableName))
+}
+
+func TestResolve_RelativePathsFromConfigDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := writeConfigFile(t, tmpDir, "path: migrations, /abs/migrations\npost_migration_script: post.sql\n")
+	file, err := LoadFile(path)
+	require.NoError(t, err)
+
+	t.Setenv("BLOOMDB_PATH", "")
+	t.Setenv("BLOOMDB_POST_MIGRATION_SCRIPT", "")
+
+	resolved, err := Resolve(file, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "migrations")+",/abs/migrations", resolved.Get(KeyPath))
+	assert.Equal(t, filepath.Join(tmpDir, "post.sql"), resolved.Get(KeyPostMigrationScript))
+}
+
+func TestResolve_UnknownProfile(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "profiles:\n  dev:\n    table_name: X\n")
+	file, err := LoadFile(path)
+	require.NoError(t, err)
+
+	_, err = Resolve(file, "prod", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `profile "prod" not found`)
+}
+
+func TestResolve_ProfileWithoutFile(t *testing.T) {
+	_, err := Resolve(nil, "prod", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no config file found")
+}
+
+func TestResolve_NoFile(t *testing.T) {
+	t.Setenv("BLOOMDB_PATH", "")
+
+	resolved, err := Resolve(nil, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "", resolved.File)
+	assert.Equal(t, ".", resolved.Get(KeyPath))
+	assert.Len(t, resolved.Values(), len(Settings))
+}
+
+func TestResolve_Durations(t *testing.T) {
+	t.Setenv("BLOOMDB_STATEMENT_TIMEOUT", "")
+	t.Setenv("BLOOMDB_MIGRATION_TIMEOUT", "")
+	t.Setenv("BLOOMDB_LOCK_TIMEOUT", "")
+
+	resolved, err := Resolve(nil, "", map[string]string{"statement-timeout": "30s", "migration-timeout": "soon"})
+	require.NoError(t, err)
+
+	timeout, err := resolved.GetDuration(KeyStatementTimeout)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, timeout)
+
+	timeout, err = resolved.GetDuration(KeyLockTimeout)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, timeout)
+
+	_, err = resolved.GetDuration(KeyMigrationTimeout)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration_timeout")
+}
+
+func TestResolve_Integers(t *testing.T) {
+	t.Setenv("BLOOMDB_CONNECT_RETRIES", "")
+
+	resolved, err := Resolve(nil, "", nil)
+	require.NoError(t, err)
+	retries, err := resolved.GetInt(KeyConnectRetries)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retries)
+
+	resolved, err = Resolve(nil, "", map[string]string{"connect-retries": "5"})
+	require.NoError(t, err)
+	retries, err = resolved.GetInt(KeyConnectRetries)
+	require.NoError(t, err)
+	assert.Equal(t, 5, retries)
+
+	t.Setenv("BLOOMDB_CONNECT_RETRIES", "-1")
+	resolved, err = Resolve(nil, "", nil)
+	require.NoError(t, err)
+	_, err = resolved.GetInt(KeyConnectRetries)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "env BLOOMDB_CONNECT_RETRIES")
+}
+
+func TestResolve_Interpolation(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "db-password"), []byte("s3cret\n"), 0600))
+	path := writeConfigFile(t, tmpDir, `
+connect: postgres://${env:TEST_DB_USER}@db/app
+password: ${file:db-password}
+profiles:
+  broken:
+    table_name: ${env:TEST_UNSET_VARIABLE}
+  unknown:
+    table_name: ${vault:secret}
+  escaped:
+    password: p$${x:y}w $${env:TEST_DB_USER} ${env:TEST_DB_USER}
+`)
+	file, err := LoadFile(path)
+	require.NoError(t, err)
+
+	t.Setenv("BLOOMDB_CONNECT_STRING", "")
+	t.Setenv("BLOOMDB_PASSWORD", "")
+	t.Setenv("BLOOMDB_VERSION_TABLE_NAME", "")
+	t.Setenv("TEST_DB_USER", "deploy")
+	os.Unsetenv("TEST_UNSET_VARIABLE")
+
+	// Unused profiles are not interpolated
+	resolved, err := Resolve(file, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://deploy@db/app", resolved.Get(KeyConnect))
+	assert.Equal(t, "s3cret", resolved.Get(KeyPassword))
+
+	_, err = Resolve(file, "broken", nil)
+	assert.ErrorContains(t, err, "references environment variable TEST_UNSET_VARIABLE, which is not set")
+
+	_, err = Resolve(file, "unknown", nil)
+	assert.ErrorContains(t, err, "unknown reference ${vault:secret}")
+
+	// $${ is a literal ${ and starts no reference
+	resolved, err = Resolve(file, "escaped", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "p${x:y}w ${env:TEST_DB_USER} deploy", resolved.Get(KeyPassword))
+}
+
+func TestReadSecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("sqlite:./app.db\r\n"), 0600))
+
+	content, err := ReadSecretFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite:./app.db", content)
+
+	_, err = ReadSecretFile(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorContains(t, err, "failed to read secret file")
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+type statementTimeoutKey struct{}
+
+type noTransactionKey struct{}
+
+// WithStatementTimeout returns a context that limits every statement run by
+// ExecuteMigration to the given duration. A zero or negative duration disables the limit.
+func WithStatementTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, statementTimeoutKey{}, timeout)
+}
+
+// StatementTimeout returns the per-statement timeout stored in ctx, or 0 if none is set
+func StatementTimeout(ctx context.Context) time.Duration {
+	timeout, _ := ctx.Value(statementTimeoutKey{}).(time.Duration)
+	return timeout
+}
+
+// WithoutTransaction returns a context under which ExecuteMigration runs every
+// statement on its own instead of wrapping the migration in a transaction
+func WithoutTransaction(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noTransactionKey{}, true)
+}
+
+// TransactionDisabled reports whether ctx was derived from WithoutTransaction
+func TransactionDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noTransactionKey{}).(bool)
+	return disabled
+}
+
+// statementContext derives the context for a single migration statement
+func statementContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := StatementTimeout(ctx); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
@@ -1,11 +1,13 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type DatabaseType string
@@ -14,24 +16,53 @@ const (
 	SQLite     DatabaseType = "sqlite"
 	PostgreSQL DatabaseType = "postgresql"
 	Oracle     DatabaseType = "oracle"
+	DuckDB     DatabaseType = "duckdb"
+	MySQL      DatabaseType = "mysql"
 )
 
+// BloomDBVersion is the bloomdb release recorded with every migration.
+// Release builds set it with -ldflags "-X bloomdb/db.BloomDBVersion=<version>".
+var BloomDBVersion = "dev"
+
 type Database interface {
-	Connect(connectionString string) error
+	Connect(ctx context.Context, connectionString string) error
 	Close() error
-	Ping() error
+	Ping(ctx context.Context) error
 	GetDB() *sql.DB
-	TableExists(tableName string) (bool, error)
-	CreateMigrationTable(tableName string) error
-	InsertBaselineRecord(tableName, version string) error
-	GetMigrationRecords(tableName string) ([]MigrationRecord, error)
-	InsertMigrationRecord(tableName string, record MigrationRecord) error
-	UpdateMigrationRecord(tableName string, installedRank int, version, description string, checksum int64) error
-	UpdateMigrationRecordFull(tableName string, record MigrationRecord) error
-	DeleteFailedMigrationRecords(tableName string) error
-	ExecuteMigration(content string) error
-	DestroyAllObjects() error
-	GetDatabaseObjects() ([]DatabaseObject, error)
+	TableExists(ctx context.Context, tableName string) (bool, error)
+	CreateMigrationTable(ctx context.Context, tableName string) error
+	// UpgradeMigrationTable brings an existing version table to HistorySchemaVersion.
+	// It does nothing if the table does not exist and returns a *SchemaVersionError
+	// if the table was written by a newer bloomdb.
+	UpgradeMigrationTable(ctx context.Context, tableName string) error
+	// MigrationTableSchemaVersion returns the schema version of an existing version table,
+	// or 0 if the table does not exist. It changes nothing.
+	MigrationTableSchemaVersion(ctx context.Context, tableName string) (int, error)
+	InsertBaselineRecord(ctx context.Context, tableName, version string, installer Installer) error
+	// SessionUser returns the database user of the connection, or an empty string
+	// for databases without users such as SQLite and DuckDB
+	SessionUser(ctx context.Context) (string, error)
+	GetMigrationRecords(ctx context.Context, tableName string) ([]MigrationRecord, error)
+	InsertMigrationRecord(ctx context.Context, tableName string, record MigrationRecord) error
+	// UpdateMigrationRecord realigns the description and checksums of a record with its file.
+	// An empty version selects a repeatable migration.
+	UpdateMigrationRecord(ctx context.Context, tableName string, installedRank int, version, description string, checksum int64, checksumSHA256, checksumNormalized string) error
+	UpdateMigrationRecordFull(ctx context.Context, tableName string, record MigrationRecord) error
+	DeleteFailedMigrationRecords(ctx context.Context, tableName string) error
+	ExecuteMigration(ctx context.Context, content string) error
+	// EvaluatePrecondition runs a query and interprets the first column of its first row
+	// as a boolean. No row and NULL are false.
+	EvaluatePrecondition(ctx context.Context, query string) (bool, error)
+	DestroyAllObjects(ctx context.Context) error
+	GetDatabaseObjects(ctx context.Context) ([]DatabaseObject, error)
+}
+
+// Locker is implemented by databases that can serialize concurrent migrations
+// with a lock scoped to the version table
+type Locker interface {
+	// AcquireLock waits up to timeout for the lock and returns a *LockTimeoutError if it is not granted
+	AcquireLock(ctx context.Context, tableName string, timeout time.Duration) error
+	ReleaseLock(ctx context.Context, tableName string) error
 }
 
 // DatabaseObject represents a database object with its type and name
@@ -52,11 +83,24 @@ type MigrationRecord struct {
 	InstalledOn   string  `json:"installed_on"`
 	ExecutionTime int     `json:"execution_time"`
 	Success       int     `json:"success"`
+	// Columns added in history schema version 2
+	ChecksumSHA256 *string `json:"checksum_sha256,omitempty"`
+	BloomDBVersion string  `json:"bloomdb_version,omitempty"`
+	Tags           string  `json:"tags,omitempty"`
+	Environment    string  `json:"environment,omitempty"`
+	// Column added in history schema version 3
+	InstalledFrom string `json:"installed_from,omitempty"`
+	// Column added in history schema version 4: passed, skipped or warned, empty without a precondition
+	Precondition string `json:"precondition,omitempty"`
+	// Column added in history schema version 5: hash ignoring comments, whitespace and keyword case
+	ChecksumNormalized *string `json:"checksum_normalized,omitempty"`
 }
 
-type Config struct {
-	Type             DatabaseType
-	ConnectionString string
+// Installer identifies who applies migrations and from where
+type Installer struct {
+	By          string // Recorded in installed_by
+	From        string // Host and CI job, recorded in installed_from
+	Environment string // Config profile, recorded in environment
 }
 
 // versionToInt converts a version string to an integer for installed rank
@@ -83,9 +127,13 @@ func logSQL(query string, args ...interface{}) {
 	// Format the SQL for display
 	displayQuery := strings.TrimSpace(query)
 
-	// If there are arguments, show them
+	// If there are arguments, show them unless --log-sql-args=false hides them
 	if len(args) > 0 {
-		fmt.Fprintf(os.Stderr, "[SQL] %s\n[ARGS] %v\n", displayQuery, args)
+		if show, err := strconv.ParseBool(os.Getenv("BLOOMDB_LOG_SQL_ARGS")); err == nil && !show {
+			fmt.Fprintf(os.Stderr, "[SQL] %s\n[ARGS] <%d hidden>\n", displayQuery, len(args))
+			return
+		}
+		fmt.Fprintf(os.Stderr, "[SQL] %s\n[ARGS] %s\n", displayQuery, RedactCredentials(fmt.Sprintf("%v", args)))
 	} else {
 		fmt.Fprintf(os.Stderr, "[SQL] %s\n", displayQuery)
 	}
@@ -0,0 +1,124 @@
+package db
+
+import "strings"
+
+// ColumnKind is a database-independent column type used to describe the version table
+type ColumnKind int
+
+const (
+	// ColumnInteger is a 32-bit integer column
+	ColumnInteger ColumnKind = iota
+	// ColumnBigInt is a 64-bit integer column
+	ColumnBigInt
+	// ColumnVarchar is a bounded string column; the column size is the maximum length
+	ColumnVarchar
+	// ColumnText is a long free-text column
+	ColumnText
+	// ColumnTimestamp is a date and time column
+	ColumnTimestamp
+)
+
+// ObjectQuery lists database objects of one type; the query returns a single name column
+type ObjectQuery struct {
+	Type  string
+	Query string
+}
+
+// Dialect describes how a backend spells the SQL bloomdb generates for its version table.
+// Adding a backend mostly means implementing a Dialect and embedding sqlDatabase.
+type Dialect interface {
+	// Name is the human-readable database name used in error messages
+	Name() string
+	// DriverName is the database/sql driver name passed to sql.Open
+	DriverName() string
+	// Placeholder returns the bind parameter for the n-th (1-based) argument
+	Placeholder(n int) string
+	// QuoteIdentifier quotes a single identifier, such as a column, table or schema name
+	QuoteIdentifier(name string) string
+	// FoldIdentifier returns the case in which the database stores an unquoted identifier.
+	// Table names are folded before quoting, so they are case-insensitive on every backend.
+	FoldIdentifier(name string) string
+	// ColumnType maps a column kind and size to the native column type
+	ColumnType(kind ColumnKind, size int) string
+	// CurrentTimestamp returns the SQL expression for the current date and time
+	CurrentTimestamp() string
+	// AddColumn returns the statement adding a column to an existing table
+	AddColumn(table TableIdentifier, column, columnType string) string
+	// AlterColumnType returns the statement changing the type of an existing column,
+	// or an empty string if the database does not enforce string lengths
+	AlterColumnType(table TableIdentifier, column, columnType string) string
+	// AddPrimaryKey returns the statement adding a primary key (or, where the database
+	// cannot alter keys, an equivalent unique index) to an existing table
+	AddPrimaryKey(table TableIdentifier, column string) string
+	// TransactionalDDL reports whether schema changes can be rolled back.
+	// Migrations on such databases run in a transaction unless they opt out.
+	TransactionalDDL() bool
+	// SessionUserQuery returns the query yielding the database user of the session,
+	// or an empty string if the database has no users
+	SessionUserQuery() string
+	// TableExistsQuery returns a query and its arguments that yield a row when the folded table exists
+	TableExistsQuery(table TableIdentifier) (string, []interface{})
+	// ObjectQueries returns the catalog queries listing user objects, in display order
+	ObjectQueries() []ObjectQuery
+	// IsTableNotFound reports whether err is the driver's "table does not exist" error
+	IsTableNotFound(err error) bool
+	// SplitStatements splits a migration script into individual statements
+	SplitStatements(content string) []string
+}
+
+// historyColumn describes one column of the version table
+type historyColumn struct {
+	name    string
+	kind    ColumnKind
+	size    int
+	since   int // HistorySchemaVersion that introduced the column
+	resized int // HistorySchemaVersion that last changed the column type, 0 if never
+}
+
+// historyColumns is the version table layout shared by all backends, in column order.
+// New columns are appended with the schema version that introduced them; a changed
+// column type records the schema version that changed it.
+var historyColumns = []historyColumn{
+	{"installed_rank", ColumnInteger, 0, 1, 0},
+	{"version", ColumnVarchar, 200, 1, 6},
+	{"description", ColumnText, 0, 1, 0},
+	{"type", ColumnVarchar, 20, 1, 0},
+	{"script", ColumnVarchar, 4000, 1, 6},
+	{"checksum", ColumnBigInt, 0, 1, 0},
+	{"installed_by", ColumnVarchar, 100, 1, 0},
+	{"installed_on", ColumnTimestamp, 0, 1, 0},
+	{"execution_time", ColumnInteger, 0, 1, 0},
+	{"success", ColumnInteger, 0, 1, 0},
+	{"checksum_sha256", ColumnVarchar, 64, 2, 0},
+	{"bloomdb_version", ColumnVarchar, 50, 2, 0},
+	{"tags", ColumnVarchar, 1000, 2, 0},
+	{"environment", ColumnVarchar, 100, 2, 0},
+	{"installed_from", ColumnVarchar, 255, 3, 0},
+	{"precondition", ColumnVarchar, 20, 4, 0},
+	{"checksum_normalized", ColumnVarchar, 64, 5, 0},
+}
+
+// questionPlaceholder is the "?" bind style used by SQLite, DuckDB and MySQL
+func questionPlaceholder(int) string {
+	return "?"
+}
+
+// alterTableAddColumn is the ANSI ALTER TABLE ... ADD COLUMN statement
+func alterTableAddColumn(dialect Dialect, table TableIdentifier, column, columnType string) string {
+	return "ALTER TABLE " + table.quote(dialect) + " ADD COLUMN " + dialect.QuoteIdentifier(column) + " " + columnType
+}
+
+// alterTableAlterColumnType is the ANSI ALTER TABLE ... ALTER COLUMN ... TYPE statement
+func alterTableAlterColumnType(dialect Dialect, table TableIdentifier, column, columnType string) string {
+	return "ALTER TABLE " + table.quote(dialect) + " ALTER COLUMN " + dialect.QuoteIdentifier(column) + " TYPE " + columnType
+}
+
+// alterTableAddPrimaryKey is the ANSI ALTER TABLE ... ADD PRIMARY KEY statement
+func alterTableAddPrimaryKey(dialect Dialect, table TableIdentifier, column string) string {
+	return "ALTER TABLE " + table.quote(dialect) + " ADD PRIMARY KEY (" + dialect.QuoteIdentifier(column) + ")"
+}
+
+// doubleQuote quotes an identifier the ANSI SQL way
+func doubleQuote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialects(t *testing.T) {
+	tests := []struct {
+		name        string
+		dialect     Dialect
+		placeholder string
+		quoted      string
+		varchar     string
+		transaction bool
+	}{
+		{"SQLite", sqliteDialect{}, "?", `"type"`, "TEXT", true},
+		{"PostgreSQL", postgresDialect{}, "$2", `"type"`, "VARCHAR(50)", true},
+		{"Oracle", oracleDialect{}, ":2", `"type"`, "VARCHAR2(50)", false},
+		{"MySQL", mysqlDialect{}, "?", "`type`", "VARCHAR(50)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.placeholder, tt.dialect.Placeholder(2))
+			assert.Equal(t, tt.quoted, tt.dialect.QuoteIdentifier("type"))
+			assert.Equal(t, tt.varchar, tt.dialect.ColumnType(ColumnVarchar, 50))
+			assert.Equal(t, tt.transaction, tt.dialect.TransactionalDDL())
+		})
+	}
+}
+
+func TestAlterColumnType(t *testing.T) {
+	table := TableIdentifier{Name: "bloomdb_version"}
+
+	tests := []struct {
+		name    string
+		dialect Dialect
+		query   string
+	}{
+		{"SQLite", sqliteDialect{}, ""},
+		{"PostgreSQL", postgresDialect{}, `ALTER TABLE "bloomdb_version" ALTER COLUMN "script" TYPE VARCHAR(4000)`},
+		{"Oracle", oracleDialect{}, `ALTER TABLE "bloomdb_version" MODIFY ("script" VARCHAR2(4000))`},
+		{"MySQL", mysqlDialect{}, "ALTER TABLE `bloomdb_version` MODIFY COLUMN `script` VARCHAR(4000)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.query, tt.dialect.AlterColumnType(table, "script", tt.dialect.ColumnType(ColumnVarchar, 4000)))
+		})
+	}
+}
+
+func TestDoubleQuote(t *testing.T) {
+	assert.Equal(t, `"installed_rank"`, doubleQuote("installed_rank"))
+	assert.Equal(t, `"a""b"`, doubleQuote(`a"b`))
+}
+
+func TestTableIdentifierFolding(t *testing.T) {
+	table := TableIdentifier{Schema: "Audit", Name: "BloomDB_Version"}
+
+	tests := []struct {
+		name    string
+		dialect Dialect
+		quoted  string
+	}{
+		{"SQLite", sqliteDialect{}, `"Audit"."BloomDB_Version"`},
+		{"PostgreSQL", postgresDialect{}, `"audit"."bloomdb_version"`},
+		{"Oracle", oracleDialect{}, `"AUDIT"."BLOOMDB_VERSION"`},
+		{"MySQL", mysqlDialect{}, "`audit`.`bloomdb_version`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.quoted, table.fold(tt.dialect).quote(tt.dialect))
+		})
+	}
+}
@@ -0,0 +1,147 @@
+//go:build !purego
+
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/marcboeker/go-duckdb"
+)
+
+type DuckDBDatabase struct {
+	sqlDatabase
+}
+
+func NewDuckDBDatabase() *DuckDBDatabase {
+	return &DuckDBDatabase{newSQLDatabase(duckdbDialect{})}
+}
+
+// duckdbDialect describes DuckDB's SQL for the version table
+type duckdbDialect struct{}
+
+func (duckdbDialect) Name() string       { return "DuckDB" }
+func (duckdbDialect) DriverName() string { return "duckdb" }
+
+func (duckdbDialect) Placeholder(n int) string { return questionPlaceholder(n) }
+
+func (duckdbDialect) QuoteIdentifier(name string) string { return doubleQuote(name) }
+
+// FoldIdentifier keeps the given case; DuckDB identifiers are case-insensitive
+func (duckdbDialect) FoldIdentifier(name string) string { return name }
+
+// ColumnType uses VARCHAR for all strings; DuckDB does not enforce lengths
+func (duckdbDialect) ColumnType(kind ColumnKind, size int) string {
+	switch kind {
+	case ColumnInteger:
+		return "INTEGER"
+	case ColumnBigInt:
+		return "BIGINT"
+	case ColumnTimestamp:
+		return "TIMESTAMP"
+	default:
+		return "VARCHAR"
+	}
+}
+
+func (duckdbDialect) CurrentTimestamp() string { return "current_timestamp" }
+
+func (d duckdbDialect) AddColumn(table TableIdentifier, column, columnType string) string {
+	return alterTableAddColumn(d, table, column, columnType)
+}
+
+// AlterColumnType is empty: a DuckDB VARCHAR has no maximum length
+func (duckdbDialect) AlterColumnType(TableIdentifier, string, string) string {
+	return ""
+}
+
+// AddPrimaryKey creates a unique index; DuckDB cannot add a primary key to an existing table
+func (d duckdbDialect) AddPrimaryKey(table TableIdentifier, column string) string {
+	return "CREATE UNIQUE INDEX " + d.QuoteIdentifier(table.Name+"_pk") + " ON " + table.quote(d) + " (" + d.QuoteIdentifier(column) + ")"
+}
+
+func (duckdbDialect) TransactionalDDL() bool { return true }
+
+// SessionUserQuery is empty: DuckDB has no database users
+func (duckdbDialect) SessionUserQuery() string {
+	return ""
+}
+
+// TableExistsQuery looks in the current schema unless the table is schema-qualified
+func (duckdbDialect) TableExistsQuery(table TableIdentifier) (string, []interface{}) {
+	if table.Schema == "" {
+		return "SELECT table_name FROM duckdb_tables() WHERE lower(table_name) = lower(?) AND schema_name = current_schema()", []interface{}{table.Name}
+	}
+	return "SELECT table_name FROM duckdb_tables() WHERE lower(table_name) = lower(?) AND lower(schema_name) = lower(?)", []interface{}{table.Name, table.Schema}
+}
+
+func (duckdbDialect) ObjectQueries() []ObjectQuery {
+	return []ObjectQuery{
+		{"table", "SELECT table_name FROM duckdb_tables() WHERE NOT internal AND schema_name = current_schema() ORDER BY table_name"},
+		{"view", "SELECT view_name FROM duckdb_views() WHERE NOT internal AND schema_name = current_schema() ORDER BY view_name"},
+		{"index", "SELECT index_name FROM duckdb_indexes() WHERE schema_name = current_schema() ORDER BY index_name"},
+		{"sequence", "SELECT sequence_name FROM duckdb_sequences() WHERE NOT temporary AND schema_name = current_schema() ORDER BY sequence_name"},
+	}
+}
+
+func (duckdbDialect) IsTableNotFound(err error) bool { return isDuckDBTableNotFound(err) }
+
+func (duckdbDialect) SplitStatements(content string) []string { return ParseSQLStatements(content) }
+
+func (d *DuckDBDatabase) DestroyAllObjects(ctx context.Context) error {
+	if d.db == nil {
+		return ErrNotConnected
+	}
+
+	// Views depend on tables, so drop them first
+	drops := []struct {
+		objectType string
+		query      string
+	}{
+		{"VIEW", "SELECT view_name FROM duckdb_views() WHERE NOT internal AND schema_name = current_schema()"},
+		{"TABLE", "SELECT table_name FROM duckdb_tables() WHERE NOT internal AND schema_name = current_schema()"},
+		{"SEQUENCE", "SELECT sequence_name FROM duckdb_sequences() WHERE NOT temporary AND schema_name = current_schema()"},
+	}
+
+	for _, drop := range drops {
+		logSQL(drop.query)
+		names, err := d.queryNames(ctx, drop.query)
+		if err != nil {
+			return fmt.Errorf("failed to query %ss: %w", strings.ToLower(drop.objectType), err)
+		}
+
+		// DuckDB has no DROP ... CASCADE for foreign keys, so tables still referenced
+		// by another table are retried once the referencing table is gone
+		for len(names) > 0 {
+			var remaining []string
+			var lastErr error
+			for _, name := range names {
+				dropQuery := fmt.Sprintf("DROP %s IF EXISTS \"%s\"", drop.objectType, name)
+				logSQL(dropQuery)
+				if _, err := d.db.ExecContext(ctx, dropQuery); err != nil {
+					remaining = append(remaining, name)
+					lastErr = fmt.Errorf("failed to drop %s %s: %w", strings.ToLower(drop.objectType), name, err)
+				}
+			}
+			if len(remaining) == len(names) {
+				return lastErr
+			}
+			names = remaining
+		}
+	}
+
+	return nil
+}
+
+// isDuckDBTableNotFound reports whether err is DuckDB's catalog error for a missing table
+func isDuckDBTableNotFound(err error) bool {
+	var duckErr *duckdb.Error
+	return errors.As(err, &duckErr) && duckErr.Type == duckdb.ErrorTypeCatalog && strings.Contains(duckErr.Msg, "does not exist")
+}
+
+// newDuckDBDatabase returns a DuckDB backend for the database factory
+func newDuckDBDatabase() (Database, error) {
+	return NewDuckDBDatabase(), nil
+}
@@ -0,0 +1,11 @@
+//go:build purego
+
+package db
+
+import "fmt"
+
+// newDuckDBDatabase reports that DuckDB is unavailable: its driver requires
+// CGO, which builds tagged purego do not use.
+func newDuckDBDatabase() (Database, error) {
+	return nil, fmt.Errorf("DuckDB is not supported in CGO-free builds (built with -tags purego)")
+}
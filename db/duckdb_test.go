@@ -0,0 +1,147 @@
+//go:build !purego
+
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDuckDB(t *testing.T) *DuckDBDatabase {
+	t.Helper()
+	db := NewDuckDBDatabase()
+	require.NoError(t, db.Connect(context.Background(), filepath.Join(t.TempDir(), "test.duckdb")))
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestDuckDBDatabase_MigrationTable(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDuckDB(t)
+	tableName := "BLOOMDB_VERSION"
+
+	exists, err := db.TableExists(ctx, tableName)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = db.GetMigrationRecords(ctx, tableName)
+	assert.True(t, errors.Is(err, ErrTableNotFound), "expected ErrTableNotFound, got %v", err)
+
+	require.NoError(t, db.CreateMigrationTable(ctx, tableName))
+	exists, err = db.TableExists(ctx, tableName)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, db.InsertBaselineRecord(ctx, tableName, "1", Installer{By: "test"}))
+
+	version := "2"
+	checksum := int64(12345)
+	require.NoError(t, db.InsertMigrationRecord(ctx, tableName, MigrationRecord{
+		InstalledRank: 2,
+		Version:       &version,
+		Description:   "Create users",
+		Type:          "versioned",
+		Script:        "V2__Create_users.sql",
+		Checksum:      &checksum,
+		InstalledBy:   "bloomdb",
+		ExecutionTime: 5,
+		Success:       1,
+	}))
+
+	repeatableChecksum := int64(1)
+	require.NoError(t, db.InsertMigrationRecord(ctx, tableName, MigrationRecord{
+		InstalledRank: 3,
+		Description:   "Views",
+		Type:          "repeatable",
+		Script:        "R__Views.sql",
+		Checksum:      &repeatableChecksum,
+		InstalledBy:   "bloomdb",
+		Success:       1,
+	}))
+
+	records, err := db.GetMigrationRecords(ctx, tableName)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "BASELINE", records[0].Type)
+	assert.Nil(t, records[0].Checksum)
+	assert.NotEmpty(t, records[0].InstalledOn)
+	assert.Equal(t, "Create users", records[1].Description)
+	assert.Equal(t, checksum, *records[1].Checksum)
+	assert.Nil(t, records[2].Version)
+
+	require.NoError(t, db.UpdateMigrationRecord(ctx, tableName, 2, "2", "Create all users", 999, "abc", "def"))
+
+	repeatable := records[2]
+	newChecksum := int64(2)
+	repeatable.Checksum = &newChecksum
+	repeatable.ExecutionTime = 7
+	require.NoError(t, db.UpdateMigrationRecordFull(ctx, tableName, repeatable))
+
+	records, err = db.GetMigrationRecords(ctx, tableName)
+	require.NoError(t, err)
+	assert.Equal(t, "Create all users", records[1].Description)
+	assert.Equal(t, int64(999), *records[1].Checksum)
+	assert.Equal(t, "abc", *records[1].ChecksumSHA256)
+	assert.Equal(t, int64(2), *records[2].Checksum)
+	assert.Equal(t, 7, records[2].ExecutionTime)
+}
+
+func TestDuckDBDatabase_ObjectsAndDestroy(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDuckDB(t)
+
+	err := db.ExecuteMigration(ctx, `
+CREATE TABLE users (id INTEGER PRIMARY KEY, name VARCHAR);
+CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id));
+CREATE INDEX idx_users_name ON users(name);
+CREATE VIEW user_names AS SELECT name FROM users;
+CREATE SEQUENCE seq_posts;`)
+	require.NoError(t, err)
+
+	objects, err := db.GetDatabaseObjects(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, objects, DatabaseObject{Type: "table", Name: "users"})
+	assert.Contains(t, objects, DatabaseObject{Type: "table", Name: "posts"})
+	assert.Contains(t, objects, DatabaseObject{Type: "view", Name: "user_names"})
+	assert.Contains(t, objects, DatabaseObject{Type: "index", Name: "idx_users_name"})
+	assert.Contains(t, objects, DatabaseObject{Type: "sequence", Name: "seq_posts"})
+
+	require.NoError(t, db.DestroyAllObjects(ctx))
+
+	objects, err = db.GetDatabaseObjects(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+}
+
+func TestDuckDBDatabase_StatementError(t *testing.T) {
+	db := newTestDuckDB(t)
+
+	err := db.ExecuteMigration(context.Background(), "CREATE TABLE a (id INTEGER);\nINSERT INTO missing VALUES (1);")
+
+	var stmtErr *StatementError
+	require.True(t, errors.As(err, &stmtErr), "expected StatementError, got %v", err)
+	assert.Equal(t, 2, stmtErr.Index)
+	assert.Equal(t, 2, stmtErr.Line)
+}
+
+func TestDuckDBDatabase_DeleteFailedMigrationRecords(t *testing.T) {
+	testDeleteFailedMigrationRecords(t, NewDuckDBDatabase(), "") // An empty path opens an in-memory DuckDB database
+}
+
+func TestDuckDBDatabase_UpdateMigrationRecordFull(t *testing.T) {
+	testUpdateMigrationRecordFull(t, NewDuckDBDatabase(), "")
+}
+
+func TestDuckDBDatabase_UpgradeMigrationTable(t *testing.T) {
+	testUpgradeMigrationTable(t, NewDuckDBDatabase(), "")
+}
+
+func TestDuckDBDialect_AlterColumnType(t *testing.T) {
+	// A DuckDB VARCHAR has no maximum length, so widened columns need no statement
+	assert.Empty(t, duckdbDialect{}.AlterColumnType(TableIdentifier{Name: "history"}, "script", "VARCHAR"))
+}
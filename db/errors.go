@@ -0,0 +1,75 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotConnected is returned when a database method is called before Connect
+var ErrNotConnected = errors.New("database not connected")
+
+// ErrTableNotFound is returned when a query references a table that does not exist
+var ErrTableNotFound = errors.New("table not found")
+
+// StatementError describes a statement of a migration script that failed to execute
+type StatementError struct {
+	Index int    // 1-based position of the statement in the script
+	Line  int    // 1-based line in the script where the statement starts
+	SQL   string // the statement text
+	Cause error  // the error returned by the driver
+}
+
+func (e *StatementError) Error() string {
+	return fmt.Sprintf("failed to execute statement %d (line %d): %v", e.Index, e.Line, e.Cause)
+}
+
+func (e *StatementError) Unwrap() error {
+	return e.Cause
+}
+
+// SchemaVersionError is returned when a version table was written by a newer bloomdb
+type SchemaVersionError struct {
+	Table     string
+	Found     int
+	Supported int
+}
+
+func (e *SchemaVersionError) Error() string {
+	return fmt.Sprintf("version table %s has schema version %d, but this bloomdb only supports up to %d - please upgrade bloomdb", e.Table, e.Found, e.Supported)
+}
+
+// DuplicateRankError is returned when a version table cannot get its primary key on
+// installed_rank because several rows share a rank, e.g. after concurrent runs of old versions
+type DuplicateRankError struct {
+	Table string
+	Ranks []int
+}
+
+func (e *DuplicateRankError) Error() string {
+	ranks := make([]string, len(e.Ranks))
+	for i, rank := range e.Ranks {
+		ranks[i] = strconv.Itoa(rank)
+	}
+	list := strings.Join(ranks, ", ")
+	return fmt.Sprintf("version table %s cannot be upgraded because several rows share installed_rank %s - "+
+		"inspect them with SELECT * FROM %s WHERE installed_rank IN (%s), give each row a unique installed_rank "+
+		"or delete the duplicates, then run bloomdb again", e.Table, list, e.Table, list)
+}
+
+// LockTimeoutError is returned when the migration lock could not be acquired in time
+type LockTimeoutError struct {
+	Table   string
+	Timeout time.Duration
+}
+
+func (e *LockTimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting for migration lock on %s", e.Timeout, e.Table)
+}
+
+// tableNotFoundError wraps a driver error reporting a missing table with ErrTableNotFound
+func tableNotFoundError(tableName string, err error) error {
+	return fmt.Errorf("%w: %s: %w", ErrTableNotFound, tableName, err)
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotConnectedErrors(t *testing.T) {
+	databases := map[string]Database{
+		"sqlite":     NewSQLiteDatabase(),
+		"postgresql": NewPostgreSQLDatabase(),
+		"oracle":     NewOracleDatabase(),
+	}
+
+	for name, database := range databases {
+		t.Run(name, func(t *testing.T) {
+			_, err := database.TableExists(context.Background(), "test_migrations")
+			assert.True(t, errors.Is(err, ErrNotConnected), "TableExists: expected ErrNotConnected, got %v", err)
+
+			err = database.CreateMigrationTable(context.Background(), "test_migrations")
+			assert.True(t, errors.Is(err, ErrNotConnected), "CreateMigrationTable: expected ErrNotConnected, got %v", err)
+
+			err = database.ExecuteMigration(context.Background(), "SELECT 1;")
+			assert.True(t, errors.Is(err, ErrNotConnected), "ExecuteMigration: expected ErrNotConnected, got %v", err)
+		})
+	}
+}
+
+func TestSQLiteDatabase_TableNotFound(t *testing.T) {
+	database := NewSQLiteDatabase()
+	require.NoError(t, database.Connect(context.Background(), ":memory:"))
+	defer database.Close()
+
+	exists, err := database.TableExists(context.Background(), "missing_table")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = database.GetMigrationRecords(context.Background(), "missing_table")
+	assert.True(t, errors.Is(err, ErrTableNotFound), "expected ErrTableNotFound, got %v", err)
+}
+
+func TestSQLiteDatabase_StatementError(t *testing.T) {
+	database := NewSQLiteDatabase()
+	require.NoError(t, database.Connect(context.Background(), ":memory:"))
+	defer database.Close()
+
+	content := `-- create a table
+CREATE TABLE a (id INTEGER);
+
+INSERT INTO missing_table
+VALUES (1);`
+
+	err := database.ExecuteMigration(context.Background(), content)
+
+	var stmtErr *StatementError
+	require.True(t, errors.As(err, &stmtErr), "expected StatementError, got %v", err)
+	assert.Equal(t, 2, stmtErr.Index)
+	assert.Equal(t, 4, stmtErr.Line)
+	assert.Equal(t, "INSERT INTO missing_table\nVALUES (1)", stmtErr.SQL)
+	assert.Contains(t, stmtErr.Error(), "failed to execute statement 2 (line 4)")
+	assert.NotNil(t, errors.Unwrap(stmtErr))
+}
+
+// interruptedExecer fails like lib/pq does when a running statement is cancelled
+type interruptedExecer struct{}
+
+func (interruptedExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, &pq.Error{Severity: "ERROR", Code: "57014", Message: "canceling statement due to user request"}
+}
+
+func TestExecuteStatements_Interrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := executeStatements(ctx, interruptedExecer{}, []string{"SELECT pg_sleep(60)"}, []int{1})
+
+	var stmtErr *StatementError
+	require.True(t, errors.As(err, &stmtErr), "expected StatementError, got %v", err)
+	assert.True(t, errors.Is(err, context.Canceled), "expected the cancellation in the cause, got %v", err)
+	var pqErr *pq.Error
+	require.True(t, errors.As(err, &pqErr), "expected the driver error in the cause, got %v", err)
+	assert.Equal(t, pq.ErrorCode("57014"), pqErr.Code)
+
+	// Without cancellation, a driver error is reported as it is
+	err = executeStatements(context.Background(), interruptedExecer{}, []string{"SELECT 1"}, []int{1})
+	assert.False(t, errors.Is(err, context.Canceled))
+}
+
+func TestLockTimeoutError(t *testing.T) {
+	var err error = &LockTimeoutError{Table: "BLOOMDB_VERSION", Timeout: 0}
+
+	var lockErr *LockTimeoutError
+	assert.True(t, errors.As(err, &lockErr))
+	assert.Contains(t, err.Error(), "BLOOMDB_VERSION")
+}
+
+func TestSQLiteDatabase_ExecuteMigrationCancelled(t *testing.T) {
+	database := NewSQLiteDatabase()
+	require.NoError(t, database.Connect(context.Background(), ":memory:"))
+	defer database.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := database.ExecuteMigration(ctx, "CREATE TABLE a (id INTEGER);")
+
+	var stmtErr *StatementError
+	require.True(t, errors.As(err, &stmtErr), "expected StatementError, got %v", err)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestSQLiteDatabase_StatementTimeout(t *testing.T) {
+	database := NewSQLiteDatabase()
+	require.NoError(t, database.Connect(context.Background(), ":memory:"))
+	defer database.Close()
+
+	ctx := WithStatementTimeout(context.Background(), 50*time.Millisecond)
+	assert.Equal(t, 50*time.Millisecond, StatementTimeout(ctx))
+
+	slow := "CREATE TABLE t AS WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 1000000000) SELECT count(*) AS n FROM c;"
+	err := database.ExecuteMigration(ctx, slow)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "expected deadline exceeded, got %v", err)
+}
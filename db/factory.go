@@ -12,16 +12,20 @@ func NewDatabase(dbType DatabaseType) (Database, error) {
 		return NewPostgreSQLDatabase(), nil
 	case Oracle:
 		return NewOracleDatabase(), nil
+	case DuckDB:
+		return newDuckDBDatabase()
+	case MySQL:
+		return NewMySQLDatabase(), nil
 	default:
 		return nil, fmt.Errorf("unsupported database type: %s", dbType)
 	}
 }
 
 func NewDatabaseFromConnectionString(connectionString string) (Database, error) {
-	dbType, err := ParseDatabaseType(connectionString)
+	config, err := ParseConfig(connectionString)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewDatabase(dbType)
+	return NewDatabase(config.Type)
 }
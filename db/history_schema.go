@@ -0,0 +1,242 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// HistorySchemaVersion is the version table layout written by this build:
+//
+//	1: the original ten columns
+//	2: primary key on installed_rank; checksum_sha256, bloomdb_version, tags and environment
+//	3: installed_from
+//	4: precondition
+//	5: checksum_normalized
+//	6: version widened to 200 and script to 4000 characters
+const HistorySchemaVersion = 6
+
+// metadataTable records the schema version of every version table in the same schema.
+// Version tables without an entry predate it and have schema version 1.
+const metadataTable = "BLOOMDB_METADATA"
+
+// UpgradeMigrationTable adds the columns and keys introduced since the table's schema version
+// and widens the columns resized since then
+func (s *sqlDatabase) UpgradeMigrationTable(ctx context.Context, tableName string) error {
+	if s.db == nil {
+		return ErrNotConnected
+	}
+
+	parsed, err := ParseTableIdentifier(tableName)
+	if err != nil {
+		return err
+	}
+	table := parsed.fold(s.dialect)
+
+	exists, err := s.TableExists(ctx, tableName)
+	if err != nil || !exists {
+		return err
+	}
+
+	version, err := s.schemaVersion(ctx, table)
+	if err != nil {
+		return err
+	}
+	switch {
+	case version > HistorySchemaVersion:
+		return &SchemaVersionError{Table: tableName, Found: version, Supported: HistorySchemaVersion}
+	case version == HistorySchemaVersion:
+		return nil
+	}
+
+	// Check the primary key can be added before changing anything: DDL is not transactional on MySQL
+	if version < 2 {
+		if err := s.checkUniqueRanks(ctx, table, tableName); err != nil {
+			return err
+		}
+	}
+
+	// Columns that already exist were added by an interrupted upgrade
+	existing, err := s.columnNames(ctx, table)
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", tableName, err)
+	}
+	for _, column := range historyColumns {
+		if column.since <= version || existing[strings.ToLower(column.name)] {
+			continue
+		}
+		query := s.dialect.AddColumn(table, column.name, s.dialect.ColumnType(column.kind, column.size))
+		logSQL(query)
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to add column %s to %s: %w", column.name, tableName, err)
+		}
+	}
+
+	// Columns added above already have their current type
+	for _, column := range historyColumns {
+		if column.resized <= version || column.since > version {
+			continue
+		}
+		query := s.dialect.AlterColumnType(table, column.name, s.dialect.ColumnType(column.kind, column.size))
+		if query == "" {
+			continue
+		}
+		logSQL(query)
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to change the type of column %s of %s: %w", column.name, tableName, err)
+		}
+	}
+
+	if version < 2 {
+		query := s.dialect.AddPrimaryKey(table, "installed_rank")
+		logSQL(query)
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to add primary key to %s: %w", tableName, err)
+		}
+	}
+
+	return s.setSchemaVersion(ctx, table, HistorySchemaVersion)
+}
+
+func (s *sqlDatabase) MigrationTableSchemaVersion(ctx context.Context, tableName string) (int, error) {
+	if s.db == nil {
+		return 0, ErrNotConnected
+	}
+
+	parsed, err := ParseTableIdentifier(tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	exists, err := s.TableExists(ctx, tableName)
+	if err != nil || !exists {
+		return 0, err
+	}
+	return s.schemaVersion(ctx, parsed.fold(s.dialect))
+}
+
+// checkUniqueRanks returns a DuplicateRankError if rows of the version table share an installed_rank
+func (s *sqlDatabase) checkUniqueRanks(ctx context.Context, table TableIdentifier, tableName string) error {
+	rank := s.dialect.QuoteIdentifier("installed_rank")
+	query := fmt.Sprintf("SELECT %s FROM %s GROUP BY %s HAVING COUNT(*) > 1 ORDER BY %s",
+		rank, table.quote(s.dialect), rank, rank)
+	logSQL(query)
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to check installed ranks of %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	var duplicates []int
+	for rows.Next() {
+		var duplicate int
+		if err := rows.Scan(&duplicate); err != nil {
+			return fmt.Errorf("failed to check installed ranks of %s: %w", tableName, err)
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check installed ranks of %s: %w", tableName, err)
+	}
+	if len(duplicates) > 0 {
+		return &DuplicateRankError{Table: tableName, Ranks: duplicates}
+	}
+	return nil
+}
+
+// metadataIdentifier returns the folded metadata table next to the version table
+func (s *sqlDatabase) metadataIdentifier(table TableIdentifier) TableIdentifier {
+	return TableIdentifier{Schema: table.Schema, Name: s.dialect.FoldIdentifier(metadataTable)}
+}
+
+// schemaVersion reads the recorded schema version of an existing version table
+func (s *sqlDatabase) schemaVersion(ctx context.Context, table TableIdentifier) (int, error) {
+	metadata := s.metadataIdentifier(table)
+	exists, err := s.TableExists(ctx, metadata.String())
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 1, nil
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s",
+		s.dialect.QuoteIdentifier("schema_version"), metadata.quote(s.dialect),
+		s.dialect.QuoteIdentifier("table_name"), s.dialect.Placeholder(1))
+	logSQL(query, table.Name)
+	var version int
+	err = s.db.QueryRowContext(ctx, query, table.Name).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version of %s: %w", table, err)
+	}
+	return version, nil
+}
+
+// setSchemaVersion records the schema version of a version table, creating the metadata table if needed
+func (s *sqlDatabase) setSchemaVersion(ctx context.Context, table TableIdentifier, version int) error {
+	metadata := s.metadataIdentifier(table)
+	exists, err := s.TableExists(ctx, metadata.String())
+	if err != nil {
+		return err
+	}
+
+	q := s.dialect.QuoteIdentifier
+	type statement struct {
+		query string
+		args  []interface{}
+	}
+	var statements []statement
+	if !exists {
+		statements = append(statements, statement{query: fmt.Sprintf("CREATE TABLE %s (\n\t%s %s,\n\t%s %s,\n\tPRIMARY KEY (%s)\n)",
+			metadata.quote(s.dialect),
+			q("table_name"), s.dialect.ColumnType(ColumnVarchar, maxIdentifierLength),
+			q("schema_version"), s.dialect.ColumnType(ColumnInteger, 0),
+			q("table_name"))})
+	}
+	// Delete and insert rather than update: MySQL reports unchanged rows as unaffected
+	statements = append(statements,
+		statement{
+			query: fmt.Sprintf("DELETE FROM %s WHERE %s = %s", metadata.quote(s.dialect), q("table_name"), s.dialect.Placeholder(1)),
+			args:  []interface{}{table.Name},
+		},
+		statement{
+			query: fmt.Sprintf("INSERT INTO %s (%s, %s) VALUES (%s, %s)", metadata.quote(s.dialect),
+				q("table_name"), q("schema_version"), s.dialect.Placeholder(1), s.dialect.Placeholder(2)),
+			args: []interface{}{table.Name, version},
+		})
+
+	for _, stmt := range statements {
+		logSQL(stmt.query, stmt.args...)
+		if _, err := s.db.ExecContext(ctx, stmt.query, stmt.args...); err != nil {
+			return fmt.Errorf("failed to record schema version of %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// columnNames returns the lowercased column names of a table
+func (s *sqlDatabase) columnNames(ctx context.Context, table TableIdentifier) (map[string]bool, error) {
+	query := "SELECT * FROM " + table.quote(s.dialect) + " WHERE 1 = 0"
+	logSQL(query)
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		names[strings.ToLower(column)] = true
+	}
+	return names, nil
+}
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// v1HistoryTable is the version table layout written before schema versioning
+const v1HistoryTable = `CREATE TABLE history (
+	installed_rank INTEGER, version VARCHAR(50), description VARCHAR(200), type VARCHAR(20),
+	script VARCHAR(1000), checksum BIGINT, installed_by VARCHAR(100), installed_on TIMESTAMP,
+	execution_time INTEGER, success INTEGER
+)`
+
+func TestSQLiteDatabase_UpgradeMigrationTable(t *testing.T) {
+	testUpgradeMigrationTable(t, NewSQLiteDatabase(), ":memory:")
+}
+
+// testUpgradeMigrationTable upgrades a version 1 table and checks the refusal of newer tables
+func testUpgradeMigrationTable(t *testing.T, db Database, connStr string) {
+	ctx := context.Background()
+	require.NoError(t, db.Connect(ctx, connStr))
+	defer db.Close()
+	sqlDB := db.GetDB()
+	sqlDB.SetMaxOpenConns(1) // keep in-memory databases on one connection
+
+	// A missing table is left alone
+	require.NoError(t, db.UpgradeMigrationTable(ctx, "history"))
+	version, err := db.MigrationTableSchemaVersion(ctx, "history")
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	_, err = sqlDB.Exec(v1HistoryTable)
+	require.NoError(t, err)
+	_, err = sqlDB.Exec(`INSERT INTO history VALUES (1, '1', '<< Baseline >>', 'BASELINE', '<< Baseline >>', NULL, 'bloomdb', CURRENT_TIMESTAMP, 0, 1)`)
+	require.NoError(t, err)
+
+	// An old table can be read before it is upgraded; the missing columns are empty
+	version, err = db.MigrationTableSchemaVersion(ctx, "history")
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+	records, err := db.GetMigrationRecords(ctx, "history")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "BASELINE", records[0].Type)
+	assert.Nil(t, records[0].ChecksumSHA256)
+	assert.Empty(t, records[0].Precondition)
+
+	require.NoError(t, db.UpgradeMigrationTable(ctx, "history"))
+	// Upgrading an up-to-date table does nothing
+	require.NoError(t, db.UpgradeMigrationTable(ctx, "history"))
+
+	require.NoError(t, sqlDB.QueryRow(`SELECT schema_version FROM BLOOMDB_METADATA WHERE table_name = 'history'`).Scan(&version))
+	assert.Equal(t, HistorySchemaVersion, version)
+	version, err = db.MigrationTableSchemaVersion(ctx, "history")
+	require.NoError(t, err)
+	assert.Equal(t, HistorySchemaVersion, version)
+
+	// Existing rows survive and new rows use the new columns
+	sha := "abc123"
+	require.NoError(t, db.InsertMigrationRecord(ctx, "history", MigrationRecord{
+		InstalledRank: 2, Version: stringPtr("2"), Description: "users", Type: "versioned", Script: "V2__users.sql",
+		Checksum: int64Ptr(7), ChecksumSHA256: &sha, InstalledBy: "test", Success: 1, Tags: "core", Environment: "prod",
+		InstalledFrom: "build-01 (gitlab:4711)",
+	}))
+	records, err = db.GetMigrationRecords(ctx, "history")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Nil(t, records[0].ChecksumSHA256)
+	assert.Empty(t, records[0].BloomDBVersion)
+	assert.Equal(t, sha, *records[1].ChecksumSHA256)
+	assert.Equal(t, BloomDBVersion, records[1].BloomDBVersion)
+	assert.Equal(t, "core", records[1].Tags)
+	assert.Equal(t, "prod", records[1].Environment)
+	assert.Equal(t, "build-01 (gitlab:4711)", records[1].InstalledFrom)
+
+	// Version and script were widened beyond the version 1 sizes
+	longVersion := strings.Repeat("1.", 90) + "3"
+	longScript := "sub/" + strings.Repeat("x", 2000) + "/V3__long.sql"
+	require.NoError(t, db.InsertMigrationRecord(ctx, "history", MigrationRecord{
+		InstalledRank: 3, Version: &longVersion, Description: "long", Type: "versioned", Script: longScript, InstalledBy: "test", Success: 1,
+	}))
+	records, err = db.GetMigrationRecords(ctx, "history")
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, longVersion, *records[2].Version)
+	assert.Equal(t, longScript, records[2].Script)
+
+	// The installed rank is now unique
+	err = db.InsertMigrationRecord(ctx, "history", MigrationRecord{
+		InstalledRank: 2, Version: stringPtr("3"), Description: "dup", Type: "versioned", Script: "V3__dup.sql", InstalledBy: "test",
+	})
+	assert.Error(t, err)
+
+	// A table written by a newer bloomdb is refused
+	_, err = sqlDB.Exec(`UPDATE BLOOMDB_METADATA SET schema_version = 99 WHERE table_name = 'history'`)
+	require.NoError(t, err)
+	err = db.UpgradeMigrationTable(ctx, "history")
+	var schemaErr *SchemaVersionError
+	require.True(t, errors.As(err, &schemaErr), "expected SchemaVersionError, got %v", err)
+	assert.Equal(t, 99, schemaErr.Found)
+	assert.Equal(t, HistorySchemaVersion, schemaErr.Supported)
+}
+
+func TestSQLiteDatabase_UpgradeMigrationTableDuplicateRanks(t *testing.T) {
+	ctx := context.Background()
+	db := NewSQLiteDatabase()
+	require.NoError(t, db.Connect(ctx, ":memory:"))
+	defer db.Close()
+	sqlDB := db.GetDB()
+	sqlDB.SetMaxOpenConns(1)
+
+	// Concurrent runs before locking existed could record the same rank twice
+	_, err := sqlDB.Exec(v1HistoryTable)
+	require.NoError(t, err)
+	for _, row := range []string{
+		`(1, '1', '<< Baseline >>', 'BASELINE', '<< Baseline >>', NULL, 'bloomdb', CURRENT_TIMESTAMP, 0, 1)`,
+		`(2, '2', 'users', 'SQL', 'V2__users.sql', 7, 'bloomdb', CURRENT_TIMESTAMP, 0, 1)`,
+		`(2, '3', 'roles', 'SQL', 'V3__roles.sql', 8, 'bloomdb', CURRENT_TIMESTAMP, 0, 1)`,
+	} {
+		_, err = sqlDB.Exec("INSERT INTO history VALUES " + row)
+		require.NoError(t, err)
+	}
+
+	err = db.UpgradeMigrationTable(ctx, "history")
+	var rankErr *DuplicateRankError
+	require.True(t, errors.As(err, &rankErr), "expected DuplicateRankError, got %v", err)
+	assert.Equal(t, []int{2}, rankErr.Ranks)
+	assert.Contains(t, err.Error(), "SELECT * FROM history WHERE installed_rank IN (2)")
+
+	// Nothing was changed, so the upgrade succeeds once the ranks are fixed
+	columns, err := db.columnNames(ctx, TableIdentifier{Name: "history"})
+	require.NoError(t, err)
+	assert.False(t, columns["checksum_sha256"], "no column should be added before the ranks are checked")
+
+	_, err = sqlDB.Exec(`UPDATE history SET installed_rank = 3 WHERE version = '3'`)
+	require.NoError(t, err)
+	require.NoError(t, db.UpgradeMigrationTable(ctx, "history"))
+}
+
+func TestSQLiteDatabase_CreateMigrationTableRecordsSchemaVersion(t *testing.T) {
+	ctx := context.Background()
+	db := NewSQLiteDatabase()
+	require.NoError(t, db.Connect(ctx, ":memory:"))
+	defer db.Close()
+	db.GetDB().SetMaxOpenConns(1)
+
+	require.NoError(t, db.CreateMigrationTable(ctx, "History"))
+
+	var version int
+	require.NoError(t, db.GetDB().QueryRow(`SELECT schema_version FROM BLOOMDB_METADATA WHERE table_name = 'History'`).Scan(&version))
+	assert.Equal(t, HistorySchemaVersion, version)
+	require.NoError(t, db.UpgradeMigrationTable(ctx, "history"))
+
+	// SQLite has no database users
+	user, err := db.SessionUser(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, user)
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxIdentifierLength is the longest accepted identifier part.
+// It is PostgreSQL's limit, the smallest among the supported databases.
+const maxIdentifierLength = 63
+
+// identifierPattern is the portable subset of unquoted SQL identifiers
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ErrInvalidIdentifier is returned for table names that are not safe SQL identifiers
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+// TableIdentifier is a validated, optionally schema-qualified table name
+type TableIdentifier struct {
+	Schema string
+	Name   string
+}
+
+// ParseTableIdentifier validates a table name of the form "table" or "schema.table".
+// Each part must start with a letter or underscore, contain only letters, digits
+// and underscores, and be at most 63 characters long.
+func ParseTableIdentifier(name string) (TableIdentifier, error) {
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return TableIdentifier{}, fmt.Errorf("%w %q: expected table or schema.table", ErrInvalidIdentifier, name)
+	}
+	for _, part := range parts {
+		if err := validateIdentifierPart(part); err != nil {
+			if len(parts) > 1 {
+				return TableIdentifier{}, fmt.Errorf("%w %q: part %q %v", ErrInvalidIdentifier, name, part, err)
+			}
+			return TableIdentifier{}, fmt.Errorf("%w %q: name %v", ErrInvalidIdentifier, name, err)
+		}
+	}
+
+	if len(parts) == 2 {
+		return TableIdentifier{Schema: parts[0], Name: parts[1]}, nil
+	}
+	return TableIdentifier{Name: parts[0]}, nil
+}
+
+// validateIdentifierPart checks a single, unqualified identifier
+func validateIdentifierPart(part string) error {
+	switch {
+	case part == "":
+		return errors.New("must not be empty")
+	case len(part) > maxIdentifierLength:
+		return fmt.Errorf("is longer than %d characters", maxIdentifierLength)
+	case !identifierPattern.MatchString(part):
+		return errors.New("may only contain letters, digits and underscores and must not start with a digit")
+	}
+	return nil
+}
+
+// String returns the identifier as written by the user
+func (t TableIdentifier) String() string {
+	if t.Schema == "" {
+		return t.Name
+	}
+	return t.Schema + "." + t.Name
+}
+
+// fold applies the dialect's case folding to every part
+func (t TableIdentifier) fold(dialect Dialect) TableIdentifier {
+	folded := TableIdentifier{Name: dialect.FoldIdentifier(t.Name)}
+	if t.Schema != "" {
+		folded.Schema = dialect.FoldIdentifier(t.Schema)
+	}
+	return folded
+}
+
+// quote returns the quoted, possibly schema-qualified reference for SQL statements
+func (t TableIdentifier) quote(dialect Dialect) string {
+	if t.Schema == "" {
+		return dialect.QuoteIdentifier(t.Name)
+	}
+	return dialect.QuoteIdentifier(t.Schema) + "." + dialect.QuoteIdentifier(t.Name)
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTableIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected TableIdentifier
+		wantErr  bool
+	}{
+		{name: "plain name", input: "BLOOMDB_VERSION", expected: TableIdentifier{Name: "BLOOMDB_VERSION"}},
+		{name: "schema qualified", input: "audit.schema_history", expected: TableIdentifier{Schema: "audit", Name: "schema_history"}},
+		{name: "leading underscore", input: "_history", expected: TableIdentifier{Name: "_history"}},
+		{name: "maximum length", input: strings.Repeat("a", 63), expected: TableIdentifier{Name: strings.Repeat("a", 63)}},
+		{name: "empty", input: "", wantErr: true},
+		{name: "SQL injection", input: "BLOOMDB_A;DROP TABLE X", wantErr: true},
+		{name: "space", input: "my table", wantErr: true},
+		{name: "quote", input: `history"`, wantErr: true},
+		{name: "leading digit", input: "1history", wantErr: true},
+		{name: "too many parts", input: "db.audit.history", wantErr: true},
+		{name: "empty schema", input: ".history", wantErr: true},
+		{name: "too long", input: strings.Repeat("a", 64), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := ParseTableIdentifier(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, ErrInvalidIdentifier))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, table)
+			assert.Equal(t, tt.input, table.String())
+		})
+	}
+}
+
+func TestSQLiteDatabase_TableNameHandling(t *testing.T) {
+	ctx := context.Background()
+	db := NewSQLiteDatabase()
+	require.NoError(t, db.Connect(ctx, ":memory:"))
+	defer db.Close()
+
+	// Names differing only in case refer to the same table
+	require.NoError(t, db.CreateMigrationTable(ctx, "History"))
+	exists, err := db.TableExists(ctx, "HISTORY")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	require.NoError(t, db.InsertBaselineRecord(ctx, "history", "1", Installer{By: "test"}))
+
+	// Schema-qualified names address the attached database
+	exists, err = db.TableExists(ctx, "main.history")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	records, err := db.GetMigrationRecords(ctx, "main.History")
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	// Unsafe names never reach the database
+	err = db.CreateMigrationTable(ctx, "x; DROP TABLE History")
+	assert.True(t, errors.Is(err, ErrInvalidIdentifier))
+	_, err = db.TableExists(ctx, "x; DROP TABLE History")
+	assert.True(t, errors.Is(err, ErrInvalidIdentifier))
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,18 +12,18 @@ func TestSQLiteDatabase_CreateMigrationTable(t *testing.T) {
 	db := NewSQLiteDatabase()
 
 	// Use in-memory SQLite for testing
-	err := db.Connect(":memory:")
+	err := db.Connect(context.Background(), ":memory:")
 	require.NoError(t, err, "Failed to connect to SQLite")
 	defer db.Close()
 
 	tableName := "test_migrations"
 
 	// Create migration table
-	err = db.CreateMigrationTable(tableName)
+	err = db.CreateMigrationTable(context.Background(), tableName)
 	require.NoError(t, err, "Failed to create migration table")
 
 	// Verify table exists
-	exists, err := db.TableExists(tableName)
+	exists, err := db.TableExists(context.Background(), tableName)
 	require.NoError(t, err, "Failed to check table existence")
 	assert.True(t, exists, "Migration table was not created")
 
@@ -72,7 +73,7 @@ func TestPostgreSQLDatabase_CreateMigrationTable_NotConnected(t *testing.T) {
 
 	// Test that the method generates correct SQL
 	// We can't easily test without a real connection, but we can verify query structure
-	err := db.CreateMigrationTable(tableName)
+	err := db.CreateMigrationTable(context.Background(), tableName)
 
 	// Should fail because not connected
 	assert.Error(t, err, "Expected error when not connected to database")
@@ -84,7 +85,7 @@ func TestOracleDatabase_CreateMigrationTable_NotConnected(t *testing.T) {
 
 	// Test that the method generates correct SQL
 	// We can't easily test without a real connection, but we can verify query structure
-	err := db.CreateMigrationTable(tableName)
+	err := db.CreateMigrationTable(context.Background(), tableName)
 
 	// Should fail because not connected
 	assert.Error(t, err, "Expected error when not connected to database")
@@ -129,7 +130,7 @@ func TestCreateMigrationTable_SQLGeneration(t *testing.T) {
 			// Test that the method handles table name correctly
 			// We can't test full execution without real connections,
 			// but we can verify method signature and basic error handling
-			err := db.CreateMigrationTable(tt.tableName)
+			err := db.CreateMigrationTable(context.Background(), tt.tableName)
 
 			// Should fail because not connected, but error should be about table creation
 			assert.Error(t, err, "Expected error when not connected to database")
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	sqle "github.com/dolthub/go-mysql-server"
+	"github.com/dolthub/go-mysql-server/memory"
+	"github.com/dolthub/go-mysql-server/server"
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// mysqlStandIn is an in-process MySQL-compatible server (go-mysql-server with an in-memory
+// database) that the MySQL tests use when no real server is configured. It is shared by
+// all tests of the package so that several connections can contend for the same lock.
+var mysqlStandIn struct {
+	once sync.Once
+	conn string
+	err  error
+}
+
+// mysqlStandInConn starts the stand-in server on first use and returns its connection string
+func mysqlStandInConn(t *testing.T) string {
+	t.Helper()
+	mysqlStandIn.once.Do(func() {
+		// The server logs every connection and failed query through logrus
+		logrus.SetLevel(logrus.ErrorLevel)
+
+		database := memory.NewDatabase("bloomdb_test")
+		database.EnablePrimaryKeyIndexes() // foreign keys need an index on the referenced columns
+		provider := memory.NewDBProvider(database)
+		engine := sqle.NewDefault(provider)
+
+		// information_schema only lists views and routines to users with privileges
+		users := engine.Analyzer.Catalog.MySQLDb
+		editor := users.Editor()
+		users.AddSuperUser(editor, "root", "%", "")
+		editor.Close()
+
+		config := server.Config{Protocol: "tcp", Address: "127.0.0.1:0"}
+		srv, err := server.NewServer(config, engine, sql.NewContext, memory.NewSessionBuilder(provider), nil)
+		if err != nil {
+			mysqlStandIn.err = err
+			return
+		}
+		go srv.Start()
+		mysqlStandIn.conn = fmt.Sprintf("mysql://root@%s/bloomdb_test", srv.Listener.Addr())
+	})
+	require.NoError(t, mysqlStandIn.err, "failed to start the in-process MySQL server")
+	return mysqlStandIn.conn
+}
@@ -1,377 +1,99 @@
 package db
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	_ "github.com/sijms/go-ora/v2"
+	"github.com/sijms/go-ora/v2/network"
 )
 
 type OracleDatabase struct {
-	db *sql.DB
+	sqlDatabase
 }
 
 func NewOracleDatabase() *OracleDatabase {
-	return &OracleDatabase{}
+	return &OracleDatabase{newSQLDatabase(oracleDialect{})}
 }
 
-func (o *OracleDatabase) Connect(connectionString string) error {
-	db, err := sql.Open("oracle", connectionString)
-	if err != nil {
-		return fmt.Errorf("failed to connect to Oracle: %w", err)
-	}
-	o.db = db
-	return nil
-}
-
-func (o *OracleDatabase) Close() error {
-	if o.db != nil {
-		return o.db.Close()
-	}
-	return nil
-}
+// oracleDialect describes Oracle's SQL for the version table
+type oracleDialect struct{}
 
-func (o *OracleDatabase) Ping() error {
-	if o.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-	return o.db.Ping()
-}
+func (oracleDialect) Name() string       { return "Oracle" }
+func (oracleDialect) DriverName() string { return "oracle" }
 
-func (o *OracleDatabase) GetDB() *sql.DB {
-	return o.db
-}
-
-func (o *OracleDatabase) TableExists(tableName string) (bool, error) {
-	// Check for uppercase table name (Oracle's default for unquoted identifiers)
-	query := "SELECT table_name FROM user_tables WHERE table_name = :1"
-	logSQL(query, strings.ToUpper(tableName))
-	var result string
-	err := o.db.QueryRow(query, strings.ToUpper(tableName)).Scan(&result)
-	if err != nil {
-		if err.Error() == "sql: no rows in result set" {
-			return false, nil
-		}
-		return false, fmt.Errorf("error checking table existence: %w", err)
-	}
-	return true, nil
-}
+func (oracleDialect) Placeholder(n int) string { return fmt.Sprintf(":%d", n) }
 
-func (o *OracleDatabase) CreateMigrationTable(tableName string) error {
-	if o.db == nil {
-		return fmt.Errorf("database not connected")
-	}
+// QuoteIdentifier keeps column names lowercase; unquoted they would be folded to uppercase
+func (oracleDialect) QuoteIdentifier(name string) string { return doubleQuote(name) }
 
-	query := fmt.Sprintf(`
-		CREATE TABLE %s (
-			"installed_rank" NUMBER,
-			"version" VARCHAR2(50),
-			"description" VARCHAR2(4000),
-			"type" VARCHAR2(20),
-			"script" VARCHAR2(1000),
-			"checksum" NUMBER,
-			"installed_by" VARCHAR2(100),
-			"installed_on" TIMESTAMP,
-			"execution_time" NUMBER,
-			"success" NUMBER
-		)
-	`, tableName)
+// FoldIdentifier uppercases, as Oracle does for unquoted identifiers
+func (oracleDialect) FoldIdentifier(name string) string { return strings.ToUpper(name) }
 
-	logSQL(query)
-	_, err := o.db.Exec(query)
-	if err != nil {
-		return fmt.Errorf("failed to create migration table %s: %w", tableName, err)
+func (oracleDialect) ColumnType(kind ColumnKind, size int) string {
+	switch kind {
+	case ColumnInteger, ColumnBigInt:
+		return "NUMBER"
+	case ColumnVarchar:
+		return fmt.Sprintf("VARCHAR2(%d)", size)
+	case ColumnTimestamp:
+		return "TIMESTAMP"
+	default:
+		return "VARCHAR2(4000)"
 	}
-
-	return nil
 }
 
-func (o *OracleDatabase) InsertBaselineRecord(tableName, version string) error {
-	if o.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	query := fmt.Sprintf(`
-		INSERT INTO %s ("installed_rank", "version", "description", "type", "script", "checksum", "installed_by", "installed_on", "execution_time", "success")
-		VALUES (:1, :2, :3, :4, :5, :6, :7, CURRENT_TIMESTAMP, :8, :9)
-	`, tableName)
-
-	// Convert version to integer for installed rank
-	installedRank := versionToInt(version)
-	logSQL(query, installedRank, version, "<< Baseline >>", "BASELINE", "<< Baseline >>", nil, "bloomdb", 0, 1)
-	_, err := o.db.Exec(query, installedRank, version, "<< Baseline >>", "BASELINE", "<< Baseline >>", nil, "bloomdb", 0, 1)
-	if err != nil {
-		return fmt.Errorf("failed to insert baseline record: %w", err)
-	}
+func (oracleDialect) CurrentTimestamp() string { return "CURRENT_TIMESTAMP" }
 
-	return nil
+// AddColumn uses Oracle's ALTER TABLE ... ADD (column type) form
+func (d oracleDialect) AddColumn(table TableIdentifier, column, columnType string) string {
+	return "ALTER TABLE " + table.quote(d) + " ADD (" + d.QuoteIdentifier(column) + " " + columnType + ")"
 }
 
-func (o *OracleDatabase) GetMigrationRecords(tableName string) ([]MigrationRecord, error) {
-	if o.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := fmt.Sprintf(`
-		SELECT "installed_rank", "version", "description", "type", "script", "checksum", "installed_by", "installed_on", "execution_time", "success"
-		FROM %s 
-		ORDER BY "installed_rank"
-	`, tableName)
-
-	logSQL(query)
-	rows, err := o.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query migration records: %w", err)
-	}
-	defer rows.Close()
-
-	var records []MigrationRecord
-	for rows.Next() {
-		var record MigrationRecord
-		err := rows.Scan(&record.InstalledRank, &record.Version, &record.Description, &record.Type, &record.Script, &record.Checksum, &record.InstalledBy, &record.InstalledOn, &record.ExecutionTime, &record.Success)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan migration record: %w", err)
-		}
-		records = append(records, record)
-	}
-
-	return records, nil
+// AlterColumnType uses Oracle's ALTER TABLE ... MODIFY (column type) form
+func (d oracleDialect) AlterColumnType(table TableIdentifier, column, columnType string) string {
+	return "ALTER TABLE " + table.quote(d) + " MODIFY (" + d.QuoteIdentifier(column) + " " + columnType + ")"
 }
 
-func (o *OracleDatabase) InsertMigrationRecord(tableName string, record MigrationRecord) error {
-	if o.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	query := fmt.Sprintf(`
-		INSERT INTO %s ("installed_rank", "version", "description", "type", "script", "checksum", "installed_by", "installed_on", "execution_time", "success")
-		VALUES (:1, :2, :3, :4, :5, :6, :7, CURRENT_TIMESTAMP, :8, :9)
-	`, tableName)
-
-	logSQL(query, record.InstalledRank, record.Version, record.Description, record.Type, record.Script, record.Checksum, record.InstalledBy, record.ExecutionTime, record.Success)
-	_, err := o.db.Exec(query, record.InstalledRank, record.Version, record.Description, record.Type, record.Script, record.Checksum, record.InstalledBy, record.ExecutionTime, record.Success)
-	if err != nil {
-		return fmt.Errorf("failed to insert migration record: %w", err)
-	}
-
-	return nil
+func (d oracleDialect) AddPrimaryKey(table TableIdentifier, column string) string {
+	return alterTableAddPrimaryKey(d, table, column)
 }
 
-func (o *OracleDatabase) UpdateMigrationRecord(tableName string, installedRank int, version, description string, checksum int64) error {
-	if o.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	query := fmt.Sprintf(`
-		UPDATE %s 
-		SET "description" = :1, "checksum" = :2
-		WHERE "installed_rank" = :3 AND "version" = :4
-	`, tableName)
-
-	logSQL(query, description, checksum, installedRank, version)
-	_, err := o.db.Exec(query, description, checksum, installedRank, version)
-	if err != nil {
-		return fmt.Errorf("failed to update migration record: %w", err)
-	}
+// TransactionalDDL is false: Oracle commits implicitly before and after every DDL statement
+func (oracleDialect) TransactionalDDL() bool { return false }
 
-	return nil
+func (oracleDialect) SessionUserQuery() string {
+	return "SELECT USER FROM dual"
 }
 
-func (o *OracleDatabase) UpdateMigrationRecordFull(tableName string, record MigrationRecord) error {
-	if o.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	query := fmt.Sprintf(`
-		UPDATE %s 
-		SET "installed_rank" = :1, "version" = :2, "description" = :3, "type" = :4, "script" = :5, "checksum" = :6, 
-			"installed_by" = :7, "installed_on" = :8, "execution_time" = :9, "success" = :10
-		WHERE "installed_rank" = :11 AND ("version" = :12 OR ("version" IS NULL AND :12 IS NULL))
-	`, tableName)
-
-	var versionPtr *string
-	if record.Version != nil {
-		versionPtr = record.Version
-	}
-
-	logSQL(query,
-		record.InstalledRank, versionPtr, record.Description, record.Type, record.Script,
-		record.Checksum, record.InstalledBy, record.InstalledOn, record.ExecutionTime, record.Success,
-		record.InstalledRank, versionPtr)
-	_, err := o.db.Exec(query,
-		record.InstalledRank, versionPtr, record.Description, record.Type, record.Script,
-		record.Checksum, record.InstalledBy, record.InstalledOn, record.ExecutionTime, record.Success,
-		record.InstalledRank, versionPtr)
-	if err != nil {
-		return fmt.Errorf("failed to update migration record: %w", err)
+// TableExistsQuery looks in the user's own schema unless the table is schema-qualified
+func (oracleDialect) TableExistsQuery(table TableIdentifier) (string, []interface{}) {
+	if table.Schema == "" {
+		return "SELECT table_name FROM user_tables WHERE table_name = :1", []interface{}{table.Name}
 	}
-
-	return nil
+	return "SELECT table_name FROM all_tables WHERE owner = :1 AND table_name = :2", []interface{}{table.Schema, table.Name}
 }
 
-func (o *OracleDatabase) DeleteFailedMigrationRecords(tableName string) error {
-	if o.db == nil {
-		return fmt.Errorf("database not connected")
+func (oracleDialect) ObjectQueries() []ObjectQuery {
+	return []ObjectQuery{
+		{"table", "SELECT table_name FROM user_tables"},
+		{"view", "SELECT view_name FROM user_views"},
+		{"index", "SELECT index_name FROM user_indexes"},
+		{"sequence", "SELECT sequence_name FROM user_sequences"},
+		{"procedure", "SELECT object_name FROM user_procedures"},
+		{"function", "SELECT object_name FROM user_objects WHERE object_type = 'FUNCTION'"},
 	}
-
-	query := fmt.Sprintf(`
-		DELETE FROM %s 
-		WHERE "success" != 1
-	`, tableName)
-
-	logSQL(query)
-	result, err := o.db.Exec(query)
-	if err != nil {
-		return fmt.Errorf("failed to delete failed migration records: %w", err)
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected > 0 {
-		fmt.Printf("Removed %d failed migration records from %s\n", rowsAffected, tableName)
-	} else {
-		fmt.Printf("No failed migration records found in %s\n", tableName)
-	}
-
-	return nil
 }
 
-func (o *OracleDatabase) ExecuteMigration(content string) error {
-	if o.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	statements := ParseSQLStatements(content)
-	for i, statement := range statements {
-		logSQL(statement)
-		_, err := o.db.Exec(statement)
-		if err != nil {
-			return fmt.Errorf("failed to execute statement %d: %w", i+1, err)
-		}
-	}
+func (oracleDialect) IsTableNotFound(err error) bool { return isOracleTableNotFound(err) }
 
-	return nil
-}
+func (oracleDialect) SplitStatements(content string) []string { return ParseSQLStatements(content) }
 
-func (o *OracleDatabase) GetDatabaseObjects() ([]DatabaseObject, error) {
+func (o *OracleDatabase) DestroyAllObjects(ctx context.Context) error {
 	if o.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	var objects []DatabaseObject
-
-	// Get tables
-	tableQuery := "SELECT table_name FROM user_tables"
-	logSQL(tableQuery)
-	rows, err := o.db.Query(tableQuery)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query tables: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var tableName string
-		if err := rows.Scan(&tableName); err != nil {
-			return nil, fmt.Errorf("failed to scan table name: %w", err)
-		}
-		objects = append(objects, DatabaseObject{Type: "table", Name: tableName})
-	}
-
-	// Get views
-	viewQuery := "SELECT view_name FROM user_views"
-	logSQL(viewQuery)
-	rows, err = o.db.Query(viewQuery)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query views: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var viewName string
-		if err := rows.Scan(&viewName); err != nil {
-			return nil, fmt.Errorf("failed to scan view name: %w", err)
-		}
-		objects = append(objects, DatabaseObject{Type: "view", Name: viewName})
-	}
-
-	// Get indexes
-	indexQuery := "SELECT index_name, table_name FROM user_indexes"
-	logSQL(indexQuery)
-	rows, err = o.db.Query(indexQuery)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query indexes: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var indexName, tableName string
-		if err := rows.Scan(&indexName, &tableName); err != nil {
-			return nil, fmt.Errorf("failed to scan index name: %w", err)
-		}
-		objects = append(objects, DatabaseObject{Type: "index", Name: indexName})
-	}
-
-	// Get sequences
-	sequenceQuery := "SELECT sequence_name FROM user_sequences"
-	logSQL(sequenceQuery)
-	rows, err = o.db.Query(sequenceQuery)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query sequences: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var sequenceName string
-		if err := rows.Scan(&sequenceName); err != nil {
-			return nil, fmt.Errorf("failed to scan sequence name: %w", err)
-		}
-		objects = append(objects, DatabaseObject{Type: "sequence", Name: sequenceName})
-	}
-
-	// Get procedures
-	procedureQuery := "SELECT object_name FROM user_procedures"
-	logSQL(procedureQuery)
-	rows, err = o.db.Query(procedureQuery)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query procedures: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var procedureName string
-		if err := rows.Scan(&procedureName); err != nil {
-			return nil, fmt.Errorf("failed to scan procedure name: %w", err)
-		}
-		objects = append(objects, DatabaseObject{Type: "procedure", Name: procedureName})
-	}
-
-	// Get functions
-	functionQuery := "SELECT object_name FROM user_objects WHERE object_type = 'FUNCTION'"
-	logSQL(functionQuery)
-	rows, err = o.db.Query(functionQuery)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query functions: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var functionName string
-		if err := rows.Scan(&functionName); err != nil {
-			return nil, fmt.Errorf("failed to scan function name: %w", err)
-		}
-		objects = append(objects, DatabaseObject{Type: "function", Name: functionName})
-	}
-
-	return objects, nil
-}
-
-func (o *OracleDatabase) DestroyAllObjects() error {
-	if o.db == nil {
-		return fmt.Errorf("database not connected")
+		return ErrNotConnected
 	}
 
 	// Oracle-specific destroy logic
@@ -414,10 +136,19 @@ func (o *OracleDatabase) DestroyAllObjects() error {
 
 	for _, query := range queries {
 		logSQL(query)
-		if _, err := o.db.Exec(query); err != nil {
+		if _, err := o.db.ExecContext(ctx, query); err != nil {
 			return fmt.Errorf("failed to execute destroy query: %w", err)
 		}
 	}
 
 	return nil
 }
+
+// oraTableNotFound is ORA-00942: table or view does not exist
+const oraTableNotFound = 942
+
+// isOracleTableNotFound reports whether err is Oracle's ORA-00942 error
+func isOracleTableNotFound(err error) bool {
+	var oraErr *network.OracleError
+	return errors.As(err, &oraErr) && oraErr.ErrCode == oraTableNotFound
+}
@@ -1,40 +1,25 @@
 package db
 
 import (
-	"fmt"
 	"strings"
 )
 
+// ParseDatabaseType returns the database type of a connection string
 func ParseDatabaseType(connectionString string) (DatabaseType, error) {
-	if strings.HasPrefix(connectionString, "sqlite:") {
-		return SQLite, nil
-	}
-	if strings.HasPrefix(connectionString, "postgres://") {
-		return PostgreSQL, nil
-	}
-	if strings.HasPrefix(connectionString, "oracle://") {
-		return Oracle, nil
+	config, err := ParseConfig(connectionString)
+	if err != nil {
+		return "", err
 	}
-
-	return "", fmt.Errorf("unable to determine database type from connection string: %s", connectionString)
+	return config.Type, nil
 }
 
+// ExtractConnectionString returns the data source name the driver is opened with
 func ExtractConnectionString(connectionString string) (string, error) {
-	dbType, err := ParseDatabaseType(connectionString)
+	config, err := ParseConfig(connectionString)
 	if err != nil {
 		return "", err
 	}
-
-	switch dbType {
-	case SQLite:
-		return strings.TrimPrefix(connectionString, "sqlite:"), nil
-	case PostgreSQL:
-		return connectionString, nil
-	case Oracle:
-		return connectionString, nil
-	default:
-		return "", fmt.Errorf("unsupported database type")
-	}
+	return config.DSN, nil
 }
 
 // ParseSQLStatements splits SQL content into individual statements
@@ -72,6 +57,74 @@ func ParseSQLStatements(content string) []string {
 	return statements
 }
 
+// StatementLines returns the 1-based line in content where each parsed statement starts
+// Statements must be the result of ParseSQLStatements(content)
+func StatementLines(content string, statements []string) []int {
+	lines := make([]int, len(statements))
+	offset := 0
+	line := 1
+	for i, statement := range statements {
+		pos := strings.Index(content[offset:], statement)
+		if pos < 0 {
+			lines[i] = line
+			continue
+		}
+		line += strings.Count(content[offset:offset+pos], "\n")
+		lines[i] = line
+		line += strings.Count(statement, "\n")
+		offset += pos + len(statement)
+	}
+	return lines
+}
+
+// ParseMySQLStatements splits MySQL SQL content into individual statements.
+// It honours mysql client DELIMITER directives, so stored routines and triggers
+// containing semicolons can be written as
+//
+//	DELIMITER $$
+//	CREATE PROCEDURE p() BEGIN SELECT 1; END$$
+//	DELIMITER ;
+func ParseMySQLStatements(content string) []string {
+	statements := []string{}
+	delimiter := ";"
+	var block strings.Builder
+
+	flush := func() {
+		if delimiter == ";" {
+			statements = append(statements, ParseSQLStatements(block.String())...)
+		} else {
+			statements = append(statements, splitByDelimiter(block.String(), delimiter)...)
+		}
+		block.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.EqualFold(fields[0], "DELIMITER") {
+			flush()
+			delimiter = fields[1]
+			continue
+		}
+		block.WriteString(line)
+	}
+	flush()
+
+	return statements
+}
+
+// splitByDelimiter splits content by a custom delimiter, dropping empty and comment-only parts
+func splitByDelimiter(content, delimiter string) []string {
+	var statements []string
+	for _, part := range strings.Split(content, delimiter) {
+		trimmed := removeLeadingComments(strings.TrimSpace(part))
+		if trimmed == "" || isCommentOnly(trimmed) {
+			continue
+		}
+		statements = append(statements, strings.TrimSpace(trimmed))
+	}
+	return statements
+}
+
 // isCommentOnly checks if a statement contains only comments
 func isCommentOnly(statement string) bool {
 	lines := strings.Split(statement, "\n")
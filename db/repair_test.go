@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -9,42 +10,25 @@ import (
 func TestDeleteFailedMigrationRecords(t *testing.T) {
 	// Test with PostgreSQL
 	t.Run("PostgreSQL", func(t *testing.T) {
-		testDeleteFailedMigrationRecords(t, NewPostgreSQLDatabase())
+		// Skip PostgreSQL test if no connection string is provided
+		t.Skip("PostgreSQL test skipped - no test database configured")
 	})
 
 	// Test with SQLite
 	t.Run("SQLite", func(t *testing.T) {
-		testDeleteFailedMigrationRecords(t, NewSQLiteDatabase())
+		testDeleteFailedMigrationRecords(t, NewSQLiteDatabase(), ":memory:") // Use in-memory database for testing
 	})
 }
 
-func testDeleteFailedMigrationRecords(t *testing.T, db Database) {
-	// Create a temporary database for testing
-	var connStr string
-	var cleanup func()
-
-	switch db.(type) {
-	case *PostgreSQLDatabase:
-		// Skip PostgreSQL test if no connection string is provided
-		t.Skip("PostgreSQL test skipped - no test database configured")
-		return
-	case *SQLiteDatabase:
-		connStr = ":memory:" // Use in-memory database for testing
-		cleanup = func() {
-			db.Close()
-		}
-	default:
-		t.Skip("Unsupported database type for test")
-		return
-	}
-	defer cleanup()
+func testDeleteFailedMigrationRecords(t *testing.T, db Database, connStr string) {
+	defer db.Close()
 
 	// Connect to database
-	err := db.Connect(connStr)
+	err := db.Connect(context.Background(), connStr)
 	require.NoError(t, err)
 
 	// Create migration table
-	err = db.CreateMigrationTable("test_schema_version")
+	err = db.CreateMigrationTable(context.Background(), "test_schema_version")
 	require.NoError(t, err)
 
 	// Insert test migration records (some successful, some failed)
@@ -97,12 +81,12 @@ func testDeleteFailedMigrationRecords(t *testing.T, db Database) {
 
 	// Insert test records
 	for _, record := range testRecords {
-		err := db.InsertMigrationRecord("test_schema_version", record)
+		err := db.InsertMigrationRecord(context.Background(), "test_schema_version", record)
 		require.NoError(t, err)
 	}
 
 	// Verify all records are inserted
-	records, err := db.GetMigrationRecords("test_schema_version")
+	records, err := db.GetMigrationRecords(context.Background(), "test_schema_version")
 	require.NoError(t, err)
 	require.Len(t, records, 4)
 
@@ -119,11 +103,11 @@ func testDeleteFailedMigrationRecords(t *testing.T, db Database) {
 	require.Equal(t, 2, failedCount)
 
 	// Delete failed migration records
-	err = db.DeleteFailedMigrationRecords("test_schema_version")
+	err = db.DeleteFailedMigrationRecords(context.Background(), "test_schema_version")
 	require.NoError(t, err)
 
 	// Verify only successful records remain
-	records, err = db.GetMigrationRecords("test_schema_version")
+	records, err = db.GetMigrationRecords(context.Background(), "test_schema_version")
 	require.NoError(t, err)
 	require.Len(t, records, 2)
 
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SetPassword returns the connection string with its password set to password,
+// replacing any password it already contains
+func SetPassword(connectionString, password string) (string, error) {
+	config, err := ParseConfig(connectionString)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case config.Type == SQLite || config.Type == DuckDB:
+		return "", fmt.Errorf("%s connection strings have no password", config.Type)
+
+	case strings.Contains(connectionString, "://"):
+		u, err := url.Parse(connectionString)
+		if err != nil {
+			return "", fmt.Errorf("invalid connection string: %w", redactedURLError(err))
+		}
+		if u.User != nil {
+			u.User = url.UserPassword(u.User.Username(), password)
+		} else if config.Type == PostgreSQL {
+			// The user may come from PGUSER, so the password goes into the query
+			query := u.Query()
+			query.Set("password", password)
+			u.RawQuery = query.Encode()
+		} else {
+			return "", fmt.Errorf("%s connection string has no user for the password", config.Type)
+		}
+		return u.String(), nil
+
+	case config.Type == PostgreSQL:
+		// libpq uses the last of repeated keys
+		return connectionString + " password=" + quoteKeyValue(password), nil
+
+	default:
+		prefix := ""
+		descriptor := connectionString
+		if strings.HasPrefix(descriptor, "oracle:") {
+			prefix, descriptor = "oracle:", strings.TrimPrefix(descriptor, "oracle:")
+		}
+		user, _, target, _ := splitOracleDescriptor(descriptor)
+		return prefix + user + "/" + password + "@" + target, nil
+	}
+}
+
+// quoteKeyValue quotes a value for a libpq key=value connection string
+func quoteKeyValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// PgpassFile returns the PostgreSQL password file: PGPASSFILE, or .pgpass in the home directory
+func PgpassFile() string {
+	if file := os.Getenv("PGPASSFILE"); file != "" {
+		return file
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".pgpass")
+}
+
+// PgpassPermissionError reports a password file that others can read.
+// Like libpq, bloomdb ignores such files.
+type PgpassPermissionError struct {
+	File string
+	Mode os.FileMode
+}
+
+func (e *PgpassPermissionError) Error() string {
+	return fmt.Sprintf("password file %s has group or world access (%04o) and is ignored, permissions should be 0600 or less", e.File, e.Mode.Perm())
+}
+
+// LookupPgpass returns the password of the first line of a .pgpass file matching the
+// host, port, database and user of a PostgreSQL connection. Lines have the format
+// hostname:port:database:username:password, where * matches anything and \: and \\
+// escape. A missing file or no matching line return an empty password.
+func LookupPgpass(file string, config *Config) (string, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", nil
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return "", &PgpassPermissionError{File: file, Mode: info.Mode()}
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file %s: %w", file, err)
+	}
+	defer f.Close()
+
+	host := config.Host
+	// Connections through the default socket directory match "localhost" like in libpq
+	if strings.HasPrefix(host, "/") {
+		host = "localhost"
+	}
+	want := []string{host, strconv.Itoa(config.Port), config.Database, config.User}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := splitPgpassLine(line)
+		if len(fields) != 5 {
+			continue
+		}
+		matches := true
+		for i, value := range want {
+			if fields[i] != "*" && fields[i] != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return fields[4], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read password file %s: %w", file, err)
+	}
+	return "", nil
+}
+
+// splitPgpassLine splits a .pgpass line at unescaped colons
+func splitPgpassLine(line string) []string {
+	var fields []string
+	var field strings.Builder
+	escaped := false
+	for _, c := range line {
+		switch {
+		case escaped:
+			field.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == ':':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteRune(c)
+		}
+	}
+	return append(fields, field.String())
+}
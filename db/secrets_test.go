@@ -0,0 +1,91 @@
+package db
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPassword(t *testing.T) {
+	clearPostgresEnv(t)
+	password := `p@ss w'rd\`
+
+	tests := []struct {
+		name string
+		conn string
+		user string
+	}{
+		{"PostgreSQL URL", "postgres://app:old@db/app", "app"},
+		{"PostgreSQL URL without password", "postgresql://app@db/app", "app"},
+		{"PostgreSQL URL without user", "postgresql://db/app", ""},
+		{"Key value DSN", "host=db user=app password=old", "app"},
+		{"Oracle URL", "oracle://scott@db:1521/XE", "scott"},
+		{"Oracle EZConnect", "scott/old@db:1521/XE", "scott"},
+		{"Prefixed EZConnect", "oracle:scott/@//db/XE", "scott"},
+		{"Oracle TNS", "scott/old@(DESCRIPTION=(ADDRESS=(HOST=db)(PORT=1521))(CONNECT_DATA=(SERVICE_NAME=XE)))", "scott"},
+		{"MySQL URL", "mysql://root@db/app", "root"},
+		{"Password with spaces", "scott/a b@db/XE", "scott"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := SetPassword(tt.conn, password)
+			require.NoError(t, err)
+			config, err := ParseConfig(conn)
+			require.NoError(t, err, conn)
+			assert.Equal(t, password, config.Password)
+			if tt.user != "" {
+				assert.Equal(t, tt.user, config.User)
+			}
+		})
+	}
+
+	_, err := SetPassword("sqlite:./app.db", password)
+	assert.ErrorContains(t, err, "sqlite connection strings have no password")
+
+	_, err = SetPassword("oracle://db:1521/XE", password)
+	assert.ErrorContains(t, err, "has no user for the password")
+}
+
+func TestLookupPgpass(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".pgpass")
+	require.NoError(t, os.WriteFile(file, []byte(`# comment
+db.example.com:5432:orders:app:orders-password
+*:*:*:report:report\:pass\\word
+localhost:5432:*:app:local-password
+`), 0600))
+
+	tests := []struct {
+		name     string
+		config   Config
+		password string
+	}{
+		{"Exact match", Config{Host: "db.example.com", Port: 5432, Database: "orders", User: "app"}, "orders-password"},
+		{"Wildcards and escapes", Config{Host: "other", Port: 6432, Database: "sales", User: "report"}, `report:pass\word`},
+		{"Socket matches localhost", Config{Host: "/var/run/postgresql", Port: 5432, Database: "sales", User: "app"}, "local-password"},
+		{"No match", Config{Host: "db.example.com", Port: 5433, Database: "orders", User: "app"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			password, err := LookupPgpass(file, &tt.config)
+			require.NoError(t, err)
+			assert.Equal(t, tt.password, password)
+		})
+	}
+
+	password, err := LookupPgpass(filepath.Join(t.TempDir(), "missing"), &Config{})
+	require.NoError(t, err)
+	assert.Empty(t, password)
+
+	// Like libpq, files readable by others are ignored
+	require.NoError(t, os.Chmod(file, 0644))
+	_, err = LookupPgpass(file, &Config{Host: "db.example.com", Port: 5432, Database: "orders", User: "app"})
+	var permissionErr *PgpassPermissionError
+	require.True(t, errors.As(err, &permissionErr), "expected PgpassPermissionError, got %v", err)
+	assert.Contains(t, err.Error(), "has group or world access (0644)")
+}
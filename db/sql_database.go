@@ -0,0 +1,571 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sqlDatabase is the generic version table repository shared by all backends.
+// It implements every Database operation that can be expressed through a Dialect;
+// backends embed it and add the rest, such as DestroyAllObjects.
+type sqlDatabase struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func newSQLDatabase(dialect Dialect) sqlDatabase {
+	return sqlDatabase{dialect: dialect}
+}
+
+func (s *sqlDatabase) Connect(ctx context.Context, connectionString string) error {
+	db, err := sql.Open(s.dialect.DriverName(), connectionString)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", s.dialect.Name(), err)
+	}
+	s.db = db
+	return nil
+}
+
+func (s *sqlDatabase) Close() error {
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+func (s *sqlDatabase) Ping(ctx context.Context) error {
+	if s.db == nil {
+		return ErrNotConnected
+	}
+	return s.db.PingContext(ctx)
+}
+
+func (s *sqlDatabase) GetDB() *sql.DB {
+	return s.db
+}
+
+func (s *sqlDatabase) TableExists(ctx context.Context, tableName string) (bool, error) {
+	if s.db == nil {
+		return false, ErrNotConnected
+	}
+
+	table, err := ParseTableIdentifier(tableName)
+	if err != nil {
+		return false, err
+	}
+
+	query, args := s.dialect.TableExistsQuery(table.fold(s.dialect))
+	logSQL(query, args...)
+	var result string
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&result)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error checking table existence: %w", err)
+	}
+	return true, nil
+}
+
+func (s *sqlDatabase) CreateMigrationTable(ctx context.Context, tableName string) error {
+	if s.db == nil {
+		return ErrNotConnected
+	}
+
+	table, err := ParseTableIdentifier(tableName)
+	if err != nil {
+		return err
+	}
+	table = table.fold(s.dialect)
+
+	var definitions []string
+	for _, column := range historyColumns {
+		definitions = append(definitions, s.dialect.QuoteIdentifier(column.name)+" "+s.dialect.ColumnType(column.kind, column.size))
+	}
+	definitions = append(definitions, "PRIMARY KEY ("+s.dialect.QuoteIdentifier("installed_rank")+")")
+	query := fmt.Sprintf("CREATE TABLE %s (\n\t%s\n)", table.quote(s.dialect), strings.Join(definitions, ",\n\t"))
+
+	logSQL(query)
+	_, err = s.db.ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to create migration table %s: %w", tableName, err)
+	}
+
+	return s.setSchemaVersion(ctx, table, HistorySchemaVersion)
+}
+
+func (s *sqlDatabase) InsertBaselineRecord(ctx context.Context, tableName, version string, installer Installer) error {
+	if s.db == nil {
+		return ErrNotConnected
+	}
+
+	// Convert version to integer for installed rank
+	record := MigrationRecord{
+		InstalledRank: versionToInt(version),
+		Version:       &version,
+		Description:   "<< Baseline >>",
+		Type:          "BASELINE",
+		Script:        "<< Baseline >>",
+		InstalledBy:   installer.By,
+		InstalledFrom: installer.From,
+		Environment:   installer.Environment,
+		ExecutionTime: 0,
+		Success:       1,
+	}
+	if err := s.insertRecord(ctx, tableName, record); err != nil {
+		return fmt.Errorf("failed to insert baseline record: %w", err)
+	}
+
+	return nil
+}
+
+// SessionUser returns the database user of the session, or an empty string if the database has no users
+func (s *sqlDatabase) SessionUser(ctx context.Context) (string, error) {
+	if s.db == nil {
+		return "", ErrNotConnected
+	}
+
+	query := s.dialect.SessionUserQuery()
+	if query == "" {
+		return "", nil
+	}
+
+	logSQL(query)
+	var user sql.NullString
+	if err := s.db.QueryRowContext(ctx, query).Scan(&user); err != nil {
+		return "", fmt.Errorf("failed to query session user: %w", err)
+	}
+	return user.String, nil
+}
+
+func (s *sqlDatabase) GetMigrationRecords(ctx context.Context, tableName string) ([]MigrationRecord, error) {
+	if s.db == nil {
+		return nil, ErrNotConnected
+	}
+
+	parsed, err := ParseTableIdentifier(tableName)
+	if err != nil {
+		return nil, err
+	}
+	table := parsed.fold(s.dialect)
+
+	// A table that has not been upgraded yet lacks the newer columns, which read as NULL
+	existing, err := s.columnNames(ctx, table)
+	if err != nil {
+		if s.dialect.IsTableNotFound(err) {
+			return nil, tableNotFoundError(tableName, err)
+		}
+		return nil, fmt.Errorf("failed to query migration records: %w", err)
+	}
+	columns := make([]string, len(historyColumns))
+	for i, column := range historyColumns {
+		columns[i] = "NULL"
+		if existing[column.name] {
+			columns[i] = s.dialect.QuoteIdentifier(column.name)
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s ORDER BY %s",
+		strings.Join(columns, ", "), table.quote(s.dialect), s.dialect.QuoteIdentifier("installed_rank"))
+
+	logSQL(query)
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query migration records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []MigrationRecord
+	for rows.Next() {
+		var record MigrationRecord
+		var bloomdbVersion, tags, environment, installedFrom, precondition sql.NullString
+		err := rows.Scan(&record.InstalledRank, &record.Version, &record.Description, &record.Type, &record.Script, &record.Checksum, &record.InstalledBy, &record.InstalledOn, &record.ExecutionTime, &record.Success,
+			&record.ChecksumSHA256, &bloomdbVersion, &tags, &environment, &installedFrom, &precondition, &record.ChecksumNormalized)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan migration record: %w", err)
+		}
+		record.BloomDBVersion = bloomdbVersion.String
+		record.Tags = tags.String
+		record.Environment = environment.String
+		record.InstalledFrom = installedFrom.String
+		record.Precondition = precondition.String
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+func (s *sqlDatabase) InsertMigrationRecord(ctx context.Context, tableName string, record MigrationRecord) error {
+	if s.db == nil {
+		return ErrNotConnected
+	}
+
+	if err := s.insertRecord(ctx, tableName, record); err != nil {
+		return fmt.Errorf("failed to insert migration record: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlDatabase) UpdateMigrationRecord(ctx context.Context, tableName string, installedRank int, version, description string, checksum int64, checksumSHA256, checksumNormalized string) error {
+	if s.db == nil {
+		return ErrNotConnected
+	}
+
+	table, err := s.tableRef(tableName)
+	if err != nil {
+		return err
+	}
+
+	q := s.dialect.QuoteIdentifier
+	args := []interface{}{description, checksum, nullIfEmpty(checksumSHA256), nullIfEmpty(checksumNormalized), installedRank}
+	query := fmt.Sprintf("UPDATE %s SET %s = %s, %s = %s, %s = %s, %s = %s WHERE %s = %s AND ",
+		table,
+		q("description"), s.dialect.Placeholder(1),
+		q("checksum"), s.dialect.Placeholder(2),
+		q("checksum_sha256"), s.dialect.Placeholder(3),
+		q("checksum_normalized"), s.dialect.Placeholder(4),
+		q("installed_rank"), s.dialect.Placeholder(5))
+
+	// Repeatable migrations have no version, and NULL never compares equal
+	if version == "" {
+		query += q("version") + " IS NULL"
+	} else {
+		args = append(args, version)
+		query += q("version") + " = " + s.dialect.Placeholder(6)
+	}
+
+	logSQL(query, args...)
+	_, err = s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update migration record: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateMigrationRecordFull overwrites the record matching installed rank and version.
+// installed_on is refreshed to the current time, as on insert. The installed rank is the
+// key and is left alone; DuckDB rejects updates that rewrite an indexed column.
+func (s *sqlDatabase) UpdateMigrationRecordFull(ctx context.Context, tableName string, record MigrationRecord) error {
+	if s.db == nil {
+		return ErrNotConnected
+	}
+
+	table, err := s.tableRef(tableName)
+	if err != nil {
+		return err
+	}
+
+	values := recordValues(record)
+	var assignments []string
+	var args []interface{}
+	for i, column := range historyColumns {
+		switch column.name {
+		case "installed_rank":
+			continue
+		case "installed_on":
+			assignments = append(assignments, s.dialect.QuoteIdentifier(column.name)+" = "+s.dialect.CurrentTimestamp())
+			continue
+		}
+		args = append(args, values[i])
+		assignments = append(assignments, s.dialect.QuoteIdentifier(column.name)+" = "+s.dialect.Placeholder(len(args)))
+	}
+
+	// Repeatable migrations have no version, and NULL never compares equal
+	args = append(args, record.InstalledRank)
+	where := s.dialect.QuoteIdentifier("installed_rank") + " = " + s.dialect.Placeholder(len(args))
+	if record.Version == nil {
+		where += " AND " + s.dialect.QuoteIdentifier("version") + " IS NULL"
+	} else {
+		args = append(args, *record.Version)
+		where += " AND " + s.dialect.QuoteIdentifier("version") + " = " + s.dialect.Placeholder(len(args))
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(assignments, ", "), where)
+
+	logSQL(query, args...)
+	_, err = s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update migration record: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlDatabase) DeleteFailedMigrationRecords(ctx context.Context, tableName string) error {
+	if s.db == nil {
+		return ErrNotConnected
+	}
+
+	table, err := s.tableRef(tableName)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s != 1", table, s.dialect.QuoteIdentifier("success"))
+
+	logSQL(query)
+	result, err := s.db.ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to delete failed migration records: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		fmt.Printf("Removed %d failed migration records from %s\n", rowsAffected, tableName)
+	} else {
+		fmt.Printf("No failed migration records found in %s\n", tableName)
+	}
+
+	return nil
+}
+
+func (s *sqlDatabase) ExecuteMigration(ctx context.Context, content string) error {
+	if s.db == nil {
+		return ErrNotConnected
+	}
+
+	// Parse the SQL content into individual statements
+	statements := s.dialect.SplitStatements(content)
+	lines := StatementLines(content, statements)
+
+	// Scripts with their own BEGIN/COMMIT keep running statement by statement
+	if !s.dialect.TransactionalDDL() || TransactionDisabled(ctx) || controlsTransaction(statements) {
+		return executeStatements(ctx, s.db, statements, lines)
+	}
+
+	// Cancellation is handled per statement, which reports the statement it interrupted
+	logSQL("BEGIN")
+	tx, err := s.db.BeginTx(context.WithoutCancel(ctx), nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := executeStatements(ctx, tx, statements, lines); err != nil {
+		logSQL("ROLLBACK")
+		tx.Rollback()
+		return err
+	}
+	logSQL("COMMIT")
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return nil
+}
+
+// EvaluatePrecondition runs a precondition query and returns its result as a boolean
+func (s *sqlDatabase) EvaluatePrecondition(ctx context.Context, query string) (bool, error) {
+	if s.db == nil {
+		return false, ErrNotConnected
+	}
+
+	logSQL(query)
+	var value interface{}
+	err := s.db.QueryRowContext(ctx, query).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate precondition: %w", err)
+	}
+	return truthy(value)
+}
+
+// truthy interprets a query result as a boolean. Oracle and MySQL have no boolean
+// results, so non-zero numbers and strings such as "true" or "1" are accepted too.
+func truthy(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case []byte:
+		value = string(v)
+	}
+
+	text := strings.TrimSpace(fmt.Sprint(value))
+	if b, err := strconv.ParseBool(text); err == nil {
+		return b, nil
+	}
+	if n, err := strconv.ParseFloat(text, 64); err == nil {
+		return n != 0, nil
+	}
+	return false, fmt.Errorf("precondition returned %q, expected a boolean or a number", text)
+}
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// executeStatements runs each statement with the statement timeout from ctx
+func executeStatements(ctx context.Context, target execer, statements []string, lines []int) error {
+	for i, statement := range statements {
+		logSQL(statement)
+		stmtCtx, cancel := statementContext(ctx)
+		_, err := target.ExecContext(stmtCtx, statement)
+		cancel()
+		if err != nil {
+			// Drivers like lib/pq report an interrupted statement as a server error,
+			// so the cancellation is kept in the cause for callers checking ctx errors
+			if ctx.Err() != nil && !errors.Is(err, ctx.Err()) {
+				err = fmt.Errorf("%w (%w)", err, ctx.Err())
+			}
+			return &StatementError{Index: i + 1, Line: lines[i], SQL: statement, Cause: err}
+		}
+	}
+	return nil
+}
+
+// transactionKeywords start statements that control the transaction themselves
+var transactionKeywords = map[string]bool{
+	"BEGIN": true, "START": true, "COMMIT": true, "END": true, "ROLLBACK": true, "SAVEPOINT": true, "RELEASE": true,
+}
+
+// controlsTransaction reports whether a script begins or ends transactions itself
+func controlsTransaction(statements []string) bool {
+	for _, statement := range statements {
+		fields := strings.Fields(stripLeadingComments(statement))
+		if len(fields) > 0 && transactionKeywords[strings.ToUpper(strings.TrimSuffix(fields[0], ";"))] {
+			return true
+		}
+	}
+	return false
+}
+
+// stripLeadingComments removes "--" and "/* */" comments before the first token of a statement
+func stripLeadingComments(statement string) string {
+	for {
+		statement = strings.TrimSpace(statement)
+		switch {
+		case strings.HasPrefix(statement, "--"):
+			end := strings.IndexByte(statement, '\n')
+			if end < 0 {
+				return ""
+			}
+			statement = statement[end+1:]
+		case strings.HasPrefix(statement, "/*"):
+			end := strings.Index(statement, "*/")
+			if end < 0 {
+				return ""
+			}
+			statement = statement[end+2:]
+		default:
+			return statement
+		}
+	}
+}
+
+func (s *sqlDatabase) GetDatabaseObjects(ctx context.Context) ([]DatabaseObject, error) {
+	if s.db == nil {
+		return nil, ErrNotConnected
+	}
+
+	var objects []DatabaseObject
+	for _, q := range s.dialect.ObjectQueries() {
+		logSQL(q.Query)
+		names, err := s.queryNames(ctx, q.Query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %ss: %w", q.Type, err)
+		}
+		for _, name := range names {
+			objects = append(objects, DatabaseObject{Type: q.Type, Name: name})
+		}
+	}
+
+	return objects, nil
+}
+
+// insertRecord inserts record into the version table, stamping installed_on with the current time
+func (s *sqlDatabase) insertRecord(ctx context.Context, tableName string, record MigrationRecord) error {
+	table, err := s.tableRef(tableName)
+	if err != nil {
+		return err
+	}
+
+	values := recordValues(record)
+	var placeholders []string
+	var args []interface{}
+	for i, column := range historyColumns {
+		if column.name == "installed_on" {
+			placeholders = append(placeholders, s.dialect.CurrentTimestamp())
+			continue
+		}
+		args = append(args, values[i])
+		placeholders = append(placeholders, s.dialect.Placeholder(len(args)))
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, s.columnList(), strings.Join(placeholders, ", "))
+
+	logSQL(query, args...)
+	_, err = s.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// tableRef validates tableName and returns it folded and quoted for use in SQL statements
+func (s *sqlDatabase) tableRef(tableName string) (string, error) {
+	table, err := ParseTableIdentifier(tableName)
+	if err != nil {
+		return "", err
+	}
+	return table.fold(s.dialect).quote(s.dialect), nil
+}
+
+// columnList returns the quoted version table columns, comma separated
+func (s *sqlDatabase) columnList() string {
+	names := make([]string, len(historyColumns))
+	for i, column := range historyColumns {
+		names[i] = s.dialect.QuoteIdentifier(column.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// queryNames runs a query returning a single name column
+func (s *sqlDatabase) queryNames(ctx context.Context, query string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// recordValues returns the values of record in historyColumns order.
+// The running bloomdb version is recorded unless the record names one.
+func recordValues(record MigrationRecord) []interface{} {
+	bloomdbVersion := record.BloomDBVersion
+	if bloomdbVersion == "" {
+		bloomdbVersion = BloomDBVersion
+	}
+	return []interface{}{
+		record.InstalledRank, record.Version, record.Description, record.Type, record.Script,
+		record.Checksum, record.InstalledBy, record.InstalledOn, record.ExecutionTime, record.Success,
+		record.ChecksumSHA256, bloomdbVersion, nullIfEmpty(record.Tags), nullIfEmpty(record.Environment),
+		nullIfEmpty(record.InstalledFrom), nullIfEmpty(record.Precondition), record.ChecksumNormalized,
+	}
+}
+
+// nullIfEmpty stores empty optional strings as NULL, as Oracle does anyway
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLDatabase_UpdateMigrationRecordFull(t *testing.T) {
+	testUpdateMigrationRecordFull(t, NewSQLiteDatabase(), ":memory:")
+}
+
+// testUpdateMigrationRecordFull updates a versioned and a repeatable (NULL version) record
+func testUpdateMigrationRecordFull(t *testing.T, db Database, connStr string) {
+	ctx := context.Background()
+	require.NoError(t, db.Connect(ctx, connStr))
+	defer db.Close()
+	require.NoError(t, db.CreateMigrationTable(ctx, "history"))
+
+	require.NoError(t, db.InsertBaselineRecord(ctx, "history", "1", Installer{By: "test"}))
+	require.NoError(t, db.InsertMigrationRecord(ctx, "history", MigrationRecord{
+		InstalledRank: 2, Description: "views", Type: "repeatable", Script: "R__views.sql",
+		Checksum: int64Ptr(1), InstalledBy: "test", Success: 0,
+	}))
+
+	require.NoError(t, db.UpdateMigrationRecordFull(ctx, "history", MigrationRecord{
+		InstalledRank: 2, Description: "views", Type: "repeatable", Script: "R__views.sql",
+		Checksum: int64Ptr(2), InstalledBy: "test", ExecutionTime: 5, Success: 1, Precondition: "skipped",
+	}))
+	require.NoError(t, db.UpdateMigrationRecordFull(ctx, "history", MigrationRecord{
+		InstalledRank: 1, Version: stringPtr("1"), Description: "<< Baseline >>", Type: "BASELINE",
+		Script: "<< Baseline >>", InstalledBy: "admin", Success: 1,
+	}))
+
+	records, err := db.GetMigrationRecords(ctx, "history")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "admin", records[0].InstalledBy)
+	assert.NotEmpty(t, records[0].InstalledOn)
+	assert.Nil(t, records[1].Version)
+	assert.Equal(t, int64(2), *records[1].Checksum)
+	assert.Equal(t, 1, records[1].Success)
+	assert.Equal(t, 5, records[1].ExecutionTime)
+	assert.Equal(t, "skipped", records[1].Precondition)
+}
+
+func TestSQLDatabase_ExecuteMigrationTransaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     func(context.Context) context.Context
+		script  string
+		applied bool // whether the first statement survives the failing second one
+	}{
+		{"Rolled back in a transaction", func(ctx context.Context) context.Context { return ctx },
+			"CREATE TABLE a (id INTEGER);\nINSERT INTO missing VALUES (1);", false},
+		{"Kept without a transaction", WithoutTransaction,
+			"CREATE TABLE a (id INTEGER);\nINSERT INTO missing VALUES (1);", true},
+		{"Script controls its own transaction", func(ctx context.Context) context.Context { return ctx },
+			"BEGIN;\nCREATE TABLE a (id INTEGER);\nCOMMIT;\nINSERT INTO missing VALUES (1);", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := NewSQLiteDatabase()
+			require.NoError(t, db.Connect(ctx, ":memory:"))
+			defer db.Close()
+			db.GetDB().SetMaxOpenConns(1)
+
+			require.Error(t, db.ExecuteMigration(tt.ctx(ctx), tt.script))
+
+			exists, err := db.TableExists(ctx, "a")
+			require.NoError(t, err)
+			assert.Equal(t, tt.applied, exists)
+		})
+	}
+}
+
+func TestControlsTransaction(t *testing.T) {
+	assert.False(t, controlsTransaction([]string{"CREATE TABLE a (id INT)", "INSERT INTO a VALUES (1)"}))
+	assert.False(t, controlsTransaction([]string{"CREATE TRIGGER t AFTER INSERT ON a BEGIN SELECT 1; END"}))
+	assert.True(t, controlsTransaction([]string{"-- start\nBEGIN", "CREATE TABLE a (id INT)", "COMMIT"}))
+	assert.True(t, controlsTransaction([]string{"/* tx */ start transaction"}))
+}
+
+func TestSQLDatabase_UpdateMigrationRecord(t *testing.T) {
+	ctx := context.Background()
+	db := NewSQLiteDatabase()
+	require.NoError(t, db.Connect(ctx, ":memory:"))
+	defer db.Close()
+	require.NoError(t, db.CreateMigrationTable(ctx, "history"))
+
+	require.NoError(t, db.InsertMigrationRecord(ctx, "history", MigrationRecord{
+		InstalledRank: 1, Version: stringPtr("1"), Description: "users", Type: "versioned", Script: "V1__users",
+		Checksum: int64Ptr(1), InstalledBy: "test", Success: 1,
+	}))
+	require.NoError(t, db.InsertMigrationRecord(ctx, "history", MigrationRecord{
+		InstalledRank: 2, Description: "views", Type: "repeatable", Script: "R__views",
+		Checksum: int64Ptr(2), InstalledBy: "test", Success: 1,
+	}))
+
+	require.NoError(t, db.UpdateMigrationRecord(ctx, "history", 1, "1", "create_users", 10, "aa", "cc"))
+	require.NoError(t, db.UpdateMigrationRecord(ctx, "history", 2, "", "views", 2, "bb", ""))
+
+	records, err := db.GetMigrationRecords(ctx, "history")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "create_users", records[0].Description)
+	assert.Equal(t, int64(10), *records[0].Checksum)
+	assert.Equal(t, "aa", *records[0].ChecksumSHA256)
+	assert.Equal(t, "cc", *records[0].ChecksumNormalized)
+	assert.Equal(t, "bb", *records[1].ChecksumSHA256)
+	assert.Nil(t, records[1].ChecksumNormalized)
+}
+
+func TestSQLDatabase_EvaluatePrecondition(t *testing.T) {
+	ctx := context.Background()
+	db := NewSQLiteDatabase()
+	require.NoError(t, db.Connect(ctx, ":memory:"))
+	defer db.Close()
+	require.NoError(t, db.ExecuteMigration(ctx, "CREATE TABLE users (name TEXT); INSERT INTO users VALUES ('a');"))
+
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{"SELECT COUNT(*) = 0 FROM users", false},
+		{"SELECT COUNT(*) FROM users", true},
+		{"SELECT name FROM users WHERE name = 'b'", false},
+		{"SELECT NULL", false},
+		{"SELECT 'true'", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			result, err := db.EvaluatePrecondition(ctx, tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	_, err := db.EvaluatePrecondition(ctx, "SELECT name FROM users")
+	assert.ErrorContains(t, err, `precondition returned "a"`)
+	_, err = db.EvaluatePrecondition(ctx, "SELECT * FROM missing")
+	assert.ErrorContains(t, err, "failed to evaluate precondition")
+}
+
+func TestTruthy(t *testing.T) {
+	for _, value := range []interface{}{true, int64(1), int64(-3), 0.5, []byte("1"), "TRUE", "t"} {
+		result, err := truthy(value)
+		require.NoError(t, err)
+		assert.True(t, result, "%#v", value)
+	}
+	for _, value := range []interface{}{nil, false, int64(0), 0.0, []byte("0"), "false", "F"} {
+		result, err := truthy(value)
+		require.NoError(t, err)
+		assert.False(t, result, "%#v", value)
+	}
+}
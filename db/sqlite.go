@@ -1,330 +1,97 @@
 package db
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 type SQLiteDatabase struct {
-	db *sql.DB
+	sqlDatabase
 }
 
 func NewSQLiteDatabase() *SQLiteDatabase {
-	return &SQLiteDatabase{}
+	return &SQLiteDatabase{newSQLDatabase(sqliteDialect{})}
 }
 
-func (s *SQLiteDatabase) Connect(connectionString string) error {
-	db, err := sql.Open("sqlite3", connectionString)
-	if err != nil {
-		return fmt.Errorf("failed to connect to SQLite: %w", err)
-	}
-	s.db = db
-	return nil
-}
+// sqliteDialect describes SQLite's SQL for the version table
+type sqliteDialect struct{}
 
-func (s *SQLiteDatabase) Close() error {
-	if s.db != nil {
-		return s.db.Close()
-	}
-	return nil
-}
+func (sqliteDialect) Name() string       { return "SQLite" }
+func (sqliteDialect) DriverName() string { return sqliteDriverName }
 
-func (s *SQLiteDatabase) Ping() error {
-	if s.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-	return s.db.Ping()
-}
+func (sqliteDialect) Placeholder(n int) string { return questionPlaceholder(n) }
 
-func (s *SQLiteDatabase) GetDB() *sql.DB {
-	return s.db
-}
+func (sqliteDialect) QuoteIdentifier(name string) string { return doubleQuote(name) }
 
-func (s *SQLiteDatabase) TableExists(tableName string) (bool, error) {
-	query := "SELECT name FROM sqlite_master WHERE type='table' AND name=?"
-	logSQL(query, tableName)
-	var result string
-	err := s.db.QueryRow(query, tableName).Scan(&result)
-	if err != nil {
-		if err.Error() == "sql: no rows in result set" {
-			return false, nil
-		}
-		return false, fmt.Errorf("error checking table existence: %w", err)
-	}
-	return true, nil
-}
+// FoldIdentifier keeps the given case; SQLite identifiers are case-insensitive
+func (sqliteDialect) FoldIdentifier(name string) string { return name }
 
-func (s *SQLiteDatabase) CreateMigrationTable(tableName string) error {
-	if s.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	query := fmt.Sprintf(`
-		CREATE TABLE %s (
-			installed_rank INTEGER,
-			version TEXT,
-			description TEXT,
-			type TEXT,
-			script TEXT,
-			checksum INTEGER,
-			installed_by TEXT,
-			installed_on DATETIME,
-			execution_time INTEGER,
-			success INTEGER
-		)
-	`, tableName)
-
-	logSQL(query)
-	_, err := s.db.Exec(query)
-	if err != nil {
-		return fmt.Errorf("failed to create migration table %s: %w", tableName, err)
+// ColumnType uses SQLite's type affinities; sizes are not enforced
+func (sqliteDialect) ColumnType(kind ColumnKind, size int) string {
+	switch kind {
+	case ColumnInteger, ColumnBigInt:
+		return "INTEGER"
+	case ColumnTimestamp:
+		return "DATETIME"
+	default:
+		return "TEXT"
 	}
-
-	return nil
 }
 
-func (s *SQLiteDatabase) InsertBaselineRecord(tableName, version string) error {
-	if s.db == nil {
-		return fmt.Errorf("database not connected")
-	}
+func (sqliteDialect) CurrentTimestamp() string { return "datetime('now')" }
 
-	query := fmt.Sprintf(`
-		INSERT INTO %s (installed_rank, version, description, type, script, checksum, installed_by, installed_on, execution_time, success)
-		VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'), ?, ?)
-	`, tableName)
-
-	// Convert version to integer for installed rank
-	installedRank := versionToInt(version)
-	logSQL(query, installedRank, version, "<< Baseline >>", "BASELINE", "<< Baseline >>", nil, "bloomdb", 0, 1)
-	_, err := s.db.Exec(query, installedRank, version, "<< Baseline >>", "BASELINE", "<< Baseline >>", nil, "bloomdb", 0, 1)
-	if err != nil {
-		return fmt.Errorf("failed to insert baseline record: %w", err)
-	}
-
-	return nil
+func (d sqliteDialect) AddColumn(table TableIdentifier, column, columnType string) string {
+	return alterTableAddColumn(d, table, column, columnType)
 }
 
-func (s *SQLiteDatabase) GetMigrationRecords(tableName string) ([]MigrationRecord, error) {
-	if s.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := fmt.Sprintf(`
-		SELECT installed_rank, version, description, type, script, checksum, installed_by, installed_on, execution_time, success
-		FROM %s 
-		ORDER BY installed_rank
-	`, tableName)
-
-	logSQL(query)
-	rows, err := s.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query migration records: %w", err)
-	}
-	defer rows.Close()
-
-	var records []MigrationRecord
-	for rows.Next() {
-		var record MigrationRecord
-		err := rows.Scan(&record.InstalledRank, &record.Version, &record.Description, &record.Type, &record.Script, &record.Checksum, &record.InstalledBy, &record.InstalledOn, &record.ExecutionTime, &record.Success)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan migration record: %w", err)
-		}
-		records = append(records, record)
-	}
-
-	return records, nil
+// AlterColumnType is empty: SQLite stores strings of any length in a TEXT column
+func (sqliteDialect) AlterColumnType(TableIdentifier, string, string) string {
+	return ""
 }
 
-func (s *SQLiteDatabase) InsertMigrationRecord(tableName string, record MigrationRecord) error {
-	if s.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	query := fmt.Sprintf(`
-		INSERT INTO %s (installed_rank, version, description, type, script, checksum, installed_by, installed_on, execution_time, success)
-		VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'), ?, ?)
-	`, tableName)
-
-	logSQL(query, record.InstalledRank, record.Version, record.Description, record.Type, record.Script, record.Checksum, record.InstalledBy, record.ExecutionTime, record.Success)
-	_, err := s.db.Exec(query, record.InstalledRank, record.Version, record.Description, record.Type, record.Script, record.Checksum, record.InstalledBy, record.ExecutionTime, record.Success)
-	if err != nil {
-		return fmt.Errorf("failed to insert migration record: %w", err)
-	}
-
-	return nil
+// AddPrimaryKey creates a unique index; SQLite cannot add a primary key to an existing table
+func (d sqliteDialect) AddPrimaryKey(table TableIdentifier, column string) string {
+	index := TableIdentifier{Schema: table.Schema, Name: table.Name + "_pk"}
+	return "CREATE UNIQUE INDEX " + index.quote(d) + " ON " + d.QuoteIdentifier(table.Name) + " (" + d.QuoteIdentifier(column) + ")"
 }
 
-func (s *SQLiteDatabase) UpdateMigrationRecord(tableName string, installedRank int, version, description string, checksum int64) error {
-	if s.db == nil {
-		return fmt.Errorf("database not connected")
-	}
+func (sqliteDialect) TransactionalDDL() bool { return true }
 
-	query := fmt.Sprintf(`
-		UPDATE %s 
-		SET description = ?, checksum = ?
-		WHERE installed_rank = ? AND version = ?
-	`, tableName)
-
-	logSQL(query, description, checksum, installedRank, version)
-	_, err := s.db.Exec(query, description, checksum, installedRank, version)
-	if err != nil {
-		return fmt.Errorf("failed to update migration record: %w", err)
-	}
-
-	return nil
+// SessionUserQuery is empty: SQLite has no database users
+func (sqliteDialect) SessionUserQuery() string {
+	return ""
 }
 
-func (s *SQLiteDatabase) UpdateMigrationRecordFull(tableName string, record MigrationRecord) error {
-	if s.db == nil {
-		return fmt.Errorf("database not connected")
+// TableExistsQuery reads the catalog of the attached database named by the schema, if any
+func (sqliteDialect) TableExistsQuery(table TableIdentifier) (string, []interface{}) {
+	catalog := "sqlite_master"
+	if table.Schema != "" {
+		catalog = doubleQuote(table.Schema) + ".sqlite_master"
 	}
-
-	query := fmt.Sprintf(`
-		UPDATE %s 
-		SET installed_rank = ?, version = ?, description = ?, type = ?, script = ?, checksum = ?, 
-			installed_by = ?, installed_on = ?, execution_time = ?, success = ?
-		WHERE installed_rank = ? AND (version = ? OR (version IS NULL AND ? IS NULL))
-	`, tableName)
-
-	var versionPtr *string
-	if record.Version != nil {
-		versionPtr = record.Version
-	}
-
-	logSQL(query, record.InstalledRank, versionPtr, record.Description, record.Type, record.Script, record.Checksum, record.InstalledBy, record.InstalledOn, record.ExecutionTime, record.Success, record.InstalledRank, versionPtr, versionPtr)
-	_, err := s.db.Exec(query,
-		record.InstalledRank, versionPtr, record.Description, record.Type, record.Script,
-		record.Checksum, record.InstalledBy, record.InstalledOn, record.ExecutionTime, record.Success,
-		record.InstalledRank, versionPtr, versionPtr)
-	if err != nil {
-		return fmt.Errorf("failed to update migration record: %w", err)
-	}
-
-	return nil
-}
-
-func (s *SQLiteDatabase) DeleteFailedMigrationRecords(tableName string) error {
-	if s.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	query := fmt.Sprintf(`
-		DELETE FROM %s 
-		WHERE success != 1
-	`, tableName)
-
-	logSQL(query)
-	result, err := s.db.Exec(query)
-	if err != nil {
-		return fmt.Errorf("failed to delete failed migration records: %w", err)
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected > 0 {
-		fmt.Printf("Removed %d failed migration records from %s\n", rowsAffected, tableName)
-	} else {
-		fmt.Printf("No failed migration records found in %s\n", tableName)
-	}
-
-	return nil
+	return "SELECT name FROM " + catalog + " WHERE type='table' AND name = ? COLLATE NOCASE", []interface{}{table.Name}
 }
 
-func (s *SQLiteDatabase) ExecuteMigration(content string) error {
-	if s.db == nil {
-		return fmt.Errorf("database not connected")
+func (sqliteDialect) ObjectQueries() []ObjectQuery {
+	return []ObjectQuery{
+		{"table", "SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%'"},
+		{"view", "SELECT name FROM sqlite_master WHERE type='view'"},
+		{"index", "SELECT name FROM sqlite_master WHERE type='index' AND name NOT LIKE 'sqlite_%'"},
 	}
-
-	// Parse the SQL content into individual statements
-	statements := ParseSQLStatements(content)
-
-	// Execute each statement individually
-	for i, statement := range statements {
-		logSQL(statement)
-		_, err := s.db.Exec(statement)
-		if err != nil {
-			return fmt.Errorf("failed to execute statement %d: %w", i+1, err)
-		}
-	}
-
-	return nil
 }
 
-func (s *SQLiteDatabase) GetDatabaseObjects() ([]DatabaseObject, error) {
-	if s.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	var objects []DatabaseObject
-
-	// Get tables
-	tableQuery := "SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%'"
-	logSQL(tableQuery)
-	rows, err := s.db.Query(tableQuery)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query tables: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var tableName string
-		if err := rows.Scan(&tableName); err != nil {
-			return nil, fmt.Errorf("failed to scan table name: %w", err)
-		}
-		objects = append(objects, DatabaseObject{Type: "table", Name: tableName})
-	}
-
-	// Get views
-	viewQuery := "SELECT name FROM sqlite_master WHERE type='view'"
-	logSQL(viewQuery)
-	rows, err = s.db.Query(viewQuery)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query views: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var viewName string
-		if err := rows.Scan(&viewName); err != nil {
-			return nil, fmt.Errorf("failed to scan view name: %w", err)
-		}
-		objects = append(objects, DatabaseObject{Type: "view", Name: viewName})
-	}
+func (sqliteDialect) IsTableNotFound(err error) bool { return isSQLiteTableNotFound(err) }
 
-	// Get indexes
-	indexQuery := "SELECT name, tbl_name FROM sqlite_master WHERE type='index' AND name NOT LIKE 'sqlite_%'"
-	logSQL(indexQuery)
-	rows, err = s.db.Query(indexQuery)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query indexes: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var indexName, tableName string
-		if err := rows.Scan(&indexName, &tableName); err != nil {
-			return nil, fmt.Errorf("failed to scan index name: %w", err)
-		}
-		objects = append(objects, DatabaseObject{Type: "index", Name: indexName})
-	}
-
-	return objects, nil
-}
+func (sqliteDialect) SplitStatements(content string) []string { return ParseSQLStatements(content) }
 
-func (s *SQLiteDatabase) DestroyAllObjects() error {
+func (s *SQLiteDatabase) DestroyAllObjects(ctx context.Context) error {
 	if s.db == nil {
-		return fmt.Errorf("database not connected")
+		return ErrNotConnected
 	}
 
 	// Get all table names
 	query := "SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%'"
 	logSQL(query)
-	rows, err := s.db.Query(query)
+	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to query tables: %w", err)
 	}
@@ -343,7 +110,7 @@ func (s *SQLiteDatabase) DestroyAllObjects() error {
 	for _, table := range tables {
 		dropQuery := fmt.Sprintf("DROP TABLE IF EXISTS %s", table)
 		logSQL(dropQuery)
-		if _, err := s.db.Exec(dropQuery); err != nil {
+		if _, err := s.db.ExecContext(ctx, dropQuery); err != nil {
 			return fmt.Errorf("failed to drop table %s: %w", table, err)
 		}
 	}
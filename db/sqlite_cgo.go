@@ -0,0 +1,27 @@
+//go:build !purego
+
+package db
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriverName is the database/sql driver used for SQLite connections.
+// The default build uses the CGO-based mattn/go-sqlite3 driver.
+const sqliteDriverName = "sqlite3"
+
+// isSQLiteTableNotFound reports whether err is SQLite's "no such table" error
+func isSQLiteTableNotFound(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && strings.HasPrefix(sqliteErr.Error(), "no such table")
+}
+
+// sqliteDriverOptions returns the options of a SQLite file URI in the form the driver reads.
+// The mattn/go-sqlite3 driver understands the documented options directly.
+func sqliteDriverOptions(options url.Values) url.Values {
+	return options
+}
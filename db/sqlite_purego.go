@@ -0,0 +1,39 @@
+//go:build purego
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"modernc.org/sqlite"
+)
+
+// sqliteDriverName is the database/sql driver used for SQLite connections.
+// Builds tagged purego use the CGO-free modernc.org/sqlite driver.
+const sqliteDriverName = "sqlite"
+
+// isSQLiteTableNotFound reports whether err is SQLite's "no such table" error
+func isSQLiteTableNotFound(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && strings.Contains(sqliteErr.Error(), "no such table")
+}
+
+// sqliteDriverOptions returns the options of a SQLite file URI in the form the driver reads.
+// The modernc.org/sqlite driver sets busy timeout, journal mode, foreign keys and
+// synchronous mode through _pragma options.
+func sqliteDriverOptions(options url.Values) url.Values {
+	translated := url.Values{}
+	for key, values := range options {
+		value := values[len(values)-1]
+		switch key {
+		case "_busy_timeout", "_journal_mode", "_foreign_keys", "_synchronous":
+			translated.Add("_pragma", fmt.Sprintf("%s(%s)", strings.TrimPrefix(key, "_"), value))
+		default:
+			translated.Set(key, value)
+		}
+	}
+	return translated
+}
@@ -0,0 +1,12 @@
+//go:build purego
+
+package integration_test
+
+import _ "modernc.org/sqlite"
+
+// sqliteDriverName is the database/sql driver used to inspect test databases
+const sqliteDriverName = "sqlite"
+
+// buildArgs are the extra "go build" arguments used to build the bloomdb binary,
+// so the binary under test uses the same CGO-free SQLite driver as the tests
+var buildArgs = []string{"-tags", "purego"}
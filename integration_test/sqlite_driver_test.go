@@ -0,0 +1,11 @@
+//go:build !purego
+
+package integration_test
+
+import _ "github.com/mattn/go-sqlite3"
+
+// sqliteDriverName is the database/sql driver used to inspect test databases
+const sqliteDriverName = "sqlite3"
+
+// buildArgs are the extra "go build" arguments used to build the bloomdb binary
+var buildArgs []string
@@ -2,14 +2,13 @@ package integration_test
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 // TestSQLiteIntegrationScript mimics the integration-test-sqlite.sh script
@@ -32,7 +31,7 @@ func TestSQLiteIntegrationScript(t *testing.T) {
 
 	// Build bloomdb binary
 	binaryPath := filepath.Join(tempDir, "bloomdb")
-	buildCmd := exec.Command("go", "build", "-o", binaryPath, "..")
+	buildCmd := exec.Command("go", buildCommand(binaryPath)...)
 	if err := buildCmd.Run(); err != nil {
 		t.Fatalf("Failed to build bloomdb: %v", err)
 	}
@@ -70,7 +69,7 @@ func TestSQLiteIntegrationScript(t *testing.T) {
 
 	// Helper function to check database state
 	checkDBState := func(description string, expectedTables []string) {
-		db, err := sql.Open("sqlite3", dbPath)
+		db, err := sql.Open(sqliteDriverName, dbPath)
 		if err != nil {
 			t.Errorf("Failed to open database: %v", err)
 			return
@@ -99,7 +98,7 @@ func TestSQLiteIntegrationScript(t *testing.T) {
 
 	// Helper function to check BLOOMDB_VERSION table
 	checkMigrationTable := func(description string) {
-		db, err := sql.Open("sqlite3", dbPath)
+		db, err := sql.Open(sqliteDriverName, dbPath)
 		if err != nil {
 			t.Errorf("Failed to open database: %v", err)
 			return
@@ -273,9 +272,12 @@ INSERT INTO non_existent_table VALUES (1, 'test');`
 		t.Log("=== Test 8: Attempt migration with faulty migration ===")
 
 		output, err := runBloomDB("migrate")
-		// The command should succeed (return no error) but the output should contain "failed"
-		if err != nil {
-			t.Errorf("Migration command should have succeeded but failed: %v", err)
+		// The command should exit with the migration failure code and report "failed"
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Errorf("Migration command should have failed with an exit error but got: %v", err)
+		} else if exitErr.ExitCode() != 4 {
+			t.Errorf("Expected exit code 4 for a failed migration, got %d", exitErr.ExitCode())
 		}
 		// Check if output contains "failed" to indicate migration failure
 		if !strings.Contains(output, "failed") {
@@ -313,7 +315,8 @@ INSERT INTO non_existent_table VALUES (1, 'test');`
 	t.Run("Test_11_FixFaultyMigration", func(t *testing.T) {
 		t.Log("=== Test 11: Fix the faulty migration ===")
 
-		fixedContent := `CREATE TABLE faulty_table (
+		// The failed run already created faulty_table before hitting the bad statement
+		fixedContent := `CREATE TABLE IF NOT EXISTS faulty_table (
     id INTEGER PRIMARY KEY,
     name TEXT NOT NULL
 );`
@@ -445,8 +448,13 @@ GROUP BY u.id, u.name;`
 	t.Run("Test_20_MigrateRepeatable", func(t *testing.T) {
 		t.Log("=== Test 20: Migrate to test repeatable migration ===")
 
-		if _, err := runBloomDB("migrate"); err != nil {
-			t.Errorf("Migrate repeatable failed: %v", err)
+		// V1 was modified in Test 14, so checksum validation must stop the migration
+		_, err := runBloomDB("migrate")
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Errorf("Migrate should have failed checksum validation but got: %v", err)
+		} else if exitErr.ExitCode() != 5 {
+			t.Errorf("Expected exit code 5 for a checksum mismatch, got %d", exitErr.ExitCode())
 		}
 
 		checkMigrationTable("After repeatable migration")
@@ -481,7 +489,7 @@ GROUP BY u.id, u.name;`
 		checkDBState("After destroy", []string{})
 
 		// Verify no user tables exist
-		db, err := sql.Open("sqlite3", dbPath)
+		db, err := sql.Open(sqliteDriverName, dbPath)
 		if err != nil {
 			t.Errorf("Failed to open database: %v", err)
 			return
@@ -504,3 +512,9 @@ GROUP BY u.id, u.name;`
 
 	t.Log("All integration tests completed successfully! 🎉")
 }
+
+// buildCommand returns the "go" arguments that build the bloomdb binary at binaryPath
+func buildCommand(binaryPath string) []string {
+	args := append([]string{"build"}, buildArgs...)
+	return append(args, "-o", binaryPath, "..")
+}
@@ -0,0 +1,451 @@
+package loader
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BundleManifestName is the manifest at the root of every bundle
+const BundleManifestName = "bloomdb-bundle.json"
+
+// bundleFormat is the version of the bundle layout written by this build
+const bundleFormat = 1
+
+// bundleCallbacksDir holds a post-migration script from outside the migration locations
+const bundleCallbacksDir = "callbacks"
+
+// maxBundleFileSize limits the size of a single file extracted from a bundle
+const maxBundleFileSize = 64 << 20
+
+// BundleManifest describes the content of a bundle. Every location of the migration path
+// is stored in its own directory; the files of all locations are listed with their SHA-256.
+type BundleManifest struct {
+	Format              int          `json:"format"`
+	BloomDBVersion      string       `json:"bloomdb_version"`
+	CreatedAt           string       `json:"created_at"`
+	SourceCommit        string       `json:"source_commit,omitempty"`
+	SourceDirty         bool         `json:"source_dirty,omitempty"`
+	Locations           []string     `json:"locations"`
+	PostMigrationScript string       `json:"post_migration_script,omitempty"`
+	Files               []BundleFile `json:"files"`
+}
+
+// BundleFile is a file stored in a bundle
+type BundleFile struct {
+	Path   string `json:"path"` // Slash-separated path inside the bundle
+	SHA256 string `json:"sha256"`
+}
+
+// BundleOptions describes what goes into a bundle
+type BundleOptions struct {
+	Locations           []string // Migration locations, bundled with all nested files
+	PostMigrationScript string   // Absolute path of the script run after migrating, bundled if set
+	BloomDBVersion      string
+	SourceCommit        string // Git commit the migrations were bundled from, empty if unknown
+	SourceDirty         bool   // The working tree had uncommitted changes
+}
+
+// bundleEntry is a file to be written to a bundle
+type bundleEntry struct {
+	name   string
+	source string
+}
+
+// CreateBundle writes the migration locations to a .tar.gz, .tgz or .zip archive, depending
+// on the output extension. Subdirectories, filter variants, .depends files, signed manifests
+// and any other file below a location are included; hidden files and directories are not.
+func CreateBundle(output string, options BundleOptions) (*BundleManifest, error) {
+	writer, err := newArchiveWriter(output)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &BundleManifest{
+		Format:         bundleFormat,
+		BloomDBVersion: options.BloomDBVersion,
+		CreatedAt:      time.Now().UTC().Format(time.RFC3339),
+		SourceCommit:   options.SourceCommit,
+		SourceDirty:    options.SourceDirty,
+	}
+
+	outputPath, err := filepath.Abs(output)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []bundleEntry
+	used := make(map[string]bool)
+	for _, location := range options.Locations {
+		name := bundleLocationName(location, used)
+		manifest.Locations = append(manifest.Locations, name)
+
+		files, err := collectBundleFiles(location, outputPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			entries = append(entries, bundleEntry{name: path.Join(name, file.name), source: file.source})
+		}
+	}
+
+	if options.PostMigrationScript != "" {
+		name, err := bundledScriptName(options, manifest.Locations)
+		if err != nil {
+			return nil, err
+		}
+		if !containsEntry(entries, name) {
+			entries = append(entries, bundleEntry{name: name, source: options.PostMigrationScript})
+		}
+		manifest.PostMigrationScript = name
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	var files []archiveFile
+	for _, entry := range entries {
+		content, err := os.ReadFile(entry.source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.source, err)
+		}
+		hash := sha256.Sum256(content)
+		manifest.Files = append(manifest.Files, BundleFile{Path: entry.name, SHA256: hex.EncodeToString(hash[:])})
+		files = append(files, archiveFile{name: entry.name, content: content})
+	}
+
+	manifestContent, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, archiveFile{name: BundleManifestName, content: append(manifestContent, '\n')})
+
+	if err := writer(output, files); err != nil {
+		return nil, fmt.Errorf("failed to write bundle %s: %w", output, err)
+	}
+	return manifest, nil
+}
+
+// bundleLocationName returns the directory name of a location inside the bundle.
+// Locations with the same base name are numbered.
+func bundleLocationName(location string, used map[string]bool) string {
+	base := filepath.Base(filepath.Clean(location))
+	if base == "." || base == string(filepath.Separator) || strings.HasPrefix(base, ".") {
+		base = "migrations"
+	}
+	name := base
+	for i := 2; used[name] || name == BundleManifestName || name == bundleCallbacksDir; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	used[name] = true
+	return name
+}
+
+// bundledScriptName returns the bundle path of the post-migration script. Scripts inside a
+// location keep their place; other scripts are stored in the callbacks directory.
+func bundledScriptName(options BundleOptions, locationNames []string) (string, error) {
+	script := options.PostMigrationScript
+	if info, err := os.Stat(script); err != nil || info.IsDir() {
+		return "", fmt.Errorf("post-migration script not found: %s", script)
+	}
+	for i, location := range options.Locations {
+		absolute, err := filepath.Abs(location)
+		if err != nil {
+			continue
+		}
+		relative, err := filepath.Rel(absolute, script)
+		if err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			return path.Join(locationNames[i], filepath.ToSlash(relative)), nil
+		}
+	}
+	return path.Join(bundleCallbacksDir, filepath.Base(script)), nil
+}
+
+// containsEntry reports whether entries contain a file with the given name
+func containsEntry(entries []bundleEntry, name string) bool {
+	for _, entry := range entries {
+		if entry.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// collectBundleFiles returns the regular files below a location, skipping hidden
+// files and directories and the bundle being written
+func collectBundleFiles(location, outputPath string) ([]bundleEntry, error) {
+	var files []bundleEntry
+	err := filepath.WalkDir(location, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if current != location && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		if absolute, err := filepath.Abs(current); err == nil && absolute == outputPath {
+			return nil
+		}
+		relative, err := filepath.Rel(location, current)
+		if err != nil {
+			return err
+		}
+		files = append(files, bundleEntry{name: filepath.ToSlash(relative), source: current})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration location %s: %w", location, err)
+	}
+	return files, nil
+}
+
+// archiveFile is a file held in memory while a bundle is written
+type archiveFile struct {
+	name    string
+	content []byte
+}
+
+// newArchiveWriter returns the writer for the archive format named by the output extension
+func newArchiveWriter(output string) (func(string, []archiveFile) error, error) {
+	switch {
+	case strings.HasSuffix(output, ".tar.gz"), strings.HasSuffix(output, ".tgz"):
+		return writeTarGz, nil
+	case strings.HasSuffix(output, ".zip"):
+		return writeZip, nil
+	default:
+		return nil, fmt.Errorf("unsupported bundle format %s: use .tar.gz, .tgz or .zip", output)
+	}
+}
+
+func writeTarGz(output string, files []archiveFile) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		header := &tar.Header{Name: file.name, Mode: 0644, Size: int64(len(file.content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(output, buf.Bytes(), 0644)
+}
+
+func writeZip(output string, files []archiveFile) error {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(file.content); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(output, buf.Bytes(), 0644)
+}
+
+// ExtractBundle unpacks a bundle into dir and checks every file against the bundle manifest.
+// Files that are missing, modified or not listed, and paths escaping dir, are errors.
+// Returns the manifest; its locations and post-migration script are relative to dir.
+func ExtractBundle(bundle, dir string) (*BundleManifest, error) {
+	files, err := readArchive(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle %s: %w", bundle, err)
+	}
+
+	manifestContent, ok := files[BundleManifestName]
+	if !ok {
+		return nil, fmt.Errorf("bundle %s has no %s", bundle, BundleManifestName)
+	}
+	var manifest BundleManifest
+	if err := json.Unmarshal(manifestContent, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s in bundle %s: %w", BundleManifestName, bundle, err)
+	}
+	if manifest.Format != bundleFormat {
+		return nil, fmt.Errorf("bundle %s has format %d, this build reads format %d", bundle, manifest.Format, bundleFormat)
+	}
+	if len(manifest.Locations) == 0 {
+		return nil, fmt.Errorf("bundle %s lists no migration locations", bundle)
+	}
+	for _, location := range manifest.Locations {
+		if !isBundlePath(location) {
+			return nil, fmt.Errorf("bundle %s has an invalid location %q", bundle, location)
+		}
+	}
+	if manifest.PostMigrationScript != "" {
+		if _, ok := files[manifest.PostMigrationScript]; !ok || !isBundlePath(manifest.PostMigrationScript) {
+			return nil, fmt.Errorf("bundle %s does not contain its post-migration script %s", bundle, manifest.PostMigrationScript)
+		}
+	}
+	delete(files, BundleManifestName)
+
+	var problems []string
+	listed := make(map[string]bool)
+	for _, file := range manifest.Files {
+		listed[file.Path] = true
+		content, ok := files[file.Path]
+		if !ok {
+			problems = append(problems, file.Path+": listed but missing")
+			continue
+		}
+		hash := sha256.Sum256(content)
+		if hex.EncodeToString(hash[:]) != file.SHA256 {
+			problems = append(problems, file.Path+": checksum mismatch")
+		}
+	}
+	for name := range files {
+		if !listed[name] {
+			problems = append(problems, name+": not listed in the bundle manifest")
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("bundle %s is corrupt: %s", bundle, strings.Join(problems, "; "))
+	}
+
+	for _, location := range manifest.Locations {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.FromSlash(location)), 0755); err != nil {
+			return nil, err
+		}
+	}
+	for name, content := range files {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return nil, err
+		}
+	}
+	return &manifest, nil
+}
+
+// readArchive reads the regular files of a .tar.gz, .tgz or .zip bundle by name.
+// Absolute paths and paths containing ".." are rejected.
+func readArchive(bundle string) (map[string][]byte, error) {
+	switch {
+	case strings.HasSuffix(bundle, ".tar.gz"), strings.HasSuffix(bundle, ".tgz"):
+		return readTarGz(bundle)
+	case strings.HasSuffix(bundle, ".zip"):
+		return readZip(bundle)
+	default:
+		return nil, fmt.Errorf("unsupported bundle format: use .tar.gz, .tgz or .zip")
+	}
+}
+
+func readTarGz(bundle string) (map[string][]byte, error) {
+	file, err := os.Open(bundle)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	files := make(map[string][]byte)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("%s is not a regular file", header.Name)
+		}
+		if err := addArchiveFile(files, header.Name, tr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func readZip(bundle string) (map[string][]byte, error) {
+	zr, err := zip.OpenReader(bundle)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	files := make(map[string][]byte)
+	for _, entry := range zr.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		if !entry.Mode().IsRegular() {
+			return nil, fmt.Errorf("%s is not a regular file", entry.Name)
+		}
+		reader, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		err = addArchiveFile(files, entry.Name, reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// isBundlePath reports whether a slash-separated path stays inside the extraction directory
+func isBundlePath(name string) bool {
+	clean := path.Clean(name)
+	return clean != "." && !path.IsAbs(clean) && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// addArchiveFile reads an archive entry after checking that its name stays inside the bundle
+func addArchiveFile(files map[string][]byte, name string, reader io.Reader) error {
+	clean := path.Clean(name)
+	if !isBundlePath(clean) || strings.Contains(name, "\\") {
+		return fmt.Errorf("invalid path %s", name)
+	}
+	if _, exists := files[clean]; exists {
+		return fmt.Errorf("%s is stored more than once", name)
+	}
+	content, err := io.ReadAll(io.LimitReader(reader, maxBundleFileSize+1))
+	if err != nil {
+		return err
+	}
+	if len(content) > maxBundleFileSize {
+		return fmt.Errorf("%s is larger than %d bytes", name, maxBundleFileSize)
+	}
+	files[clean] = content
+	return nil
+}
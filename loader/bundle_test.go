@@ -0,0 +1,131 @@
+package loader
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAndExtractBundle(t *testing.T) {
+	for _, name := range []string{"migrations.tar.gz", "migrations.zip"} {
+		t.Run(name, func(t *testing.T) {
+			source := t.TempDir()
+			location := filepath.Join(source, "db")
+			writeFiles(t, location, map[string]string{
+				"V1__Users.sql":          "CREATE TABLE users (id INT);",
+				"V1__Users.postgres.sql": "CREATE TABLE users (id SERIAL);",
+				"R__Views.sql":           "CREATE VIEW v AS SELECT 1;",
+				"R__Views.depends":       "functions\n",
+				"tenant/V1__Tenant.sql":  "SELECT 1;",
+				".git/config":            "hidden",
+			})
+			writeFiles(t, source, map[string]string{"hooks/post.sql": "ANALYZE;"})
+
+			output := filepath.Join(t.TempDir(), name)
+			manifest, err := CreateBundle(output, BundleOptions{
+				Locations:           []string{location},
+				PostMigrationScript: filepath.Join(source, "hooks", "post.sql"),
+				BloomDBVersion:      "1.2.3",
+				SourceCommit:        "abc123",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"db"}, manifest.Locations)
+			assert.Equal(t, "callbacks/post.sql", manifest.PostMigrationScript)
+			assert.Len(t, manifest.Files, 6)
+
+			dir := t.TempDir()
+			extracted, err := ExtractBundle(output, dir)
+			require.NoError(t, err)
+			assert.Equal(t, "abc123", extracted.SourceCommit)
+			assert.Equal(t, "1.2.3", extracted.BloomDBVersion)
+			assert.Equal(t, manifest.Files, extracted.Files)
+
+			content, err := os.ReadFile(filepath.Join(dir, "db", "tenant", "V1__Tenant.sql"))
+			require.NoError(t, err)
+			assert.Equal(t, "SELECT 1;", string(content))
+			assert.FileExists(t, filepath.Join(dir, "db", "V1__Users.postgres.sql"))
+			assert.FileExists(t, filepath.Join(dir, "callbacks", "post.sql"))
+			assert.NoDirExists(t, filepath.Join(dir, "db", ".git"))
+
+			// The extracted locations load like the originals
+			migrations, err := NewVersionedMigrationLoader(filepath.Join(dir, "db")).LoadMigrations()
+			require.NoError(t, err)
+			require.Len(t, migrations, 1)
+			assert.Equal(t, CalculateChecksum([]byte("CREATE TABLE users (id INT);")), migrations[0].Checksum)
+		})
+	}
+}
+
+func TestCreateBundle_LocationNames(t *testing.T) {
+	source := t.TempDir()
+	first := filepath.Join(source, "a", "migrations")
+	second := filepath.Join(source, "b", "migrations")
+	writeFiles(t, first, map[string]string{"V1__a.sql": "SELECT 1;"})
+	writeFiles(t, second, map[string]string{"V2__b.sql": "SELECT 2;", "post.sql": "SELECT 3;"})
+
+	manifest, err := CreateBundle(filepath.Join(t.TempDir(), "b.tgz"), BundleOptions{
+		Locations:           []string{first, second},
+		PostMigrationScript: filepath.Join(second, "post.sql"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"migrations", "migrations-2"}, manifest.Locations)
+	assert.Equal(t, "migrations-2/post.sql", manifest.PostMigrationScript)
+	assert.Len(t, manifest.Files, 3)
+
+	_, err = CreateBundle(filepath.Join(t.TempDir(), "b.rar"), BundleOptions{Locations: []string{first}})
+	assert.ErrorContains(t, err, "unsupported bundle format")
+}
+
+func TestExtractBundle_Corrupt(t *testing.T) {
+	location := t.TempDir()
+	writeFiles(t, location, map[string]string{"V1__a.sql": "SELECT 1;"})
+	valid := filepath.Join(t.TempDir(), "valid.tar.gz")
+	_, err := CreateBundle(valid, BundleOptions{Locations: []string{location}})
+	require.NoError(t, err)
+	files, err := readArchive(valid)
+	require.NoError(t, err)
+	manifest := string(files[BundleManifestName])
+	name := filepath.Base(location) + "/V1__a.sql"
+
+	tests := []struct {
+		name    string
+		files   []archiveFile
+		message string
+	}{
+		{"Modified file", []archiveFile{{BundleManifestName, []byte(manifest)}, {name, []byte("DROP TABLE users;")}}, name + ": checksum mismatch"},
+		{"Missing file", []archiveFile{{BundleManifestName, []byte(manifest)}}, name + ": listed but missing"},
+		{"Extra file", []archiveFile{{BundleManifestName, []byte(manifest)}, {name, []byte("SELECT 1;")}, {"V2__b.sql", nil}}, "V2__b.sql: not listed in the bundle manifest"},
+		{"No manifest", []archiveFile{{name, []byte("SELECT 1;")}}, "has no bloomdb-bundle.json"},
+		{"Path traversal", []archiveFile{{"../evil.sql", nil}}, "invalid path ../evil.sql"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+			require.NoError(t, writeTarGz(bundle, tt.files))
+			_, err := ExtractBundle(bundle, t.TempDir())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestExtractBundle_RejectsLinks(t *testing.T) {
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	file, err := os.Create(bundle)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "V1__a.sql", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, file.Close())
+
+	_, err = ExtractBundle(bundle, t.TempDir())
+	assert.ErrorContains(t, err, "V1__a.sql is not a regular file")
+}
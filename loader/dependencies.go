@@ -0,0 +1,128 @@
+package loader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// dependsFileSuffix names the sidecar file listing the dependencies of a repeatable
+// migration: R__summary.sql reads R__summary.depends if it exists
+const dependsFileSuffix = ".depends"
+
+// readDependsFile reads the descriptions listed in the sidecar file of a migration,
+// one per line. Blank lines and lines starting with "#" are ignored.
+func readDependsFile(migrationPath string) ([]string, error) {
+	path := strings.TrimSuffix(migrationPath, ".sql") + dependsFileSuffix
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dependency file: %w", err)
+	}
+	defer file.Close()
+
+	var depends []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		depends = append(depends, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dependency file: %w", err)
+	}
+	return depends, nil
+}
+
+// mergeDepends combines dependency lists, dropping duplicates
+func mergeDepends(lists ...[]string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, description := range list {
+			if !seen[description] {
+				seen[description] = true
+				merged = append(merged, description)
+			}
+		}
+	}
+	return merged
+}
+
+// sortByDependencies orders repeatable migrations so that every migration comes after
+// the migrations it depends on. Migrations without dependencies between them keep their order.
+// Unknown dependencies, cycles and regular migrations depending on run-always ones are errors.
+func sortByDependencies(migrations []*RepeatableMigration) ([]*RepeatableMigration, error) {
+	byDescription := make(map[string]*RepeatableMigration, len(migrations))
+	for _, migration := range migrations {
+		byDescription[migration.Description] = migration
+	}
+
+	for _, migration := range migrations {
+		for _, description := range migration.Depends {
+			dependency, exists := byDescription[description]
+			if !exists {
+				return nil, fmt.Errorf("repeatable migration %s depends on unknown repeatable migration %s", migration.Description, description)
+			}
+			// Run-always migrations run last, so nothing else can wait for them
+			if dependency.RunAlways && !migration.RunAlways {
+				return nil, fmt.Errorf("repeatable migration %s cannot depend on run-always migration %s", migration.Description, description)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(migrations))
+	sorted := make([]*RepeatableMigration, 0, len(migrations))
+	var path []string
+
+	var visit func(migration *RepeatableMigration) error
+	visit = func(migration *RepeatableMigration) error {
+		switch state[migration.Description] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := append(path[indexOf(path, migration.Description):], migration.Description)
+			return fmt.Errorf("dependency cycle between repeatable migrations: %s", strings.Join(cycle, " -> "))
+		}
+
+		state[migration.Description] = visiting
+		path = append(path, migration.Description)
+		for _, description := range migration.Depends {
+			if err := visit(byDescription[description]); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[migration.Description] = visited
+		sorted = append(sorted, migration)
+		return nil
+	}
+
+	for _, migration := range migrations {
+		if err := visit(migration); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// indexOf returns the position of value in list, or -1
+func indexOf(list []string, value string) int {
+	for i, item := range list {
+		if item == value {
+			return i
+		}
+	}
+	return -1
+}
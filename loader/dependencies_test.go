@@ -0,0 +1,94 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// descriptions returns the descriptions of migrations in order
+func descriptions(migrations []*RepeatableMigration) []string {
+	var result []string
+	for _, migration := range migrations {
+		result = append(result, migration.Description)
+	}
+	return result
+}
+
+func TestSortByDependencies(t *testing.T) {
+	migrations := []*RepeatableMigration{
+		{Description: "summary", Depends: []string{"base_view", "totals"}},
+		{Description: "audit"},
+		{Description: "totals", Depends: []string{"base_view"}},
+		{Description: "base_view"},
+	}
+
+	sorted, err := sortByDependencies(migrations)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base_view", "totals", "summary", "audit"}, descriptions(sorted))
+}
+
+func TestSortByDependencies_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		migrations []*RepeatableMigration
+		message    string
+	}{
+		{
+			name: "Unknown dependency",
+			migrations: []*RepeatableMigration{
+				{Description: "summary", Depends: []string{"base"}},
+			},
+			message: "repeatable migration summary depends on unknown repeatable migration base",
+		},
+		{
+			name: "Cycle",
+			migrations: []*RepeatableMigration{
+				{Description: "audit"},
+				{Description: "a", Depends: []string{"b"}},
+				{Description: "b", Depends: []string{"c"}},
+				{Description: "c", Depends: []string{"a"}},
+			},
+			message: "dependency cycle between repeatable migrations: a -> b -> c -> a",
+		},
+		{
+			name: "Self dependency",
+			migrations: []*RepeatableMigration{
+				{Description: "a", Depends: []string{"a"}},
+			},
+			message: "dependency cycle between repeatable migrations: a -> a",
+		},
+		{
+			name: "Dependency on a run-always migration",
+			migrations: []*RepeatableMigration{
+				{Description: "views", Depends: []string{"grants"}},
+				{Description: "grants", RunAlways: true},
+			},
+			message: "repeatable migration views cannot depend on run-always migration grants",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := sortByDependencies(tt.migrations)
+			assert.ErrorContains(t, err, tt.message)
+		})
+	}
+}
+
+func TestLoadRepeatableMigrations_DependsFile(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "R__a_summary.sql"), []byte("-- bloomdb: depends=c_totals\nCREATE VIEW s AS SELECT * FROM b;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "R__a_summary.depends"), []byte("# views read by the summary\nb_base\n\nc_totals\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "R__b_base.sql"), []byte("CREATE VIEW b AS SELECT 1;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "R__c_totals.sql"), []byte("CREATE VIEW c AS SELECT 1;"), 0644))
+
+	migrations, err := NewRepeatableMigrationLoader(tempDir).LoadRepeatableMigrations()
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, "a_summary", migrations[2].Description)
+	assert.Equal(t, []string{"c_totals", "b_base"}, migrations[2].Depends)
+}
@@ -0,0 +1,208 @@
+package loader
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// directivePrefix starts a directive comment in the header of a migration file
+const directivePrefix = "-- bloomdb:"
+
+// Directives are per-migration options set by "-- bloomdb:" comments at the top of a file:
+//
+//	-- bloomdb: no-transaction
+//	-- bloomdb: timeout=10m retry=3
+//	-- bloomdb: tags=seed,demo
+//	-- bloomdb: on-fail=skip
+//	-- bloomdb: precondition SELECT COUNT(*) = 0 FROM users
+//
+// The header ends at the first line that is neither blank nor a "--" comment.
+// A precondition takes the rest of its line; consecutive precondition lines form one query.
+type Directives struct {
+	NoTransaction bool          // Run without the surrounding transaction
+	Timeout       time.Duration // Replaces --statement-timeout and --migration-timeout, 0 if unset
+	Retry         int           // Additional attempts after a failed execution
+	Tags          []string      // Tags selecting the migration
+	RunAlways     bool          // Run a repeatable migration even if its checksum is unchanged
+	Depends       []string      // Repeatable migrations (descriptions) that must run first
+	Precondition  string        // Query deciding whether the migration runs, empty if unset
+	OnFail        OnFail        // What to do when the precondition is false
+}
+
+// OnFail is the policy applied when a precondition is false
+type OnFail string
+
+const (
+	OnFailFail OnFail = "fail" // Abort the migration run (default)
+	OnFailSkip OnFail = "skip" // Record the migration as applied without running it
+	OnFailWarn OnFail = "warn" // Print a warning and run the migration anyway
+)
+
+// preconditionDirective takes a query instead of name[=value] items
+const preconditionDirective = "precondition"
+
+// ParseDirectives reads the directives from the header of a migration script.
+// Unknown directives, invalid values and repeated directives are errors.
+func ParseDirectives(content string) (Directives, error) {
+	var directives Directives
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if lineNumber == 1 {
+			line = strings.TrimSpace(stripBOM(line))
+		}
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+		if !strings.HasPrefix(line, directivePrefix) {
+			continue
+		}
+
+		rest := strings.TrimSpace(strings.TrimPrefix(line, directivePrefix))
+		if query, ok := cutPrecondition(rest); ok {
+			if query == "" {
+				return Directives{}, fmt.Errorf("line %d: directive precondition requires a query", lineNumber)
+			}
+			if directives.Precondition != "" {
+				directives.Precondition += "\n"
+			}
+			directives.Precondition += query
+			continue
+		}
+
+		for _, item := range strings.Fields(strings.TrimPrefix(line, directivePrefix)) {
+			name, value, _ := strings.Cut(item, "=")
+			if seen[name] {
+				return Directives{}, fmt.Errorf("line %d: directive %q is set more than once", lineNumber, name)
+			}
+			seen[name] = true
+			if err := directives.set(name, value); err != nil {
+				return Directives{}, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Directives{}, err
+	}
+
+	if directives.Precondition == "" {
+		if directives.OnFail != "" {
+			return Directives{}, fmt.Errorf("directive on-fail requires a precondition")
+		}
+		return directives, nil
+	}
+	directives.Precondition = strings.TrimSpace(strings.TrimSuffix(directives.Precondition, ";"))
+	if directives.OnFail == "" {
+		directives.OnFail = OnFailFail
+	}
+	return directives, nil
+}
+
+// cutPrecondition returns the query of a precondition directive line
+func cutPrecondition(directive string) (string, bool) {
+	query, ok := strings.CutPrefix(directive, preconditionDirective)
+	if !ok || (query != "" && query[0] != ' ' && query[0] != '\t') {
+		return "", false
+	}
+	return strings.TrimSpace(query), true
+}
+
+// set applies a single name[=value] directive
+func (d *Directives) set(name, value string) error {
+	switch name {
+	case "no-transaction":
+		d.NoTransaction = true
+		return requireNoValue(name, value)
+	case "run-always":
+		d.RunAlways = true
+		return requireNoValue(name, value)
+	case "timeout":
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("directive timeout must be a positive duration such as 10m, got %q", value)
+		}
+		d.Timeout = timeout
+	case "retry":
+		retry, err := strconv.Atoi(value)
+		if err != nil || retry < 0 {
+			return fmt.Errorf("directive retry must be a non-negative number, got %q", value)
+		}
+		d.Retry = retry
+	case "tags":
+		items, err := splitList(name, value)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			tag, err := ParseTag(item)
+			if err != nil {
+				return fmt.Errorf("directive tags: %w", err)
+			}
+			d.Tags = appendTag(d.Tags, tag)
+		}
+	case "depends":
+		depends, err := splitList(name, value)
+		if err != nil {
+			return err
+		}
+		d.Depends = depends
+	case preconditionDirective:
+		return fmt.Errorf("directive precondition takes a query after a space, such as: -- bloomdb: precondition SELECT 1")
+	case "on-fail":
+		switch policy := OnFail(value); policy {
+		case OnFailFail, OnFailSkip, OnFailWarn:
+			d.OnFail = policy
+		default:
+			return fmt.Errorf("directive on-fail must be skip, fail or warn, got %q", value)
+		}
+	default:
+		return fmt.Errorf("unknown directive %q (known: no-transaction, timeout, retry, tags, run-always, depends, precondition, on-fail)", name)
+	}
+	return nil
+}
+
+// validateForVersioned rejects directives that only apply to repeatable migrations
+func (d Directives) validateForVersioned() error {
+	if d.RunAlways {
+		return fmt.Errorf("directive run-always is only valid for repeatable migrations")
+	}
+	if len(d.Depends) > 0 {
+		return fmt.Errorf("directive depends is only valid for repeatable migrations")
+	}
+	return nil
+}
+
+// requireNoValue rejects a value on a flag directive
+func requireNoValue(name, value string) error {
+	if value != "" {
+		return fmt.Errorf("directive %s does not take a value", name)
+	}
+	return nil
+}
+
+// splitList splits a comma-separated directive value, rejecting empty entries
+func splitList(name, value string) ([]string, error) {
+	if value == "" {
+		return nil, fmt.Errorf("directive %s requires a comma-separated list", name)
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return nil, fmt.Errorf("directive %s contains an empty entry", name)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
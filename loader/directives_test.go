@@ -0,0 +1,156 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDirectives(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected Directives
+	}{
+		{
+			name:     "No header",
+			content:  "CREATE TABLE users (id INT);",
+			expected: Directives{},
+		},
+		{
+			name: "All directives",
+			content: "-- bloomdb: no-transaction\n" +
+				"-- bloomdb: timeout=10m retry=3\n" +
+				"-- bloomdb: tags=seed,demo run-always depends=views,functions\n" +
+				"CREATE INDEX CONCURRENTLY idx ON users (name);",
+			expected: Directives{
+				NoTransaction: true,
+				Timeout:       10 * time.Minute,
+				Retry:         3,
+				Tags:          []string{"seed", "demo"},
+				RunAlways:     true,
+				Depends:       []string{"views", "functions"},
+			},
+		},
+		{
+			name:     "Comments and blank lines in the header",
+			content:  "\ufeff-- Creates the index\n\n-- bloomdb: no-transaction\n-- more notes\nSELECT 1;",
+			expected: Directives{NoTransaction: true},
+		},
+		{
+			name: "Precondition spanning several lines",
+			content: "-- bloomdb: on-fail=skip\n" +
+				"-- bloomdb: precondition SELECT COUNT(*) = 0\n" +
+				"-- bloomdb: precondition   FROM users WHERE name = 'a b';\n" +
+				"INSERT INTO users (name) VALUES ('a b');",
+			expected: Directives{
+				Precondition: "SELECT COUNT(*) = 0\nFROM users WHERE name = 'a b'",
+				OnFail:       OnFailSkip,
+			},
+		},
+		{
+			name:     "Precondition defaults to on-fail=fail",
+			content:  "-- bloomdb: precondition SELECT 1\nSELECT 1;",
+			expected: Directives{Precondition: "SELECT 1", OnFail: OnFailFail},
+		},
+		{
+			name:     "Directives after the first statement are ignored",
+			content:  "SELECT 1;\n-- bloomdb: no-transaction\n-- bloomdb: bogus",
+			expected: Directives{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directives, err := ParseDirectives(tt.content)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, directives)
+		})
+	}
+}
+
+func TestParseDirectives_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{"Unknown directive", "-- bloomdb: no-transactions\nSELECT 1;", `line 1: unknown directive "no-transactions"`},
+		{"Invalid timeout", "-- bloomdb: timeout=soon", "directive timeout must be a positive duration"},
+		{"Zero timeout", "-- bloomdb: timeout=0s", "directive timeout must be a positive duration"},
+		{"Negative retry", "-- bloomdb: retry=-1", "directive retry must be a non-negative number"},
+		{"Flag with value", "-- bloomdb: run-always=yes", "directive run-always does not take a value"},
+		{"Empty list", "-- bloomdb: tags=", "directive tags requires a comma-separated list"},
+		{"Empty list entry", "-- bloomdb: depends=a,,b", "directive depends contains an empty entry"},
+		{"Empty precondition", "-- bloomdb: precondition", "line 1: directive precondition requires a query"},
+		{"Precondition with equals", "-- bloomdb: precondition=1", "directive precondition takes a query after a space"},
+		{"Unknown on-fail policy", "-- bloomdb: on-fail=ignore\n-- bloomdb: precondition SELECT 1", "directive on-fail must be skip, fail or warn"},
+		{"On-fail without precondition", "-- bloomdb: on-fail=skip", "directive on-fail requires a precondition"},
+		{"Repeated directive", "-- bloomdb: retry=1\n-- bloomdb: retry=2", `line 2: directive "retry" is set more than once`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDirectives(tt.content)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestLoadMigrations_Directives(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "V1__index.sql"), []byte("-- bloomdb: no-transaction timeout=1h\nCREATE INDEX i ON t (c);"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "R__views.sql"), []byte("-- bloomdb: run-always depends=functions\nCREATE VIEW v AS SELECT 1;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "A__functions.sql"), []byte("SELECT 1;"), 0644))
+
+	versioned, err := NewVersionedMigrationLoader(tempDir).LoadMigrations()
+	require.NoError(t, err)
+	require.Len(t, versioned, 1)
+	assert.True(t, versioned[0].Directives.NoTransaction)
+	assert.Equal(t, time.Hour, versioned[0].Directives.Timeout)
+
+	repeatable, err := NewRepeatableMigrationLoader(tempDir).LoadRepeatableMigrations()
+	require.NoError(t, err)
+	require.Len(t, repeatable, 2)
+	assert.Equal(t, "A__functions", repeatable[0].String())
+	assert.True(t, repeatable[1].Directives.RunAlways)
+	assert.True(t, repeatable[1].RunAlways)
+	assert.Equal(t, "R__views", repeatable[1].String())
+	assert.Equal(t, []string{"functions"}, repeatable[1].Depends)
+}
+
+func TestLoadMigrations_InvalidDirectives(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		message  string
+	}{
+		{"Unknown directive", "V1__a.sql", "-- bloomdb: fast\nSELECT 1;", `unknown directive "fast"`},
+		{"run-always on versioned", "V1__a.sql", "-- bloomdb: run-always\nSELECT 1;", "run-always is only valid for repeatable migrations"},
+		{"depends on versioned", "V1__a.sql", "-- bloomdb: depends=b\nSELECT 1;", "depends is only valid for repeatable migrations"},
+		{"Unknown directive in repeatable", "R__a.sql", "-- bloomdb: fast\nSELECT 1;", `unknown directive "fast"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(tempDir, tt.filename), []byte(tt.content), 0644))
+
+			var err error
+			if tt.filename[0] == 'R' {
+				_, err = NewRepeatableMigrationLoader(tempDir).LoadRepeatableMigrations()
+			} else {
+				_, err = NewVersionedMigrationLoader(tempDir).LoadMigrations()
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.filename)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
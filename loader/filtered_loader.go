@@ -31,8 +31,10 @@ type MigrationFile struct {
 	Filename     string
 	Version      string // Empty for repeatable migrations
 	Description  string
-	Filter       string // Empty if no filter
+	Filter       string   // Empty if no filter
+	Tags         []string // Tags from "+tag" segments after the description
 	IsRepeatable bool
+	RunAlways    bool // Repeatable migration named A__ instead of R__
 }
 
 // GetFilterConfig reads filter configuration from environment variables
@@ -62,11 +64,12 @@ func GetFilterConfig() FilterConfig {
 
 // ParseMigrationFilename parses a migration filename and extracts its components
 func ParseMigrationFilename(filename string) (*MigrationFile, error) {
-	// Pattern for versioned migrations: V<version>__<description>[.<filter>].sql
+	// Pattern for versioned migrations: V<version>__<description>[+<tag>...][.<filter>].sql
 	versionedPattern := regexp.MustCompile(`^V(.+?)__(.+?)(?:\.([^.]+))?\.sql$`)
 
-	// Pattern for repeatable migrations: R__<description>[.<filter>].sql
-	repeatablePattern := regexp.MustCompile(`^R__(.+?)(?:\.([^.]+))?\.sql$`)
+	// Pattern for repeatable migrations: R__<description>[+<tag>...][.<filter>].sql,
+	// or A__ for repeatable migrations that run on every migrate
+	repeatablePattern := regexp.MustCompile(`^([RA])__(.+?)(?:\.([^.]+))?\.sql$`)
 
 	// Try versioned pattern first
 	if matches := versionedPattern.FindStringSubmatch(filename); len(matches) >= 3 {
@@ -82,21 +85,32 @@ func ParseMigrationFilename(filename string) (*MigrationFile, error) {
 			return nil, fmt.Errorf("invalid version format in file %s: %s (expected format: 1, 1.2, 1.2.3, etc.)", filename, version)
 		}
 
+		description, tags, err := splitFilenameTags(filename, description)
+		if err != nil {
+			return nil, err
+		}
+
 		return &MigrationFile{
 			Filename:     filename,
 			Version:      version,
 			Description:  description,
 			Filter:       filter,
+			Tags:         tags,
 			IsRepeatable: false,
 		}, nil
 	}
 
 	// Try repeatable pattern
-	if matches := repeatablePattern.FindStringSubmatch(filename); len(matches) >= 2 {
-		description := matches[1]
+	if matches := repeatablePattern.FindStringSubmatch(filename); len(matches) >= 3 {
+		description := matches[2]
 		filter := ""
-		if len(matches) == 3 && matches[2] != "" {
-			filter = matches[2]
+		if len(matches) == 4 && matches[3] != "" {
+			filter = matches[3]
+		}
+
+		description, tags, err := splitFilenameTags(filename, description)
+		if err != nil {
+			return nil, err
 		}
 
 		return &MigrationFile{
@@ -104,7 +118,9 @@ func ParseMigrationFilename(filename string) (*MigrationFile, error) {
 			Version:      "",
 			Description:  description,
 			Filter:       filter,
+			Tags:         tags,
 			IsRepeatable: true,
+			RunAlways:    matches[1] == "A",
 		}, nil
 	}
 
@@ -113,38 +129,25 @@ func ParseMigrationFilename(filename string) (*MigrationFile, error) {
 
 // CollectFilteredMigrationFiles collects migration files based on the filter configuration
 func CollectFilteredMigrationFiles(directory string, filterConfig FilterConfig) ([]*MigrationFile, error) {
-	files, err := os.ReadDir(directory)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read migration directory: %w", err)
-	}
-
-	// Pattern to check if file looks like a migration (V* or R__*.sql)
-	migrationLikePattern := regexp.MustCompile(`^(V.+?__.+|R__.+)\.sql$`)
+	return CollectFilteredMigrationFilesFromLocations([]string{directory}, false, filterConfig)
+}
 
-	// Parse all valid migration files
+// CollectFilteredMigrationFilesFromLocations collects migration files from several locations
+// as one migration set. When recursive is true, nested subdirectories of every location are
+// searched as well. The same version (or repeatable description) appearing in more than one
+// file for the same filter is reported as an error.
+func CollectFilteredMigrationFilesFromLocations(locations []string, recursive bool, filterConfig FilterConfig) ([]*MigrationFile, error) {
 	var allFiles []*MigrationFile
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
-
-		filename := file.Name()
-
-		// Check if this looks like a migration file
-		if !migrationLikePattern.MatchString(filename) {
-			// Not a migration file, skip silently
-			continue
-		}
-
-		// This looks like a migration file, so parse it and report errors
-		migFile, err := ParseMigrationFilename(filename)
+	for _, location := range locations {
+		files, err := collectMigrationFiles(location, recursive)
 		if err != nil {
-			// Return error for files that look like migrations but are invalid
 			return nil, err
 		}
+		allFiles = append(allFiles, files...)
+	}
 
-		migFile.FullPath = filepath.Join(directory, filename)
-		allFiles = append(allFiles, migFile)
+	if err := checkDuplicateMigrationFiles(allFiles); err != nil {
+		return nil, err
 	}
 
 	// Apply filter based on mode
@@ -245,3 +248,74 @@ func filterFilesSoft(files []*MigrationFile, filter string) []*MigrationFile {
 
 	return result
 }
+
+// collectMigrationFiles parses all migration files in a directory, descending into
+// subdirectories when recursive is true
+func collectMigrationFiles(directory string, recursive bool) ([]*MigrationFile, error) {
+	files, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration directory: %w", err)
+	}
+
+	// Pattern to check if file looks like a migration (V*, R__* or A__*.sql)
+	migrationLikePattern := regexp.MustCompile(`^(V.+?__.+|[RA]__.+)\.sql$`)
+
+	// Parse all valid migration files
+	var allFiles []*MigrationFile
+	for _, file := range files {
+		filename := file.Name()
+
+		if file.IsDir() {
+			if !recursive {
+				continue
+			}
+			nested, err := collectMigrationFiles(filepath.Join(directory, filename), recursive)
+			if err != nil {
+				return nil, err
+			}
+			allFiles = append(allFiles, nested...)
+			continue
+		}
+
+		// Check if this looks like a migration file
+		if !migrationLikePattern.MatchString(filename) {
+			// Not a migration file, skip silently
+			continue
+		}
+
+		// This looks like a migration file, so parse it and report errors
+		migFile, err := ParseMigrationFilename(filename)
+		if err != nil {
+			// Return error for files that look like migrations but are invalid
+			return nil, err
+		}
+
+		migFile.FullPath = filepath.Join(directory, filename)
+		allFiles = append(allFiles, migFile)
+	}
+
+	return allFiles, nil
+}
+
+// checkDuplicateMigrationFiles returns an error if two files provide the same
+// version (or the same repeatable description) for the same filter
+func checkDuplicateMigrationFiles(files []*MigrationFile) error {
+	seen := make(map[string]*MigrationFile)
+	for _, file := range files {
+		var key string
+		if file.IsRepeatable {
+			key = "R:" + file.Description + ":" + file.Filter
+		} else {
+			key = "V:" + file.Version + ":" + file.Filter
+		}
+
+		if existing, exists := seen[key]; exists {
+			if file.IsRepeatable {
+				return fmt.Errorf("duplicate repeatable migration %s found in %s and %s", file.Description, existing.FullPath, file.FullPath)
+			}
+			return fmt.Errorf("duplicate migration version %s found in %s and %s", file.Version, existing.FullPath, file.FullPath)
+		}
+		seen[key] = file
+	}
+	return nil
+}
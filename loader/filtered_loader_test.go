@@ -97,6 +97,25 @@ func TestParseMigrationFilename_RepeatableWithFilter(t *testing.T) {
 	assert.True(t, file.IsRepeatable)
 }
 
+func TestParseMigrationFilename_RunAlways(t *testing.T) {
+	file, err := ParseMigrationFilename("A__refresh_grants.postgres.sql")
+
+	require.NoError(t, err)
+	assert.Equal(t, "refresh_grants", file.Description)
+	assert.Equal(t, "postgres", file.Filter)
+	assert.True(t, file.IsRepeatable)
+	assert.True(t, file.RunAlways)
+}
+
+func TestCollectFilteredMigrationFiles_RunAlwaysDuplicatesRepeatable(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "R__grants.sql"), []byte("SELECT 1;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "A__grants.sql"), []byte("SELECT 1;"), 0644))
+
+	_, err := CollectFilteredMigrationFiles(tempDir, FilterConfig{Mode: NoFilter})
+	assert.ErrorContains(t, err, "duplicate repeatable migration grants")
+}
+
 func TestParseMigrationFilename_InvalidFormat(t *testing.T) {
 	invalidFiles := []string{
 		"invalid.sql",
@@ -126,6 +145,7 @@ func TestParseMigrationFilename_ComplexVersions(t *testing.T) {
 		{"V1.2.3__three_part.sql", "1.2.3", "three_part", ""},
 		{"V2.10.15__high_numbers.postgres.sql", "2.10.15", "high_numbers", "postgres"},
 		{"V0.1__initial.sqlite.sql", "0.1", "initial", "sqlite"},
+		{"V3__daily_rollup.duckdb.sql", "3", "daily_rollup", "duckdb"},
 	}
 
 	for _, tt := range tests {
@@ -478,3 +498,86 @@ func TestCollectFilteredMigrationFiles_RepeatableGroupsByDescription(t *testing.
 	assert.False(t, filenames["R__create_views.sql"], "Non-filtered version should not be collected when filtered version exists")
 	assert.True(t, filenames["R__other_views.sql"])
 }
+
+func TestCollectFilteredMigrationFilesFromLocations_MultipleLocations(t *testing.T) {
+	locationA := t.TempDir()
+	locationB := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(locationA, "V1__create_users.sql"), []byte("CREATE TABLE users (id INT);"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(locationB, "V2__create_orders.sql"), []byte("CREATE TABLE orders (id INT);"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(locationB, "R__views.sql"), []byte("CREATE VIEW v AS SELECT 1;"), 0644))
+
+	config := FilterConfig{Mode: NoFilter}
+	collected, err := CollectFilteredMigrationFilesFromLocations([]string{locationA, locationB}, false, config)
+
+	require.NoError(t, err)
+	assert.Len(t, collected, 3)
+}
+
+func TestCollectFilteredMigrationFilesFromLocations_Recursive(t *testing.T) {
+	tempDir := t.TempDir()
+
+	nested := filepath.Join(tempDir, "2025", "q1")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "V1__root.sql"), []byte("SELECT 1;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(nested, "V2__nested.sql"), []byte("SELECT 2;"), 0644))
+
+	config := FilterConfig{Mode: NoFilter}
+
+	// Without recursion only the root file is collected
+	collected, err := CollectFilteredMigrationFilesFromLocations([]string{tempDir}, false, config)
+	require.NoError(t, err)
+	assert.Len(t, collected, 1)
+
+	// With recursion the nested file is collected as well
+	collected, err = CollectFilteredMigrationFilesFromLocations([]string{tempDir}, true, config)
+	require.NoError(t, err)
+	require.Len(t, collected, 2)
+
+	paths := []string{collected[0].FullPath, collected[1].FullPath}
+	assert.Contains(t, paths, filepath.Join(nested, "V2__nested.sql"))
+}
+
+func TestCollectFilteredMigrationFilesFromLocations_DuplicateVersion(t *testing.T) {
+	locationA := t.TempDir()
+	locationB := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(locationA, "V1__create_users.sql"), []byte("SELECT 1;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(locationB, "V1__create_orders.sql"), []byte("SELECT 2;"), 0644))
+
+	config := FilterConfig{Mode: NoFilter}
+	_, err := CollectFilteredMigrationFilesFromLocations([]string{locationA, locationB}, false, config)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate migration version 1")
+}
+
+func TestCollectFilteredMigrationFilesFromLocations_DuplicateRepeatable(t *testing.T) {
+	tempDir := t.TempDir()
+
+	nested := filepath.Join(tempDir, "views")
+	require.NoError(t, os.Mkdir(nested, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "R__summary.sql"), []byte("SELECT 1;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(nested, "R__summary.sql"), []byte("SELECT 2;"), 0644))
+
+	config := FilterConfig{Mode: NoFilter}
+	_, err := CollectFilteredMigrationFilesFromLocations([]string{tempDir}, true, config)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate repeatable migration summary")
+}
+
+func TestCollectFilteredMigrationFilesFromLocations_FilterVariantsAreNotDuplicates(t *testing.T) {
+	locationA := t.TempDir()
+	locationB := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(locationA, "V1__create_users.sql"), []byte("SELECT 1;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(locationB, "V1__create_users.postgres.sql"), []byte("SELECT 2;"), 0644))
+
+	config := FilterConfig{Mode: SoftFilter, Filter: "postgres"}
+	collected, err := CollectFilteredMigrationFilesFromLocations([]string{locationA, locationB}, false, config)
+
+	require.NoError(t, err)
+	require.Len(t, collected, 1)
+	assert.Equal(t, "postgres", collected[0].Filter)
+}
@@ -1,6 +1,8 @@
 package loader
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"hash/crc32"
 	"strings"
 )
@@ -33,6 +35,29 @@ func CalculateChecksum(content []byte) int64 {
 	return int64(int32(crc.Sum32()))
 }
 
+// CalculateSHA256 returns the hex-encoded SHA-256 hash of the given content.
+// Line endings and the BOM are handled as for CalculateChecksum, except that lines are joined with "\n"
+// so that moving a line break changes the hash.
+func CalculateSHA256(content []byte) string {
+	hash := sha256.New()
+	for i, line := range splitLines(content) {
+		if i == 0 {
+			line = stripBOM(line)
+		} else {
+			hash.Write([]byte{'\n'})
+		}
+		hash.Write([]byte(line))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// CalculateNormalizedChecksum returns the hex-encoded SHA-256 hash of the normalized SQL
+// (see NormalizeSQL), which survives reformatting, comment edits and keyword case changes.
+func CalculateNormalizedChecksum(content []byte) string {
+	hash := sha256.Sum256([]byte(NormalizeSQL(string(content))))
+	return hex.EncodeToString(hash[:])
+}
+
 // splitLines splits content into lines, handling \n, \r\n, and \r line endings
 // This ensures cross-platform compatibility with Flyway's behavior
 func splitLines(content []byte) []string {
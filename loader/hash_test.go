@@ -143,3 +143,24 @@ func TestCalculateChecksum_Performance(t *testing.T) {
 	checksum := CalculateChecksum(content)
 	assert.NotZero(t, checksum, "Expected non-zero checksum for large content")
 }
+
+func TestCalculateSHA256(t *testing.T) {
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", CalculateSHA256(nil))
+	assert.Equal(t, "7e18f737311b2dc3b2f269dd78396b0351f14fb66efa879f768cb23181883c78", CalculateSHA256([]byte("a\nb")))
+
+	// Line endings and the BOM are normalized as for the CRC32 checksum
+	assert.Equal(t, CalculateSHA256([]byte("a\nb")), CalculateSHA256([]byte("a\r\nb\r\n")))
+	assert.Equal(t, CalculateSHA256([]byte("a\nb")), CalculateSHA256([]byte("\ufeffa\rb")))
+
+	// Unlike the CRC32 checksum, moving a line break changes the hash
+	assert.Equal(t, CalculateChecksum([]byte("ab\nc")), CalculateChecksum([]byte("a\nbc")))
+	assert.NotEqual(t, CalculateSHA256([]byte("ab\nc")), CalculateSHA256([]byte("a\nbc")))
+}
+
+func TestCalculateNormalizedChecksum(t *testing.T) {
+	checksum := CalculateNormalizedChecksum([]byte("CREATE TABLE t (id INT);"))
+	assert.Len(t, checksum, 64)
+	assert.Equal(t, checksum, CalculateNormalizedChecksum([]byte("-- table t\ncreate table t (\n  id int\n);\n")))
+	assert.NotEqual(t, checksum, CalculateNormalizedChecksum([]byte("CREATE TABLE t (id BIGINT);")))
+	assert.NotEqual(t, CalculateSHA256([]byte("CREATE TABLE t (id INT);")), checksum)
+}
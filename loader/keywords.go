@@ -0,0 +1,43 @@
+package loader
+
+import "strings"
+
+// sqlKeywords are the words NormalizeSQL treats as case-insensitive: SQL keywords,
+// built-in types and common functions of the supported databases. Other words are
+// identifiers, whose case is significant on some databases, such as MySQL table names
+// on Linux, and are kept as written.
+var sqlKeywords = makeKeywordSet(`
+	ABSOLUTE ACTION ADD AFTER ALGORITHM ALL ALTER ALWAYS ANALYZE AND ANY ARRAY AS ASC
+	AUTOINCREMENT AUTO_INCREMENT BEFORE BEGIN BETWEEN BIGINT BIGSERIAL BINARY BLOB BOOL BOOLEAN
+	BOTH BY BYTEA CALL CASCADE CASE CAST CHAR CHARACTER CHARSET CHECK CLOB COALESCE COLLATE
+	COLUMN COLUMNS COMMENT COMMIT CONCURRENTLY CONFLICT CONSTRAINT CONSTRAINTS COUNT CREATE CROSS
+	CURRENT CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP CURRENT_USER CURSOR CYCLE DATABASE DATE
+	DATETIME DAY DEC DECIMAL DECLARE DEFAULT DEFERRABLE DEFERRED DEFINER DELETE DELIMITER DESC
+	DETERMINISTIC DISABLE DISTINCT DO DOUBLE DROP EACH ELSE ELSIF ELSEIF ENABLE END ENGINE ENUM
+	ESCAPE EXCEPT EXCEPTION EXCLUDE EXECUTE EXISTS EXPLAIN EXTENSION FALSE FETCH FILTER FIRST
+	FLOAT FOLLOWING FOR FOREIGN FROM FULL FUNCTION GENERATED GLOBAL GRANT GROUP HAVING HOUR
+	IDENTITY IF IGNORE ILIKE IMMEDIATE IMMUTABLE IN INCLUDE INCREMENT INDEX INHERITS INITIALLY
+	INNER INOUT INSERT INSTEAD INT INT2 INT4 INT8 INTEGER INTERSECT INTERVAL INTO INVOKER IS
+	ISNULL JOIN JSON JSONB KEY LANGUAGE LAST LATERAL LEADING LEFT LIKE LIMIT LOCAL LOCK LONGTEXT
+	LOOP MATERIALIZED MAXVALUE MEDIUMINT MEDIUMTEXT MERGE MINUTE MINVALUE MODIFY MONTH NATURAL
+	NCHAR NCLOB NEXT NO NOCYCLE NOT NOTHING NOTNULL NOW NULL NULLIF NULLS NUMBER NUMERIC
+	NVARCHAR NVARCHAR2 OF OFF OFFSET ON ONLY OPTION OR ORDER OTHERS OUT OUTER OVER OWNER
+	PACKAGE PARTITION PERFORM PLPGSQL PRAGMA PRECEDING PRECISION PRIMARY PRIOR PRIVILEGES
+	PROCEDURE PUBLIC RAISE RANGE RAW READ REAL RECURSIVE REFERENCES REFRESH RENAME REPLACE
+	RESTRICT RETURN RETURNING RETURNS REVOKE RIGHT ROLE ROLLBACK ROW ROWID ROWS SAVEPOINT
+	SCHEMA SECOND SECURITY SELECT SEQUENCE SERIAL SESSION SET SETOF SHOW SIGNED SMALLINT
+	SMALLSERIAL SOME SQL STABLE START STORED STRICT SYSDATE SYSTIMESTAMP TABLE TEMP TEMPORARY
+	TEXT THEN TIME TIMESTAMP TIMESTAMPTZ TINYINT TINYTEXT TO TRAILING TRANSACTION TRIGGER TRUE
+	TRUNCATE TYPE UNBOUNDED UNION UNIQUE UNKNOWN UNLOGGED UNSIGNED UPDATE USAGE USE USER USING
+	UUID VACUUM VALUES VARBINARY VARCHAR VARCHAR2 VARIADIC VARYING VIEW VIRTUAL VOLATILE WHEN
+	WHERE WHILE WINDOW WITH WITHOUT WORK YEAR ZONE
+`)
+
+// makeKeywordSet builds a lookup set from whitespace-separated upper-case keywords
+func makeKeywordSet(keywords string) map[string]bool {
+	set := make(map[string]bool)
+	for _, keyword := range strings.Fields(keywords) {
+		set[keyword] = true
+	}
+	return set
+}
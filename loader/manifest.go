@@ -0,0 +1,341 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestFileName is the signed manifest written to every migration location by "bloomdb sign"
+const ManifestFileName = "bloomdb.manifest"
+
+// manifestHeader is the first line of a manifest and names its format version
+const manifestHeader = "bloomdb-manifest v1"
+
+// Manifest lists the SHA-256 hash of every file the loaders read from a migration location:
+// migration files of all filter variants and their .depends sidecar files, and of the
+// post-migration script run after them.
+//
+//	bloomdb-manifest v1
+//	sha256 <hex> V1__Create_users.sql
+//	sha256 <hex> tenants/R__Views.depends
+//	post-migration-script sha256 <hex>
+//	signature ed25519 <base64>
+//
+// The post-migration script may live outside the location and is moved by bundles,
+// so it is identified by its content only. The signature covers every line before
+// the signature line.
+type Manifest struct {
+	Files               map[string]string // SHA-256 by slash-separated path relative to the location
+	PostMigrationScript string            // SHA-256 of the post-migration script, empty if there is none
+	Signature           []byte
+}
+
+// BuildManifest hashes the migration files of a location and the post-migration script, if any.
+// When recursive is true, files in nested subdirectories are included.
+func BuildManifest(location string, recursive bool, postMigrationScript string) (*Manifest, error) {
+	files, err := signedFiles(location, recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{Files: make(map[string]string)}
+	for name, path := range files {
+		hash, err := hashFile(path)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files[name] = hash
+	}
+	if postMigrationScript != "" {
+		if manifest.PostMigrationScript, err = hashFile(postMigrationScript); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// signedFiles returns the paths of the files covered by a manifest, by relative name
+func signedFiles(location string, recursive bool) (map[string]string, error) {
+	migrationFiles, err := collectMigrationFiles(location, recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	for _, file := range migrationFiles {
+		paths := []string{file.FullPath}
+		sidecar := strings.TrimSuffix(file.FullPath, ".sql") + dependsFileSuffix
+		if _, err := os.Stat(sidecar); err == nil {
+			paths = append(paths, sidecar)
+		}
+		for _, path := range paths {
+			name, err := filepath.Rel(location, path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+			}
+			files[filepath.ToSlash(name)] = path
+		}
+	}
+	return files, nil
+}
+
+// hashFile returns the hex-encoded SHA-256 of a file's raw content
+func hashFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// body returns the signed part of the manifest, with files in sorted order
+func (m *Manifest) body() []byte {
+	names := make([]string, 0, len(m.Files))
+	for name := range m.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteString(manifestHeader + "\n")
+	for _, name := range names {
+		fmt.Fprintf(&buf, "sha256 %s %s\n", m.Files[name], name)
+	}
+	if m.PostMigrationScript != "" {
+		fmt.Fprintf(&buf, "post-migration-script sha256 %s\n", m.PostMigrationScript)
+	}
+	return buf.Bytes()
+}
+
+// Sign signs the manifest with an Ed25519 private key
+func (m *Manifest) Sign(key ed25519.PrivateKey) {
+	m.Signature = ed25519.Sign(key, m.body())
+}
+
+// Marshal returns the manifest file content
+func (m *Manifest) Marshal() []byte {
+	content := m.body()
+	if m.Signature != nil {
+		content = append(content, fmt.Sprintf("signature ed25519 %s\n", base64.StdEncoding.EncodeToString(m.Signature))...)
+	}
+	return content
+}
+
+// ParseManifest reads manifest file content
+func ParseManifest(content []byte) (*Manifest, error) {
+	manifest := &Manifest{Files: make(map[string]string)}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if lineNumber == 1 {
+			if line != manifestHeader {
+				return nil, fmt.Errorf("not a bloomdb manifest: expected %q on line 1", manifestHeader)
+			}
+			continue
+		}
+		if manifest.Signature != nil {
+			return nil, fmt.Errorf("line %d: unexpected content after the signature", lineNumber)
+		}
+
+		fields := strings.SplitN(line, " ", 3)
+		switch {
+		case len(fields) == 3 && fields[0] == "sha256":
+			if _, err := hex.DecodeString(fields[1]); err != nil || len(fields[1]) != sha256.Size*2 {
+				return nil, fmt.Errorf("line %d: invalid SHA-256 hash %q", lineNumber, fields[1])
+			}
+			if _, exists := manifest.Files[fields[2]]; exists {
+				return nil, fmt.Errorf("line %d: file %s is listed more than once", lineNumber, fields[2])
+			}
+			manifest.Files[fields[2]] = fields[1]
+		case len(fields) == 3 && fields[0] == "post-migration-script" && fields[1] == "sha256":
+			if _, err := hex.DecodeString(fields[2]); err != nil || len(fields[2]) != sha256.Size*2 {
+				return nil, fmt.Errorf("line %d: invalid SHA-256 hash %q", lineNumber, fields[2])
+			}
+			if manifest.PostMigrationScript != "" {
+				return nil, fmt.Errorf("line %d: the post-migration script is listed more than once", lineNumber)
+			}
+			manifest.PostMigrationScript = fields[2]
+		case len(fields) == 3 && fields[0] == "signature" && fields[1] == "ed25519":
+			signature, err := base64.StdEncoding.DecodeString(fields[2])
+			if err != nil || len(signature) != ed25519.SignatureSize {
+				return nil, fmt.Errorf("line %d: invalid Ed25519 signature", lineNumber)
+			}
+			manifest.Signature = signature
+		default:
+			return nil, fmt.Errorf("line %d: unexpected content %q", lineNumber, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if lineNumber == 0 {
+		return nil, fmt.Errorf("not a bloomdb manifest: file is empty")
+	}
+	return manifest, nil
+}
+
+// SignLocation writes a signed manifest of the migration files of a location and the
+// post-migration script, if any, and returns the number of files it covers
+func SignLocation(location string, recursive bool, postMigrationScript string, key ed25519.PrivateKey) (int, error) {
+	manifest, err := BuildManifest(location, recursive, postMigrationScript)
+	if err != nil {
+		return 0, err
+	}
+	manifest.Sign(key)
+
+	path := filepath.Join(location, ManifestFileName)
+	if err := os.WriteFile(path, manifest.Marshal(), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write manifest %s: %w", path, err)
+	}
+	count := len(manifest.Files)
+	if manifest.PostMigrationScript != "" {
+		count++
+	}
+	return count, nil
+}
+
+// VerificationError lists the files of a location that do not match its signed manifest
+type VerificationError struct {
+	Location string
+	Problems []string // One entry per file, such as "V2__Users.sql: modified after signing"
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("signature verification failed for %s: %s", e.Location, strings.Join(e.Problems, "; "))
+}
+
+// VerifyLocation checks the migration files of a location and the post-migration script
+// that will run after them, if any, against the location's signed manifest. Unsigned
+// locations, invalid signatures and modified, missing or extra files are errors.
+func VerifyLocation(location string, recursive bool, postMigrationScript string, key ed25519.PublicKey) error {
+	content, err := os.ReadFile(filepath.Join(location, ManifestFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &VerificationError{Location: location, Problems: []string{ManifestFileName + ": not found, the migrations are unsigned"}}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	manifest, err := ParseManifest(content)
+	if err != nil {
+		return &VerificationError{Location: location, Problems: []string{fmt.Sprintf("%s: %v", ManifestFileName, err)}}
+	}
+	if manifest.Signature == nil {
+		return &VerificationError{Location: location, Problems: []string{ManifestFileName + ": not signed"}}
+	}
+	if !ed25519.Verify(key, manifest.body(), manifest.Signature) {
+		return &VerificationError{Location: location, Problems: []string{ManifestFileName + ": signature does not match the verify key, or the manifest was edited"}}
+	}
+
+	files, err := signedFiles(location, recursive)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for name, path := range files {
+		expected, signed := manifest.Files[name]
+		if !signed {
+			problems = append(problems, name+": not in the signed manifest")
+			continue
+		}
+		hash, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		if hash != expected {
+			problems = append(problems, name+": modified after signing")
+		}
+	}
+	for name := range manifest.Files {
+		if _, exists := files[name]; !exists {
+			problems = append(problems, name+": signed but missing")
+		}
+	}
+	if postMigrationScript != "" {
+		name := filepath.Base(postMigrationScript)
+		hash, err := hashFile(postMigrationScript)
+		switch {
+		case err != nil:
+			return err
+		case manifest.PostMigrationScript == "":
+			problems = append(problems, name+": post-migration script not in the signed manifest")
+		case hash != manifest.PostMigrationScript:
+			problems = append(problems, name+": post-migration script modified after signing")
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return &VerificationError{Location: location, Problems: problems}
+	}
+	return nil
+}
+
+// ReadPrivateKey reads an Ed25519 private key from a PKCS #8 PEM file,
+// as written by "openssl genpkey -algorithm ed25519"
+func ReadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+	}
+	ed25519Key, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key %s is not an Ed25519 key", path)
+	}
+	return ed25519Key, nil
+}
+
+// ReadPublicKey reads an Ed25519 public key from a PKIX PEM file,
+// as written by "openssl pkey -pubout"
+func ReadPublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+	ed25519Key, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an Ed25519 key", path)
+	}
+	return ed25519Key, nil
+}
+
+// readPEM returns the DER content of the first PEM block of the given type in a file
+func readPEM(path, blockType string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			return nil, fmt.Errorf("no %s PEM block found in %s", blockType, path)
+		}
+		if block.Type == blockType {
+			return block.Bytes, nil
+		}
+	}
+}
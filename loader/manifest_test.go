@@ -0,0 +1,213 @@
+package loader
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles creates files relative to dir, including parent directories
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+// verificationProblems returns the problems reported by VerifyLocation
+func verificationProblems(t *testing.T, err error) []string {
+	t.Helper()
+	var verifyErr *VerificationError
+	require.True(t, errors.As(err, &verifyErr), "expected VerificationError, got %v", err)
+	return verifyErr.Problems
+}
+
+func TestBuildManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"V1__Users.sql":      "CREATE TABLE users (id INT);",
+		"V1__Users.prod.sql": "CREATE TABLE users (id BIGINT);",
+		"R__Views.sql":       "CREATE VIEW v AS SELECT 1;",
+		"R__Views.depends":   "functions\n",
+		"README.md":          "not a migration",
+		"nested/V2__a.sql":   "SELECT 1;",
+	})
+
+	manifest, err := BuildManifest(dir, false, "")
+	require.NoError(t, err)
+	assert.Len(t, manifest.Files, 4)
+	assert.Equal(t, CalculateSHA256([]byte("CREATE TABLE users (id INT);")), manifest.Files["V1__Users.sql"])
+	assert.Contains(t, manifest.Files, "R__Views.depends")
+
+	manifest, err = BuildManifest(dir, true, "")
+	require.NoError(t, err)
+	assert.Len(t, manifest.Files, 5)
+	assert.Contains(t, manifest.Files, "nested/V2__a.sql")
+}
+
+func TestManifest_MarshalAndParse(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	manifest := &Manifest{Files: map[string]string{
+		"V2__b.sql": strings.Repeat("b", 64),
+		"V1__a.sql": strings.Repeat("a", 64),
+	}}
+	manifest.Sign(key)
+	content := manifest.Marshal()
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "bloomdb-manifest v1", lines[0])
+	assert.Equal(t, "sha256 "+strings.Repeat("a", 64)+" V1__a.sql", lines[1])
+	assert.True(t, strings.HasPrefix(lines[3], "signature ed25519 "))
+
+	parsed, err := ParseManifest(content)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, parsed)
+
+	manifest.PostMigrationScript = strings.Repeat("c", 64)
+	manifest.Sign(key)
+	content = manifest.Marshal()
+	assert.Contains(t, string(content), "\npost-migration-script sha256 "+strings.Repeat("c", 64)+"\nsignature ed25519 ")
+
+	parsed, err = ParseManifest(content)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, parsed)
+}
+
+func TestParseManifest_Errors(t *testing.T) {
+	hash := strings.Repeat("a", 64)
+	tests := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{"Empty", "", "file is empty"},
+		{"Wrong header", "manifest v2\n", "not a bloomdb manifest"},
+		{"Invalid hash", "bloomdb-manifest v1\nsha256 xyz V1__a.sql\n", `line 2: invalid SHA-256 hash "xyz"`},
+		{"Duplicate file", "bloomdb-manifest v1\nsha256 " + hash + " V1__a.sql\nsha256 " + hash + " V1__a.sql\n", "listed more than once"},
+		{"Duplicate post-migration script", "bloomdb-manifest v1\npost-migration-script sha256 " + hash + "\npost-migration-script sha256 " + hash + "\n", "post-migration script is listed more than once"},
+		{"Invalid signature", "bloomdb-manifest v1\nsignature ed25519 abc\n", "invalid Ed25519 signature"},
+		{"Unknown line", "bloomdb-manifest v1\nmd5 abc V1__a.sql\n", "line 2: unexpected content"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseManifest([]byte(tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestVerifyLocation(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"V1__Users.sql": "CREATE TABLE users (id INT);",
+		"V2__Roles.sql": "CREATE TABLE roles (id INT);",
+	})
+
+	// Unsigned migrations are rejected
+	assert.Equal(t, []string{"bloomdb.manifest: not found, the migrations are unsigned"}, verificationProblems(t, VerifyLocation(dir, false, "", public)))
+
+	count, err := SignLocation(dir, false, "", private)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.NoError(t, VerifyLocation(dir, false, "", public))
+
+	// A different key does not verify the signature
+	assert.Contains(t, verificationProblems(t, VerifyLocation(dir, false, "", otherPublic))[0], "signature does not match")
+
+	// Modified, missing and extra files are reported by name
+	writeFiles(t, dir, map[string]string{
+		"V1__Users.sql": "CREATE TABLE users (id BIGINT);",
+		"V3__Extra.sql": "DROP TABLE users;",
+	})
+	require.NoError(t, os.Remove(filepath.Join(dir, "V2__Roles.sql")))
+	assert.Equal(t, []string{
+		"V1__Users.sql: modified after signing",
+		"V2__Roles.sql: signed but missing",
+		"V3__Extra.sql: not in the signed manifest",
+	}, verificationProblems(t, VerifyLocation(dir, false, "", public)))
+
+	// Editing the manifest to match breaks the signature
+	manifestPath := filepath.Join(dir, ManifestFileName)
+	content, err := os.ReadFile(manifestPath)
+	require.NoError(t, err)
+	edited := strings.Replace(string(content), "V2__Roles.sql", "V3__Extra.sql", 1)
+	require.NoError(t, os.WriteFile(manifestPath, []byte(edited), 0644))
+	assert.Contains(t, verificationProblems(t, VerifyLocation(dir, false, "", public))[0], "signature does not match")
+}
+
+func TestVerifyLocation_PostMigrationScript(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	script := filepath.Join(t.TempDir(), "post.sql")
+	writeFiles(t, dir, map[string]string{"V1__Users.sql": "CREATE TABLE users (id INT);"})
+	require.NoError(t, os.WriteFile(script, []byte("SELECT 1;"), 0644))
+
+	// A script added after signing is rejected
+	_, err = SignLocation(dir, false, "", private)
+	require.NoError(t, err)
+	require.NoError(t, VerifyLocation(dir, false, "", public))
+	assert.Equal(t, []string{"post.sql: post-migration script not in the signed manifest"}, verificationProblems(t, VerifyLocation(dir, false, script, public)))
+
+	count, err := SignLocation(dir, false, script, private)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.NoError(t, VerifyLocation(dir, false, script, public))
+
+	// The script is identified by its content, not its path
+	moved := filepath.Join(t.TempDir(), "deploy.sql")
+	require.NoError(t, os.WriteFile(moved, []byte("SELECT 1;"), 0644))
+	require.NoError(t, VerifyLocation(dir, false, moved, public))
+
+	require.NoError(t, os.WriteFile(script, []byte("DROP TABLE users;"), 0644))
+	assert.Equal(t, []string{"post.sql: post-migration script modified after signing"}, verificationProblems(t, VerifyLocation(dir, false, script, public)))
+}
+
+func TestReadKeys(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "signing.pem")
+	publicPath := filepath.Join(dir, "verify.pem")
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600))
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644))
+
+	readPrivate, err := ReadPrivateKey(privatePath)
+	require.NoError(t, err)
+	assert.Equal(t, private, readPrivate)
+
+	readPublic, err := ReadPublicKey(publicPath)
+	require.NoError(t, err)
+	assert.Equal(t, public, readPublic)
+
+	_, err = ReadPublicKey(privatePath)
+	assert.ErrorContains(t, err, "no PUBLIC KEY PEM block found")
+}
@@ -6,19 +6,35 @@ import (
 )
 
 type RepeatableMigration struct {
-	Description string
-	Content     string
-	FilePath    string
-	Checksum    int64
+	Description        string
+	Content            string
+	FilePath           string
+	Checksum           int64  // Flyway-compatible CRC32
+	ChecksumSHA256     string // Hex-encoded SHA-256 of the content with line endings and BOM normalized
+	ChecksumNormalized string // Hex-encoded SHA-256 ignoring comments, whitespace and keyword case
+	Directives         Directives
+	Tags               []string // From the filename and the tags directive
+	RunAlways          bool     // Runs on every migrate: A__ prefix or run-always directive
+	Depends            []string // Descriptions of the repeatable migrations that must run first
+	Prefix             string   // Filename prefix, "A" for A__ files and "R" otherwise
 }
 
 type RepeatableMigrationLoader struct {
-	directory string
+	locations []string
+	recursive bool
 }
 
 func NewRepeatableMigrationLoader(directory string) *RepeatableMigrationLoader {
 	return &RepeatableMigrationLoader{
-		directory: directory,
+		locations: []string{directory},
+	}
+}
+
+// NewRepeatableMigrationLoaderForDirectory creates a loader reading all locations of a migration directory
+func NewRepeatableMigrationLoaderForDirectory(migDir MigrationDirectory) *RepeatableMigrationLoader {
+	return &RepeatableMigrationLoader{
+		locations: migDir.GetLocations(),
+		recursive: migDir.Recursive,
 	}
 }
 
@@ -27,7 +43,7 @@ func (r *RepeatableMigrationLoader) LoadRepeatableMigrations() ([]*RepeatableMig
 	filterConfig := GetFilterConfig()
 
 	// Collect filtered migration files
-	migrationFiles, err := CollectFilteredMigrationFiles(r.directory, filterConfig)
+	migrationFiles, err := CollectFilteredMigrationFilesFromLocations(r.locations, r.recursive, filterConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -46,23 +62,51 @@ func (r *RepeatableMigrationLoader) LoadRepeatableMigrations() ([]*RepeatableMig
 
 		checksum := CalculateChecksum(content)
 
+		directives, err := ParseDirectives(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("invalid directive in migration file %s: %w", mf.Filename, err)
+		}
+
+		depends, err := readDependsFile(mf.FullPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dependencies of migration file %s: %w", mf.Filename, err)
+		}
+
 		migration := &RepeatableMigration{
-			Description: mf.Description,
-			Content:     string(content),
-			FilePath:    mf.FullPath,
-			Checksum:    checksum,
+			Description:        mf.Description,
+			Content:            string(content),
+			FilePath:           mf.FullPath,
+			Checksum:           checksum,
+			ChecksumSHA256:     CalculateSHA256(content),
+			ChecksumNormalized: CalculateNormalizedChecksum(content),
+			Directives:         directives,
+			Tags:               mergeTags(mf.Tags, directives.Tags),
+			RunAlways:          mf.RunAlways || directives.RunAlways,
+			Depends:            mergeDepends(directives.Depends, depends),
+			Prefix:             "R",
+		}
+		if mf.RunAlways {
+			migration.Prefix = "A"
 		}
 
 		migrations = append(migrations, migration)
 	}
 
-	return migrations, nil
+	return sortByDependencies(migrations)
 }
 
 func (r *RepeatableMigration) GetFileName() string {
-	return fmt.Sprintf("R__%s.sql", r.Description)
+	return fmt.Sprintf("%s__%s.sql", r.prefix(), r.Description)
 }
 
 func (r *RepeatableMigration) String() string {
-	return fmt.Sprintf("R__%s", r.Description)
+	return fmt.Sprintf("%s__%s", r.prefix(), r.Description)
+}
+
+// prefix returns the filename prefix, R for migrations built without one
+func (r *RepeatableMigration) prefix() string {
+	if r.Prefix == "" {
+		return "R"
+	}
+	return r.Prefix
 }
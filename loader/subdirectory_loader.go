@@ -13,6 +13,65 @@ type MigrationDirectory struct {
 	Name           string // Directory name (empty for root)
 	VersionTable   string // Derived version table name
 	IsSubdirectory bool   // True if this is a subdirectory
+
+	Locations []string // All locations forming this migration set (defaults to Path)
+	Recursive bool     // True if nested subdirectories of each location are included
+}
+
+// GetLocations returns the locations of this migration directory, falling back to Path
+func (d MigrationDirectory) GetLocations() []string {
+	if len(d.Locations) > 0 {
+		return d.Locations
+	}
+	return []string{d.Path}
+}
+
+// SplitLocations splits a comma-separated list of migration locations
+// Example: "a, b,c" -> ["a", "b", "c"]
+func SplitLocations(path string) []string {
+	var locations []string
+	for _, location := range strings.Split(path, ",") {
+		location = strings.TrimSpace(location)
+		if location != "" {
+			locations = append(locations, location)
+		}
+	}
+	return locations
+}
+
+// DetectMigrationLocations resolves the migration directories for one or more locations.
+// A single location without recursion keeps the subdirectory detection of
+// DetectMigrationDirectories. Multiple locations or recursive mode form a single
+// migration set tracked in the default version table.
+func DetectMigrationLocations(locations []string, recursive bool) ([]MigrationDirectory, error) {
+	if len(locations) == 0 {
+		return nil, fmt.Errorf("no migration location specified")
+	}
+
+	if len(locations) == 1 && !recursive {
+		return DetectMigrationDirectories(locations[0])
+	}
+
+	for _, location := range locations {
+		info, err := os.Stat(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration directory: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("migration location is not a directory: %s", location)
+		}
+	}
+
+	return []MigrationDirectory{
+		{
+			Path:           locations[0],
+			Name:           "",
+			VersionTable:   "", // Will use default
+			IsSubdirectory: false,
+			Locations:      locations,
+			Recursive:      recursive,
+		},
+	}, nil
 }
 
 // DeriveVersionTableName converts a directory name to a version table name
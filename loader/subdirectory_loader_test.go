@@ -168,3 +168,91 @@ func TestDetectMigrationDirectories_SubdirectoriesWithNonMigrationFiles(t *testi
 	assert.Equal(t, "", dirs[0].Name)
 	assert.False(t, dirs[0].IsSubdirectory)
 }
+
+func TestSplitLocations(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{
+			name:     "single location",
+			path:     "./migrations",
+			expected: []string{"./migrations"},
+		},
+		{
+			name:     "multiple locations",
+			path:     "a,b,c",
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "whitespace and empty entries",
+			path:     " a , ,b ",
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "empty string",
+			path:     "",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SplitLocations(tt.path))
+		})
+	}
+}
+
+func TestDetectMigrationLocations_SingleLocationKeepsSubdirectoryDetection(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	subdir := filepath.Join(tmpDir, "tenant-a")
+	require.NoError(t, os.Mkdir(subdir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(subdir, "V1__test.sql"), []byte("SELECT 1;"), 0644))
+
+	dirs, err := DetectMigrationLocations([]string{tmpDir}, false)
+	require.NoError(t, err)
+
+	assert.Len(t, dirs, 1)
+	assert.Equal(t, "BLOOMDB_TENANT_A", dirs[0].VersionTable)
+	assert.True(t, dirs[0].IsSubdirectory)
+	assert.Equal(t, []string{subdir}, dirs[0].GetLocations())
+}
+
+func TestDetectMigrationLocations_Recursive(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	subdir := filepath.Join(tmpDir, "2025")
+	require.NoError(t, os.Mkdir(subdir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(subdir, "V1__test.sql"), []byte("SELECT 1;"), 0644))
+
+	dirs, err := DetectMigrationLocations([]string{tmpDir}, true)
+	require.NoError(t, err)
+
+	// Subdirectories are part of one migration set using the default table
+	assert.Len(t, dirs, 1)
+	assert.Equal(t, tmpDir, dirs[0].Path)
+	assert.Equal(t, "", dirs[0].VersionTable)
+	assert.False(t, dirs[0].IsSubdirectory)
+	assert.True(t, dirs[0].Recursive)
+}
+
+func TestDetectMigrationLocations_MultipleLocations(t *testing.T) {
+	locationA := t.TempDir()
+	locationB := t.TempDir()
+
+	dirs, err := DetectMigrationLocations([]string{locationA, locationB}, false)
+	require.NoError(t, err)
+
+	assert.Len(t, dirs, 1)
+	assert.Equal(t, []string{locationA, locationB}, dirs[0].GetLocations())
+	assert.Equal(t, "", dirs[0].VersionTable)
+}
+
+func TestDetectMigrationLocations_MissingLocation(t *testing.T) {
+	locationA := t.TempDir()
+
+	_, err := DetectMigrationLocations([]string{locationA, filepath.Join(locationA, "missing")}, false)
+	assert.Error(t, err)
+}
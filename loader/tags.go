@@ -0,0 +1,117 @@
+package loader
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tagPattern is the accepted form of a tag; tags are compared case-insensitively
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ParseTag normalizes and validates a single tag
+func ParseTag(tag string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(tag))
+	if !tagPattern.MatchString(normalized) {
+		return "", fmt.Errorf("invalid tag %q: tags may only contain letters, digits, '_' and '-' and must start with a letter or digit", tag)
+	}
+	return normalized, nil
+}
+
+// ParseTagList parses a comma-separated list of tags. An empty string is an empty list.
+func ParseTagList(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var tags []string
+	for _, item := range strings.Split(value, ",") {
+		tag, err := ParseTag(item)
+		if err != nil {
+			return nil, err
+		}
+		tags = appendTag(tags, tag)
+	}
+	return tags, nil
+}
+
+// splitFilenameTags splits "<description>+<tag>+<tag>" from a migration filename
+func splitFilenameTags(filename, description string) (string, []string, error) {
+	parts := strings.Split(description, "+")
+	if parts[0] == "" {
+		return "", nil, fmt.Errorf("missing description in file %s", filename)
+	}
+	var tags []string
+	for _, part := range parts[1:] {
+		tag, err := ParseTag(part)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w in file %s", err, filename)
+		}
+		tags = appendTag(tags, tag)
+	}
+	return parts[0], tags, nil
+}
+
+// appendTag appends tag unless it is already present
+func appendTag(tags []string, tag string) []string {
+	for _, existing := range tags {
+		if existing == tag {
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
+
+// mergeTags combines filename and directive tags, keeping their order
+func mergeTags(lists ...[]string) []string {
+	var merged []string
+	for _, list := range lists {
+		for _, tag := range list {
+			merged = appendTag(merged, tag)
+		}
+	}
+	return merged
+}
+
+// TagSelection decides which tagged migrations run.
+// Untagged migrations always run. A tagged migration runs only if one of its tags
+// is included, and never if one of its tags is excluded.
+type TagSelection struct {
+	Include []string
+	Exclude []string
+}
+
+// ParseTagSelection parses the comma-separated --tags and --exclude-tags values
+func ParseTagSelection(include, exclude string) (TagSelection, error) {
+	var selection TagSelection
+	var err error
+	if selection.Include, err = ParseTagList(include); err != nil {
+		return TagSelection{}, fmt.Errorf("tags: %w", err)
+	}
+	if selection.Exclude, err = ParseTagList(exclude); err != nil {
+		return TagSelection{}, fmt.Errorf("exclude_tags: %w", err)
+	}
+	return selection, nil
+}
+
+// Selects reports whether a migration with the given tags runs
+func (s TagSelection) Selects(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	if containsAny(s.Exclude, tags) {
+		return false
+	}
+	return containsAny(s.Include, tags)
+}
+
+// containsAny reports whether any of tags is in list
+func containsAny(list, tags []string) bool {
+	for _, tag := range tags {
+		for _, item := range list {
+			if item == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTagList(t *testing.T) {
+	tags, err := ParseTagList(" Seed, demo,seed ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"seed", "demo"}, tags)
+
+	tags, err = ParseTagList("")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	_, err = ParseTagList("seed,,demo")
+	assert.Error(t, err)
+	_, err = ParseTagList("seed data")
+	assert.Error(t, err)
+}
+
+func TestTagSelection_Selects(t *testing.T) {
+	tests := []struct {
+		name      string
+		include   string
+		exclude   string
+		tags      []string
+		selection bool
+	}{
+		{"Untagged without selection", "", "", nil, true},
+		{"Untagged with selection", "seed", "", nil, true},
+		{"Tagged without selection", "", "", []string{"seed"}, false},
+		{"Tagged and included", "seed,demo", "", []string{"demo"}, true},
+		{"Tagged and not included", "seed", "", []string{"backfill"}, false},
+		{"Exclusion wins", "seed", "slow", []string{"seed", "slow"}, false},
+		{"Exclusion of other tags", "seed", "slow", []string{"seed"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection, err := ParseTagSelection(tt.include, tt.exclude)
+			require.NoError(t, err)
+			assert.Equal(t, tt.selection, selection.Selects(tt.tags))
+		})
+	}
+}
+
+func TestParseTagSelection_Invalid(t *testing.T) {
+	_, err := ParseTagSelection("seed;drop", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tags:")
+
+	_, err = ParseTagSelection("", "-x")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exclude_tags:")
+}
+
+func TestParseMigrationFilename_Tags(t *testing.T) {
+	file, err := ParseMigrationFilename("V5__Seed_users+seed+Demo.postgres.sql")
+	require.NoError(t, err)
+	assert.Equal(t, "Seed_users", file.Description)
+	assert.Equal(t, []string{"seed", "demo"}, file.Tags)
+	assert.Equal(t, "postgres", file.Filter)
+
+	file, err = ParseMigrationFilename("R__Refresh_stats+backfill.sql")
+	require.NoError(t, err)
+	assert.Equal(t, "Refresh_stats", file.Description)
+	assert.Equal(t, []string{"backfill"}, file.Tags)
+
+	_, err = ParseMigrationFilename("V6__Seed+.sql")
+	assert.Error(t, err)
+	_, err = ParseMigrationFilename("V6__+seed.sql")
+	assert.Error(t, err)
+}
+
+func TestLoadMigrations_Tags(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "V1__Seed+seed.sql"), []byte("-- bloomdb: tags=demo,seed\nINSERT INTO t VALUES (1);"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "R__Views.sql"), []byte("-- bloomdb: tags=Reporting\nCREATE VIEW v AS SELECT 1;"), 0644))
+
+	versioned, err := NewVersionedMigrationLoader(tempDir).LoadMigrations()
+	require.NoError(t, err)
+	require.Len(t, versioned, 1)
+	assert.Equal(t, "Seed", versioned[0].Description)
+	assert.Equal(t, []string{"seed", "demo"}, versioned[0].Tags)
+
+	repeatable, err := NewRepeatableMigrationLoader(tempDir).LoadRepeatableMigrations()
+	require.NoError(t, err)
+	require.Len(t, repeatable, 1)
+	assert.Equal(t, []string{"reporting"}, repeatable[0].Tags)
+}
@@ -0,0 +1,179 @@
+package loader
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind classifies a token of an SQL script
+type TokenKind int
+
+const (
+	TokenWord             TokenKind = iota // Keyword, unquoted identifier or number
+	TokenString                            // 'literal', including dollar-quoted strings
+	TokenQuotedIdentifier                  // "identifier", `identifier` or [identifier]
+	TokenComment                           // -- line comment or /* block comment */
+	TokenSymbol                            // Operator or punctuation, one character per token
+)
+
+// Token is a single lexical element of an SQL script
+type Token struct {
+	Kind TokenKind
+	Text string
+}
+
+// TokenizeSQL splits an SQL script into tokens, dropping whitespace.
+// Unterminated strings, identifiers and comments run to the end of the script.
+func TokenizeSQL(content string) []Token {
+	var tokens []Token
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRuneInString(content[i:])
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			continue
+		case strings.HasPrefix(content[i:], "--"):
+			i = indexFrom(content, i, "\n", 0)
+			tokens = append(tokens, Token{TokenComment, strings.TrimRight(content[start:i], "\r\n")})
+			continue
+		case strings.HasPrefix(content[i:], "/*"):
+			i = blockCommentEnd(content, i)
+			tokens = append(tokens, Token{TokenComment, content[start:i]})
+			continue
+		case r == '\'':
+			i = quotedEnd(content, i, '\'')
+			tokens = append(tokens, Token{TokenString, content[start:i]})
+			continue
+		case r == '"' || r == '`':
+			i = quotedEnd(content, i, byte(r))
+			tokens = append(tokens, Token{TokenQuotedIdentifier, content[start:i]})
+			continue
+		case r == '[':
+			i = indexFrom(content, i+1, "]", 1)
+			tokens = append(tokens, Token{TokenQuotedIdentifier, content[start:i]})
+			continue
+		case r == '$':
+			if tag, ok := dollarTag(content[i:]); ok {
+				i = indexFrom(content, i+len(tag), tag, len(tag))
+				tokens = append(tokens, Token{TokenString, content[start:i]})
+				continue
+			}
+		}
+
+		if isWordRune(r) {
+			for i < len(content) {
+				r, size := utf8.DecodeRuneInString(content[i:])
+				if !isWordRune(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, Token{TokenWord, content[start:i]})
+			continue
+		}
+
+		i += size
+		tokens = append(tokens, Token{TokenSymbol, content[start:i]})
+	}
+	return tokens
+}
+
+// NormalizeSQL returns the tokens of an SQL script separated by single spaces, without
+// comments and with keywords in upper case. Scripts that differ only in formatting,
+// comments and keyword case normalize to the same text. Identifiers keep their case,
+// and directive comments are kept because they change how the migration runs.
+func NormalizeSQL(content string) string {
+	var parts []string
+	for _, token := range TokenizeSQL(stripBOM(content)) {
+		switch token.Kind {
+		case TokenComment:
+			if !strings.HasPrefix(token.Text, directivePrefix) {
+				continue
+			}
+			parts = append(parts, strings.Join(strings.Fields(token.Text), " "))
+		case TokenWord:
+			if upper := strings.ToUpper(token.Text); sqlKeywords[upper] {
+				parts = append(parts, upper)
+			} else {
+				parts = append(parts, token.Text)
+			}
+		default:
+			parts = append(parts, token.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// isWordRune reports whether r belongs to a keyword, identifier or number
+func isWordRune(r rune) bool {
+	return r == '_' || r == '$' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// indexFrom returns the offset just past the first occurrence of substr at or after
+// content[from+skip:], or the end of content if there is none
+func indexFrom(content string, from int, substr string, skip int) int {
+	from += skip
+	if from > len(content) {
+		return len(content)
+	}
+	pos := strings.Index(content[from:], substr)
+	if pos < 0 {
+		return len(content)
+	}
+	return from + pos + len(substr)
+}
+
+// quotedEnd returns the offset just past the quoted text starting at content[start].
+// A doubled quote character is an escaped quote.
+func quotedEnd(content string, start int, quote byte) int {
+	for i := start + 1; i < len(content); i++ {
+		if content[i] != quote {
+			continue
+		}
+		if i+1 < len(content) && content[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(content)
+}
+
+// blockCommentEnd returns the offset just past the block comment starting at content[start].
+// Block comments nest, as in PostgreSQL.
+func blockCommentEnd(content string, start int) int {
+	depth := 0
+	for i := start; i+1 < len(content); i++ {
+		switch content[i : i+2] {
+		case "/*":
+			depth++
+			i++
+		case "*/":
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(content)
+}
+
+// dollarTag returns the opening tag of a PostgreSQL dollar-quoted string, such as $$ or $body$
+func dollarTag(content string) (string, bool) {
+	for i := 1; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '$':
+			return content[:i+1], true
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9':
+			continue
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,81 @@
+package loader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenizeSQL(t *testing.T) {
+	content := "-- note\nSELECT a.id, 'it''s' AS \"Name\" /* x /* nested */ y */ FROM [t] WHERE b >= $1;\n" +
+		"CREATE FUNCTION f() AS $body$ SELECT 'x' $body$;"
+
+	assert.Equal(t, []Token{
+		{TokenComment, "-- note"},
+		{TokenWord, "SELECT"},
+		{TokenWord, "a.id"},
+		{TokenSymbol, ","},
+		{TokenString, "'it''s'"},
+		{TokenWord, "AS"},
+		{TokenQuotedIdentifier, `"Name"`},
+		{TokenComment, "/* x /* nested */ y */"},
+		{TokenWord, "FROM"},
+		{TokenQuotedIdentifier, "[t]"},
+		{TokenWord, "WHERE"},
+		{TokenWord, "b"},
+		{TokenSymbol, ">"},
+		{TokenSymbol, "="},
+		{TokenWord, "$1"},
+		{TokenSymbol, ";"},
+		{TokenWord, "CREATE"},
+		{TokenWord, "FUNCTION"},
+		{TokenWord, "f"},
+		{TokenSymbol, "("},
+		{TokenSymbol, ")"},
+		{TokenWord, "AS"},
+		{TokenString, "$body$ SELECT 'x' $body$"},
+		{TokenSymbol, ";"},
+	}, TokenizeSQL(content))
+}
+
+func TestTokenizeSQL_Unterminated(t *testing.T) {
+	assert.Equal(t, []Token{{TokenWord, "SELECT"}, {TokenString, "'abc"}}, TokenizeSQL("SELECT 'abc"))
+	assert.Equal(t, []Token{{TokenWord, "SELECT"}, {TokenComment, "/* abc"}}, TokenizeSQL("SELECT /* abc"))
+}
+
+func TestNormalizeSQL(t *testing.T) {
+	original := "CREATE TABLE users (id INTEGER, name TEXT DEFAULT 'Anon');"
+
+	cosmetic := []string{
+		"create table users(id integer,name text default 'Anon');",
+		"-- Users of the application\nCREATE TABLE users (\n    id   INTEGER,\n    name TEXT DEFAULT 'Anon' /* display name */\n);\n\n",
+		"\ufeffCreate Table users (id Integer, name Text Default 'Anon');   \r\n",
+	}
+	for _, content := range cosmetic {
+		assert.Equal(t, NormalizeSQL(original), NormalizeSQL(content), "content: %q", content)
+	}
+
+	semantic := []string{
+		"CREATE TABLE users (id INTEGER, name TEXT DEFAULT 'anon');",
+		`CREATE TABLE "Users" (id INTEGER, name TEXT DEFAULT 'Anon');`,
+		"CREATE TABLE Users (id INTEGER, name TEXT DEFAULT 'Anon');",
+		"CREATE TABLE users (ID INTEGER, name TEXT DEFAULT 'Anon');",
+		"CREATE TABLE users (id BIGINT, name TEXT DEFAULT 'Anon');",
+		"-- bloomdb: no-transaction\nCREATE TABLE users (id INTEGER, name TEXT DEFAULT 'Anon');",
+	}
+	for _, content := range semantic {
+		assert.NotEqual(t, NormalizeSQL(original), NormalizeSQL(content), "content: %q", content)
+	}
+}
+
+func TestNormalizeSQL_Directives(t *testing.T) {
+	assert.Equal(t,
+		NormalizeSQL("-- bloomdb: tags=seed   retry=1\nSELECT 1;"),
+		NormalizeSQL("-- bloomdb: tags=seed retry=1\r\n\n-- seeds demo data\nselect 1;"))
+	assert.Equal(t, "-- bloomdb: no-transaction SELECT 1 ;", NormalizeSQL("-- bloomdb: no-transaction\nSELECT 1;"))
+}
+
+func TestNormalizeSQL_IdentifierCase(t *testing.T) {
+	assert.Equal(t, "SELECT id , Name FROM app.Users ;", NormalizeSQL("select id, Name from app.Users;"))
+	assert.NotEqual(t, NormalizeSQL("ALTER TABLE users RENAME TO archive;"), NormalizeSQL("ALTER TABLE Users RENAME TO archive;"))
+}
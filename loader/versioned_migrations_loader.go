@@ -10,20 +10,33 @@ import (
 )
 
 type VersionedMigration struct {
-	Version     string
-	Description string
-	Content     string
-	FilePath    string
-	Checksum    int64
+	Version            string
+	Description        string
+	Content            string
+	FilePath           string
+	Checksum           int64  // Flyway-compatible CRC32
+	ChecksumSHA256     string // Hex-encoded SHA-256 of the content with line endings and BOM normalized
+	ChecksumNormalized string // Hex-encoded SHA-256 ignoring comments, whitespace and keyword case
+	Directives         Directives
+	Tags               []string // From the filename and the tags directive
 }
 
 type VersionedMigrationLoader struct {
-	directory string
+	locations []string
+	recursive bool
 }
 
 func NewVersionedMigrationLoader(directory string) *VersionedMigrationLoader {
 	return &VersionedMigrationLoader{
-		directory: directory,
+		locations: []string{directory},
+	}
+}
+
+// NewVersionedMigrationLoaderForDirectory creates a loader reading all locations of a migration directory
+func NewVersionedMigrationLoaderForDirectory(migDir MigrationDirectory) *VersionedMigrationLoader {
+	return &VersionedMigrationLoader{
+		locations: migDir.GetLocations(),
+		recursive: migDir.Recursive,
 	}
 }
 
@@ -43,7 +56,7 @@ func (l *VersionedMigrationLoader) LoadMigrations() ([]*VersionedMigration, erro
 	filterConfig := GetFilterConfig()
 
 	// Collect filtered migration files
-	migrationFiles, err := CollectFilteredMigrationFiles(l.directory, filterConfig)
+	migrationFiles, err := CollectFilteredMigrationFilesFromLocations(l.locations, l.recursive, filterConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -62,12 +75,24 @@ func (l *VersionedMigrationLoader) LoadMigrations() ([]*VersionedMigration, erro
 
 		checksum := CalculateChecksum(content)
 
+		directives, err := ParseDirectives(string(content))
+		if err == nil {
+			err = directives.validateForVersioned()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid directive in migration file %s: %w", mf.Filename, err)
+		}
+
 		migration := &VersionedMigration{
-			Version:     mf.Version,
-			Description: mf.Description,
-			Content:     string(content),
-			FilePath:    mf.FullPath,
-			Checksum:    checksum,
+			Version:            mf.Version,
+			Description:        mf.Description,
+			Content:            string(content),
+			FilePath:           mf.FullPath,
+			Checksum:           checksum,
+			ChecksumSHA256:     CalculateSHA256(content),
+			ChecksumNormalized: CalculateNormalizedChecksum(content),
+			Directives:         directives,
+			Tags:               mergeTags(mf.Tags, directives.Tags),
 		}
 
 		migrations = append(migrations, migration)
@@ -226,3 +226,21 @@ func TestIsValidVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestLoader_LoadMigrations_MultipleLocations(t *testing.T) {
+	locationA := t.TempDir()
+	locationB := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(locationA, "V1__first.sql"), []byte("SELECT 1;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(locationB, "V2__second.sql"), []byte("SELECT 2;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(locationA, "V3__third.sql"), []byte("SELECT 3;"), 0644))
+
+	migDir := MigrationDirectory{Path: locationA, Locations: []string{locationA, locationB}}
+	migrations, err := NewVersionedMigrationLoaderForDirectory(migDir).LoadMigrations()
+
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, "1", migrations[0].Version)
+	assert.Equal(t, "2", migrations[1].Version)
+	assert.Equal(t, "3", migrations[2].Version)
+}
@@ -175,8 +175,11 @@ func (p *HumanPrinter) DisplayMigrationTable(dbType db.DatabaseType, tableName s
 		colorize("VERSION", "bold+blue"),
 		colorize("DESCRIPTION", "bold+blue"),
 		colorize("TYPE", "bold+blue"),
+		colorize("TAGS", "bold+blue"),
 		colorize("STATUS", "bold+blue"),
 		colorize("INSTALLED ON", "bold+blue"),
+		colorize("INSTALLED BY", "bold+blue"),
+		colorize("INSTALLED FROM", "bold+blue"),
 	})
 
 	// Track if we've crossed the baseline boundary
@@ -205,10 +208,9 @@ func (p *HumanPrinter) DisplayMigrationTable(dbType db.DatabaseType, tableName s
 			version = colorize(version, "cyan")
 		}
 
-		installedOn := status.InstalledOn
-		if installedOn == "" {
-			installedOn = colorize("─", "dim")
-		}
+		installedOn := orDash(status.InstalledOn)
+		installedBy := orDash(status.InstalledBy)
+		installedFrom := orDash(status.InstalledFrom)
 
 		// Colorize status
 		statusColored := colorizeStatus(status.Status)
@@ -220,8 +222,11 @@ func (p *HumanPrinter) DisplayMigrationTable(dbType db.DatabaseType, tableName s
 			version,
 			formatDescription(status.Description),
 			typeColored,
+			orDash(status.Tags),
 			statusColored,
 			installedOn,
+			installedBy,
+			installedFrom,
 		})
 	}
 
@@ -229,6 +234,59 @@ func (p *HumanPrinter) DisplayMigrationTable(dbType db.DatabaseType, tableName s
 	t.Render()
 }
 
+// orDash returns value, or a dimmed dash for empty cells
+func orDash(value string) string {
+	if value == "" {
+		return colorize("─", "dim")
+	}
+	return value
+}
+
+// DisplayConfig prints a formatted table of resolved configuration values
+func (p *HumanPrinter) DisplayConfig(configFile, profile string, entries []ConfigEntry) {
+	if configFile == "" {
+		configFile = colorize("none", "dim")
+	}
+	if profile == "" {
+		profile = colorize("none", "dim")
+	}
+	fmt.Printf("Config file: %s\n", configFile)
+	fmt.Printf("Profile:     %s\n", profile)
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+
+	style := table.StyleRounded
+	style.Options.SeparateRows = false
+	style.Options.SeparateColumns = true
+	style.Options.SeparateHeader = true
+	t.SetStyle(style)
+
+	t.AppendHeader(table.Row{
+		colorize("SETTING", "bold+blue"),
+		colorize("VALUE", "bold+blue"),
+		colorize("SOURCE", "bold+blue"),
+	})
+
+	for _, entry := range entries {
+		value := entry.Value
+		if value == "" {
+			value = colorize("─", "dim")
+		}
+
+		source := entry.Source
+		if source == "default" {
+			source = colorize(source, "dim")
+		} else {
+			source = colorize(source, "cyan")
+		}
+
+		t.AppendRow(table.Row{entry.Key, value, source})
+	}
+
+	t.Render()
+}
+
 // Helper functions for colorization
 
 // colorize adds ANSI color codes to text for terminal output
@@ -279,12 +337,18 @@ func colorizeStatus(status string) string {
 	switch status {
 	case "success":
 		return colorize("✓ "+status, "bold+green")
+	case "cosmetic":
+		return colorize("✓ "+status, "green")
 	case "pending":
 		return colorize("○ "+status, "yellow")
 	case "baseline":
 		return colorize("◉ "+status, "cyan")
-	case "below baseline":
+	case "below baseline", "excluded":
 		return colorize("⊘ "+status, "dim")
+	case "skipped":
+		return colorize("⊘ "+status, "cyan")
+	case "warned":
+		return colorize("⚠ "+status, "yellow")
 	case "failed":
 		return colorize("✗ "+status, "bold+red")
 	case "missing":
@@ -301,7 +365,7 @@ func colorizeType(migrationType string) string {
 	switch migrationType {
 	case "versioned":
 		return colorize(migrationType, "blue")
-	case "repeatable":
+	case "repeatable", "always":
 		return colorize(migrationType, "magenta")
 	default:
 		return migrationType
@@ -281,11 +281,13 @@ func TestHumanPrinter_DisplayMigrationTable(t *testing.T) {
 	p := &HumanPrinter{verbose: false}
 	statuses := []MigrationStatus{
 		{
-			Version:     "V0.1",
-			Description: "create_user_table",
-			Type:        "versioned",
-			Status:      "success",
-			InstalledOn: "2025-01-01 12:00:00",
+			Version:       "V0.1",
+			Description:   "create_user_table",
+			Type:          "versioned",
+			Status:        "success",
+			InstalledOn:   "2025-01-01 12:00:00",
+			InstalledBy:   "deployer",
+			InstalledFrom: "build-01 (gitlab:4711)",
 		},
 		{
 			Version:     "V0.2",
@@ -320,6 +322,8 @@ func TestHumanPrinter_DisplayMigrationTable(t *testing.T) {
 	assert.Contains(t, output, "TYPE")
 	assert.Contains(t, output, "STATUS")
 	assert.Contains(t, output, "INSTALLED ON")
+	assert.Contains(t, output, "INSTALLED BY")
+	assert.Contains(t, output, "INSTALLED FROM")
 
 	// Check for data rows
 	assert.Contains(t, output, "V0.1")
@@ -327,6 +331,8 @@ func TestHumanPrinter_DisplayMigrationTable(t *testing.T) {
 	assert.Contains(t, output, "V0.2")
 	assert.Contains(t, output, "V1")
 	assert.Contains(t, output, "R__Test_summary")
+	assert.Contains(t, output, "deployer")
+	assert.Contains(t, output, "build-01 (gitlab:4711)")
 }
 
 func TestColorize(t *testing.T) {
@@ -385,6 +391,9 @@ func TestColorizeStatus(t *testing.T) {
 		{status: "failed", icon: "✗", colorOpt: "red"},
 		{status: "missing", icon: "✗", colorOpt: "red"},
 		{status: "checksum", icon: "⚠", colorOpt: "red"},
+		{status: "skipped", icon: "⊘", colorOpt: "cyan"},
+		{status: "warned", icon: "⚠", colorOpt: "yellow"},
+		{status: "cosmetic", icon: "✓", colorOpt: "green"},
 	}
 
 	for _, tt := range tests {
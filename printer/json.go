@@ -145,3 +145,12 @@ func (p *JSONPrinter) DisplayMigrationTable(dbType db.DatabaseType, tableName st
 	jsonBytes, _ := json.Marshal(output)
 	fmt.Println(string(jsonBytes))
 }
+
+// DisplayConfig prints resolved configuration values as JSON
+func (p *JSONPrinter) DisplayConfig(configFile, profile string, entries []ConfigEntry) {
+	p.outputJSON("info", "config", map[string]interface{}{
+		"config_file": configFile,
+		"profile":     profile,
+		"settings":    entries,
+	})
+}
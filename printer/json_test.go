@@ -317,6 +317,34 @@ func TestJSONPrinter_DisplayMigrationTable_Empty(t *testing.T) {
 	assert.Len(t, migrationsData, 0)
 }
 
+func TestJSONPrinter_DisplayConfig(t *testing.T) {
+	printer := NewJSONPrinter(false)
+
+	entries := []ConfigEntry{
+		{Key: "path", Value: "./migrations", Source: "bloomdb.yaml (profile prod)"},
+		{Key: "table_name", Value: "BLOOMDB_VERSION", Source: "default"},
+	}
+
+	output := captureJSONOutput(t, func() {
+		printer.DisplayConfig("bloomdb.yaml", "prod", entries)
+	})
+
+	assert.Equal(t, "info", output.Level)
+	assert.Equal(t, "config", output.Message)
+	assert.Equal(t, "bloomdb.yaml", output.Data["config_file"])
+	assert.Equal(t, "prod", output.Data["profile"])
+
+	settingsData, ok := output.Data["settings"].([]interface{})
+	assert.True(t, ok, "settings should be an array")
+	assert.Len(t, settingsData, 2)
+
+	first, ok := settingsData[0].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, "path", first["key"])
+	assert.Equal(t, "./migrations", first["value"])
+	assert.Equal(t, "bloomdb.yaml (profile prod)", first["source"])
+}
+
 func TestJSONPrinter_OutputFormat_ValidJSON(t *testing.T) {
 	printer := NewJSONPrinter(false)
 
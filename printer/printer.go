@@ -16,9 +16,20 @@ const (
 type MigrationStatus struct {
 	Version     string
 	Description string
-	Type        string // "versioned" or "repeatable"
-	Status      string // "baseline", "success", "pending", "below baseline"
+	Type        string // "versioned", "repeatable" or "always" (run-always repeatable)
+	Status      string // "baseline", "success", "pending", "excluded", "below baseline"
+	Tags        string // Comma-separated tags of the migration file
 	InstalledOn string
+	// Who applied the migration and from which host and CI job
+	InstalledBy   string
+	InstalledFrom string
+}
+
+// ConfigEntry represents a resolved configuration value and its source
+type ConfigEntry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
 }
 
 // Printer interface defines all output methods for BloomDB CLI
@@ -35,4 +46,5 @@ type Printer interface {
 	PrintMigration(version, description, status string)
 	PrintObject(objType, name string)
 	DisplayMigrationTable(dbType db.DatabaseType, tableName string, statuses []MigrationStatus)
+	DisplayConfig(configFile, profile string, entries []ConfigEntry)
 }
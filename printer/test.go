@@ -111,3 +111,11 @@ func (p *TestPrinter) DisplayMigrationTable(dbType db.DatabaseType, tableName st
 			status.Version, status.Description, status.Type, status.Status))
 	}
 }
+
+// DisplayConfig prints resolved configuration values (simplified for tests)
+func (p *TestPrinter) DisplayConfig(configFile, profile string, entries []ConfigEntry) {
+	p.output("INFO", fmt.Sprintf("config: file=%s profile=%s count=%d", configFile, profile, len(entries)))
+	for _, entry := range entries {
+		p.output("INFO", fmt.Sprintf("config_row: key=%s value=%s source=%s", entry.Key, entry.Value, entry.Source))
+	}
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -289,3 +290,36 @@ func TestBaselineVersionPriority_CLIOverridesEnv(t *testing.T) {
 	require.Len(t, records, 1, "Expected 1 baseline record")
 	assert.Equal(t, "6.0", records[0].Version, "Expected version '6.0' from CLI flag (not '5.0' from env)")
 }
+
+// TestBaseline_InstalledBy tests that the baseline records who ran it and from where
+func TestBaseline_InstalledBy(t *testing.T) {
+	ctx := NewTestContext(t)
+	t.Setenv("BLOOMDB_INSTALLED_BY", "")
+	t.Setenv("GITHUB_RUN_ID", "")
+	t.Setenv("CI_JOB_ID", "4711")
+
+	_, err := ctx.RunCommand("baseline", "--version", "1", "--installed-by", "deployer")
+	require.NoError(t, err, "Baseline command failed")
+
+	records, err := ctx.GetMigrationRecords()
+	require.NoError(t, err, "Failed to get migration records")
+	require.Len(t, records, 1, "Expected 1 baseline record")
+
+	assert.Equal(t, "deployer", records[0].InstalledBy)
+	assert.True(t, strings.HasSuffix(records[0].InstalledFrom.String, "(gitlab:4711)"),
+		"Expected installed_from to name the CI job, got %q", records[0].InstalledFrom.String)
+}
+
+// TestBaseline_InstalledByEnvironment tests BLOOMDB_INSTALLED_BY
+func TestBaseline_InstalledByEnvironment(t *testing.T) {
+	ctx := NewTestContext(t)
+	t.Setenv("BLOOMDB_INSTALLED_BY", "release-bot")
+
+	_, err := ctx.RunCommand("baseline", "--version", "1")
+	require.NoError(t, err, "Baseline command failed")
+
+	records, err := ctx.GetMigrationRecords()
+	require.NoError(t, err, "Failed to get migration records")
+	require.Len(t, records, 1, "Expected 1 baseline record")
+	assert.Equal(t, "release-bot", records[0].InstalledBy)
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBundle tests that migrate, info and validate run a bundle created with bloomdb bundle
+func TestBundle(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"V2__Create_users.sql": "CREATE TABLE users (id INTEGER);",
+		"R__Users_view.sql":    "CREATE VIEW IF NOT EXISTS users_view AS SELECT * FROM users;",
+	})
+	bundle := filepath.Join(t.TempDir(), "migrations.tar.gz")
+
+	output, err := ctx.RunCommand("bundle", "--output", bundle)
+	require.NoError(t, err, "Bundle command failed:\n%s", output)
+	assert.Contains(t, output, "Bundled 2 file(s)")
+	require.FileExists(t, bundle)
+
+	// The source directory is no longer needed once bundled
+	require.NoError(t, os.RemoveAll(ctx.MigrationPath))
+
+	_, err = runWithBundle(ctx, "baseline", "--version", "1")
+	require.NoError(t, err, "Baseline command failed")
+
+	output, err = runWithBundle(ctx, "migrate", "--bundle", bundle)
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	assert.Contains(t, output, "Using bundle "+bundle)
+	exists, err := ctx.TableExists("users")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	output, err = runWithBundle(ctx, "info", "--bundle", bundle)
+	require.NoError(t, err, "Info command failed:\n%s", output)
+	assert.Contains(t, output, "version=2 description=Create_users type=versioned status=success")
+
+	output, err = runWithBundle(ctx, "validate", "--bundle", bundle)
+	require.NoError(t, err, "Validate command failed:\n%s", output)
+
+	// A bundle replaces the migration path
+	output, err = ctx.RunCommand("migrate", "--bundle", bundle)
+	assertExitCode(t, err, 2, output)
+	AssertErrorMessage(t, output, "bundle and path cannot be combined")
+
+	// Tampered bundles are rejected before any migration runs
+	tampered := filepath.Join(t.TempDir(), "tampered.tar.gz")
+	require.NoError(t, os.WriteFile(tampered, []byte("not a bundle"), 0644))
+	output, err = runWithBundle(ctx, "migrate", "--bundle", tampered)
+	assertExitCode(t, err, 5, output)
+}
+
+// runWithBundle runs a bloomdb command like RunCommand but without a migration path,
+// so that --bundle can provide the migrations
+func runWithBundle(ctx *TestContext, args ...string) (string, error) {
+	ctx.T.Helper()
+	fullArgs := append([]string{"--conn", fmt.Sprintf("sqlite://%s", ctx.DBPath)}, args...)
+	cmd := exec.Command(ctx.BloomDBBinary, fullArgs...)
+	cmd.Env = append(os.Environ(), "BLOOMDB_PRINTER=test", "BLOOMDB_VERBOSE=1")
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
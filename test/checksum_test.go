@@ -0,0 +1,126 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChecksumSHA256 tests that SHA-256 hashes are recorded, backfilled by repair and validated
+func TestChecksumSHA256(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"V2__Create_users.sql": "CREATE TABLE users (id INTEGER);",
+		"R__Users_view.sql":    "CREATE VIEW IF NOT EXISTS users_view AS SELECT * FROM users;",
+	})
+
+	_, err := ctx.RunCommand("baseline", "--version", "1")
+	require.NoError(t, err, "Baseline command failed")
+	output, err := ctx.RunCommand("migrate")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+
+	db, err := sql.Open(sqliteDriverName, ctx.DBPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	hashes := recordedHashes(t, db)
+	require.Len(t, hashes, 2)
+	for description, hash := range hashes {
+		assert.Len(t, hash, 64, "migration %s should have a SHA-256 hash", description)
+	}
+
+	// Rows written before hashes were recorded are backfilled by repair
+	_, err = db.Exec("UPDATE BLOOMDB_VERSION SET checksum_sha256 = NULL")
+	require.NoError(t, err)
+	output, err = ctx.RunCommand("repair")
+	require.NoError(t, err, "Repair command failed:\n%s", output)
+	assert.Equal(t, hashes, recordedHashes(t, db))
+
+	// A hash mismatch is a checksum error even when the CRC32 checksum matches
+	_, err = db.Exec("UPDATE BLOOMDB_VERSION SET checksum_sha256 = '0000' WHERE version = '2'")
+	require.NoError(t, err)
+
+	output, err = ctx.RunCommand("info")
+	require.NoError(t, err, "Info command failed:\n%s", output)
+	assert.Contains(t, output, "version=2 description=Create_users type=versioned status=checksum")
+
+	output, err = ctx.RunCommand("migrate")
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr), "Expected migrate to fail:\n%s", output)
+	assert.Equal(t, 5, exitErr.ExitCode())
+	AssertErrorMessage(t, output, "SHA-256 expected: 0000")
+}
+
+// recordedHashes returns the SHA-256 hashes of the version table by description, skipping the baseline
+func recordedHashes(t *testing.T, db *sql.DB) map[string]string {
+	t.Helper()
+	rows, err := db.Query("SELECT description, checksum_sha256 FROM BLOOMDB_VERSION WHERE checksum IS NOT NULL")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var description string
+		var hash sql.NullString
+		require.NoError(t, rows.Scan(&description, &hash))
+		hashes[description] = hash.String
+	}
+	require.NoError(t, rows.Err())
+	return hashes
+}
+
+// TestChecksumNormalized tests that validate tells cosmetic from semantic changes and
+// accepts cosmetic changes with --checksum-mode=normalized
+func TestChecksumNormalized(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"V2__Create_users.sql": "CREATE TABLE users (id INTEGER, name TEXT);",
+	})
+
+	_, err := ctx.RunCommand("baseline", "--version", "1")
+	require.NoError(t, err, "Baseline command failed")
+	output, err := ctx.RunCommand("migrate")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	output, err = ctx.RunCommand("validate")
+	require.NoError(t, err, "Validate command failed:\n%s", output)
+
+	// Reformatting and comments are a cosmetic change, rejected in the default strict mode
+	writeMigrations(t, ctx, map[string]string{
+		"V2__Create_users.sql": "-- Application users\ncreate table users (\n    id   integer,\n    name text\n);\n",
+	})
+	output, err = ctx.RunCommand("validate")
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr), "Expected validate to fail:\n%s", output)
+	assert.Equal(t, 5, exitErr.ExitCode())
+	AssertErrorMessage(t, output, "Create_users")
+	assert.Contains(t, output, "cosmetic change")
+	assert.Contains(t, output, "--checksum-mode=normalized")
+
+	output, err = ctx.RunCommand("validate", "--checksum-mode", "normalized")
+	require.NoError(t, err, "Validate command failed:\n%s", output)
+	AssertWarningMessage(t, output, "Accepted cosmetic change to applied migration V2 - Create_users")
+
+	output, err = ctx.RunCommand("info", "--checksum-mode", "normalized")
+	require.NoError(t, err, "Info command failed:\n%s", output)
+	assert.Contains(t, output, "version=2 description=Create_users type=versioned status=cosmetic")
+
+	output, err = ctx.RunCommand("migrate", "--checksum-mode", "normalized")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+
+	// Changed statements are a semantic change in either mode
+	writeMigrations(t, ctx, map[string]string{
+		"V2__Create_users.sql": "CREATE TABLE users (id BIGINT, name TEXT);",
+	})
+	output, err = ctx.RunCommand("validate", "--checksum-mode", "normalized")
+	require.True(t, errors.As(err, &exitErr), "Expected validate to fail:\n%s", output)
+	assert.Equal(t, 5, exitErr.ExitCode())
+	assert.Contains(t, output, "semantic change")
+
+	output, err = ctx.RunCommand("validate", "--checksum-mode", "loose")
+	require.True(t, errors.As(err, &exitErr), "Expected validate to fail:\n%s", output)
+	assert.Equal(t, 2, exitErr.ExitCode())
+}
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConnectionStringFormats tests SQLite URIs with options, in-memory databases and early validation
+func TestConnectionStringFormats(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"V1__Create_users.sql": "CREATE TABLE users (id INTEGER);",
+	})
+
+	fileURI := "file:" + ctx.DBPath + "?_busy_timeout=5000&_journal_mode=WAL"
+	output, err := ctx.RunCommand("baseline", "--version", "0", "--conn", fileURI)
+	require.NoError(t, err, "Baseline command failed:\n%s", output)
+	output, err = ctx.RunCommand("migrate", "--conn", fileURI)
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	AssertInfoMessage(t, output, "Connecting to sqlite database "+ctx.DBPath+" (_busy_timeout=5000, _journal_mode=WAL)")
+
+	// Read-only connections can inspect but not migrate
+	readOnly := "sqlite:" + ctx.DBPath + "?mode=ro"
+	output, err = ctx.RunCommand("info", "--conn", readOnly)
+	require.NoError(t, err, "Info command failed:\n%s", output)
+	assert.Contains(t, output, "version=1 description=Create_users type=versioned status=success")
+
+	require.NoError(t, os.WriteFile(filepath.Join(ctx.MigrationPath, "V2__Create_roles.sql"), []byte("CREATE TABLE roles (id INTEGER);"), 0644))
+	output, err = ctx.RunCommand("migrate", "--conn", readOnly)
+	require.Error(t, err, "A read-only database must not be migrated:\n%s", output)
+	exists, err := ctx.TableExists("roles")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// Every pooled connection sees the same in-memory database
+	output, err = ctx.RunCommand("baseline", "--version", "1", "--conn", ":memory:")
+	require.NoError(t, err, "Baseline command failed:\n%s", output)
+	AssertInfoMessage(t, output, "Connecting to sqlite in-memory database")
+
+	// Invalid connection strings fail before connecting and explain why
+	output, err = ctx.RunCommand("info", "--conn", "sqlite:"+ctx.DBPath+"?mode=readonly")
+	assertExitCode(t, err, 2, output)
+	AssertErrorMessage(t, output, "mode must be one of ro, rw, rwc, memory")
+
+	output, err = ctx.RunCommand("info", "--conn", "postgres://app:s3cret@db:5x/app")
+	assertExitCode(t, err, 2, output)
+	AssertErrorMessage(t, output, "invalid PostgreSQL connection string")
+	assert.NotContains(t, output, "s3cret")
+}
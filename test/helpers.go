@@ -8,8 +8,6 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 // TestContext holds shared test configuration
@@ -34,6 +32,11 @@ type MigrationRecord struct {
 	Success     int
 	InstalledOn string
 	Checksum    *int64
+	InstalledBy string
+	// InstalledFrom is the host and CI job, empty for tables predating the column
+	InstalledFrom sql.NullString
+	Tags          sql.NullString
+	Precondition  sql.NullString
 }
 
 // NewTestContext creates a new test context with temporary database
@@ -51,7 +54,7 @@ func NewTestContext(t *testing.T) *TestContext {
 	binaryPath := filepath.Join("..", "bloomdb")
 	if _, err := os.Stat(binaryPath); os.IsNotExist(err) {
 		t.Logf("Building bloomdb binary...")
-		cmd := exec.Command("go", "build", "-o", binaryPath, "..")
+		cmd := exec.Command("go", buildCommand(binaryPath)...)
 		if output, err := cmd.CombinedOutput(); err != nil {
 			t.Fatalf("Failed to build bloomdb: %v\n%s", err, output)
 		}
@@ -153,14 +156,14 @@ func ParseTestOutput(output string) ([]TestOutput, error) {
 func (ctx *TestContext) GetMigrationRecords() ([]MigrationRecord, error) {
 	ctx.T.Helper()
 
-	db, err := sql.Open("sqlite3", ctx.DBPath)
+	db, err := sql.Open(sqliteDriverName, ctx.DBPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 	defer db.Close()
 
 	rows, err := db.Query(`
-		SELECT "version", "description", "type", "success", "installed on", "checksum"
+		SELECT "version", "description", "type", "success", "installed on", "checksum", "installed_by", "installed_from", "tags", "precondition"
 		FROM BLOOMDB_VERSION
 		ORDER BY "installed on"
 	`)
@@ -179,6 +182,10 @@ func (ctx *TestContext) GetMigrationRecords() ([]MigrationRecord, error) {
 			&record.Success,
 			&record.InstalledOn,
 			&record.Checksum,
+			&record.InstalledBy,
+			&record.InstalledFrom,
+			&record.Tags,
+			&record.Precondition,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
@@ -193,7 +200,7 @@ func (ctx *TestContext) GetMigrationRecords() ([]MigrationRecord, error) {
 func (ctx *TestContext) TableExists(tableName string) (bool, error) {
 	ctx.T.Helper()
 
-	db, err := sql.Open("sqlite3", ctx.DBPath)
+	db, err := sql.Open(sqliteDriverName, ctx.DBPath)
 	if err != nil {
 		return false, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -251,3 +258,9 @@ func AssertWarningMessage(t *testing.T, output string, messageSubstring string)
 func AssertInfoMessage(t *testing.T, output string, messageSubstring string) {
 	AssertOutputContains(t, output, "INFO", messageSubstring)
 }
+
+// buildCommand returns the "go" arguments that build the bloomdb binary at binaryPath
+func buildCommand(binaryPath string) []string {
+	args := append([]string{"build"}, buildArgs...)
+	return append(args, "-o", binaryPath, "..")
+}
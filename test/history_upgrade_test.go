@@ -0,0 +1,60 @@
+package test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHistoryUpgrade_OnlyMutatingCommands tests that info and validate report an outdated
+// version table without changing it, and that migrate upgrades it
+func TestHistoryUpgrade_OnlyMutatingCommands(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"V2__Create_users.sql": "CREATE TABLE users (id INTEGER);",
+	})
+
+	// A version table written before schema versioning, with only the original ten columns
+	db, err := sql.Open(sqliteDriverName, ctx.DBPath)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE BLOOMDB_VERSION (
+		installed_rank INTEGER, version VARCHAR(50), description VARCHAR(200), type VARCHAR(20),
+		script VARCHAR(1000), checksum BIGINT, installed_by VARCHAR(100), installed_on TIMESTAMP,
+		execution_time INTEGER, success INTEGER
+	)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO BLOOMDB_VERSION VALUES (1, '1', '<< Baseline >>', 'BASELINE', '<< Baseline >>', NULL, 'bloomdb', CURRENT_TIMESTAMP, 0, 1)`)
+	require.NoError(t, err)
+
+	for _, command := range []string{"info", "validate"} {
+		output, err := ctx.RunCommand(command)
+		require.NoError(t, err, "%s command failed:\n%s", command, output)
+		AssertWarningMessage(t, output, "Migration table 'BLOOMDB_VERSION' has schema version 1 and needs an upgrade")
+		assert.Equal(t, 10, columnCount(t, db, "BLOOMDB_VERSION"), "%s must not change the version table", command)
+		exists, err := ctx.TableExists("BLOOMDB_METADATA")
+		require.NoError(t, err)
+		assert.False(t, exists, "%s must not record a schema version", command)
+	}
+
+	output, err := ctx.RunCommand("migrate")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	assert.Greater(t, columnCount(t, db, "BLOOMDB_VERSION"), 10)
+	var version int
+	require.NoError(t, db.QueryRow("SELECT schema_version FROM BLOOMDB_METADATA WHERE table_name = 'BLOOMDB_VERSION'").Scan(&version))
+	assert.Greater(t, version, 1)
+
+	output, err = ctx.RunCommand("info")
+	require.NoError(t, err, "Info command failed:\n%s", output)
+	assert.NotContains(t, output, "needs an upgrade")
+}
+
+// columnCount returns the number of columns of a SQLite table
+func columnCount(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?)", table).Scan(&count))
+	return count
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeMigrations writes migration files into a fresh migration directory of ctx
+func writeMigrations(t *testing.T, ctx *TestContext, files map[string]string) {
+	t.Helper()
+	ctx.MigrationPath = t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(ctx.MigrationPath, name), []byte(content), 0644))
+	}
+}
+
+// TestMigrate_Preconditions tests the skip and warn policies and how info reports them
+func TestMigrate_Preconditions(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"V2__Create_users.sql": "CREATE TABLE users (id INTEGER);\nINSERT INTO users VALUES (1);",
+		"V3__Seed_admin.sql": "-- bloomdb: on-fail=skip\n" +
+			"-- bloomdb: precondition SELECT COUNT(*) = 0 FROM users\n" +
+			"INSERT INTO users VALUES (0);",
+		"V4__Add_email.sql": "-- bloomdb: on-fail=warn\n" +
+			"-- bloomdb: precondition SELECT COUNT(*) = 0 FROM pragma_table_info('users')\n" +
+			"-- bloomdb: precondition WHERE name = 'email'\n" +
+			"ALTER TABLE users ADD COLUMN email TEXT;",
+		"V5__Create_audit.sql": "-- bloomdb: on-fail=warn\n" +
+			"-- bloomdb: precondition SELECT 0\n" +
+			"CREATE TABLE audit (id INTEGER);",
+	})
+
+	_, err := ctx.RunCommand("baseline", "--version", "1")
+	require.NoError(t, err, "Baseline command failed")
+
+	output, err := ctx.RunCommand("migrate")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	AssertWarningMessage(t, output, "Skipped migration V3__Seed_admin: precondition not met")
+	AssertWarningMessage(t, output, "Precondition of migration Create_audit not met, running it anyway")
+
+	records, err := ctx.GetMigrationRecords()
+	require.NoError(t, err)
+	preconditions := make(map[string]string)
+	for _, record := range records {
+		preconditions[record.Version] = record.Precondition.String
+		assert.Equal(t, 1, record.Success, "migration %s should be recorded as successful", record.Version)
+	}
+	assert.Equal(t, map[string]string{"1": "", "2": "", "3": "skipped", "4": "passed", "5": "warned"}, preconditions)
+
+	exists, err := ctx.TableExists("audit")
+	require.NoError(t, err)
+	assert.True(t, exists, "warned migration should have run")
+
+	output, err = ctx.RunCommand("info")
+	require.NoError(t, err, "Info command failed:\n%s", output)
+	assert.Contains(t, output, "version=3 description=Seed_admin type=versioned status=skipped")
+	assert.Contains(t, output, "version=4 description=Add_email type=versioned status=success")
+	assert.Contains(t, output, "version=5 description=Create_audit type=versioned status=warned")
+
+	// The skipped migration stays applied
+	output, err = ctx.RunCommand("migrate")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	assert.NotContains(t, output, "Seed_admin status=pending")
+}
+
+// TestMigrate_PreconditionFail tests that on-fail=fail stops before the migration is run or recorded
+func TestMigrate_PreconditionFail(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"V2__Requires_users.sql": "-- bloomdb: precondition SELECT COUNT(*) FROM sqlite_master WHERE name = 'users'\n" +
+			"CREATE INDEX idx_users ON users (id);",
+		"V3__Create_orders.sql": "CREATE TABLE orders (id INTEGER);",
+	})
+
+	_, err := ctx.RunCommand("baseline", "--version", "1")
+	require.NoError(t, err, "Baseline command failed")
+
+	output, err := ctx.RunCommand("migrate")
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr), "Expected migrate to fail:\n%s", output)
+	assert.Equal(t, 4, exitErr.ExitCode())
+	AssertErrorMessage(t, output, "precondition not met")
+
+	records, err := ctx.GetMigrationRecords()
+	require.NoError(t, err)
+	assert.Len(t, records, 1, "only the baseline should be recorded")
+
+	exists, err := ctx.TableExists("orders")
+	require.NoError(t, err)
+	assert.False(t, exists, "migrations after the failed precondition must not run")
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMigrate_RepeatableDependencies tests dependency order and that dependents run again with their dependencies
+func TestMigrate_RepeatableDependencies(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"V2__Create_items.sql": "CREATE TABLE items (id INTEGER);",
+		"R__a_summary.sql": "-- bloomdb: depends=b_base\n" +
+			"DROP VIEW IF EXISTS summary;\nCREATE VIEW summary AS SELECT COUNT(*) AS n FROM base_view;",
+		"R__b_base.sql":  "DROP VIEW IF EXISTS base_view;\nCREATE VIEW base_view AS SELECT * FROM items;",
+		"R__c_audit.sql": "CREATE VIEW IF NOT EXISTS audit AS SELECT 1 AS n;",
+	})
+
+	_, err := ctx.RunCommand("baseline", "--version", "1")
+	require.NoError(t, err, "Baseline command failed")
+
+	output, err := ctx.RunCommand("migrate")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	AssertInfoMessage(t, output, "command: Executing repeatable migration 1/3: b_base")
+	AssertInfoMessage(t, output, "command: Executing repeatable migration 2/3: a_summary")
+
+	require.NoError(t, os.WriteFile(filepath.Join(ctx.MigrationPath, "R__b_base.sql"),
+		[]byte("DROP VIEW IF EXISTS base_view;\nCREATE VIEW base_view AS SELECT id FROM items;"), 0644))
+
+	output, err = ctx.RunCommand("info")
+	require.NoError(t, err, "Info command failed:\n%s", output)
+	assert.Contains(t, output, "description=b_base type=repeatable status=pending")
+	assert.Contains(t, output, "description=a_summary type=repeatable status=pending")
+	assert.Contains(t, output, "description=c_audit type=repeatable status=success")
+
+	output, err = ctx.RunCommand("migrate")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	AssertInfoMessage(t, output, "command: Executing repeatable migration 1/2: b_base")
+	AssertInfoMessage(t, output, "command: Executing repeatable migration 2/2: a_summary")
+
+	db, err := sql.Open(sqliteDriverName, ctx.DBPath)
+	require.NoError(t, err)
+	defer db.Close()
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM BLOOMDB_VERSION").Scan(&count))
+	assert.Equal(t, 5, count, "re-run repeatable migrations should update their records")
+}
+
+// TestMigrate_RepeatableDependencyCycle tests that a dependency cycle is a validation error
+func TestMigrate_RepeatableDependencyCycle(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"R__a.sql":     "-- bloomdb: depends=b\nSELECT 1;",
+		"R__b.sql":     "SELECT 1;",
+		"R__b.depends": "a\n",
+	})
+
+	_, err := ctx.RunCommand("baseline", "--version", "1")
+	require.NoError(t, err, "Baseline command failed")
+
+	output, err := ctx.RunCommand("migrate")
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr), "Expected migrate to fail:\n%s", output)
+	assert.Equal(t, 5, exitErr.ExitCode())
+	assert.Contains(t, output, "dependency cycle between repeatable migrations: a -> b -> a")
+}
@@ -0,0 +1,48 @@
+package test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMigrate_ChangedRepeatableRunsAgain tests that a changed R__ migration runs again instead of failing checksum validation
+func TestMigrate_ChangedRepeatableRunsAgain(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"V2__Create_items.sql": "CREATE TABLE items (id INTEGER, name TEXT);\nINSERT INTO items VALUES (1, 'first');",
+		"R__Items_view.sql":    "DROP VIEW IF EXISTS items_view;\nCREATE VIEW items_view AS SELECT id FROM items;",
+	})
+
+	_, err := ctx.RunCommand("baseline", "--version", "1")
+	require.NoError(t, err, "Baseline command failed")
+
+	output, err := ctx.RunCommand("migrate")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+
+	require.NoError(t, os.WriteFile(filepath.Join(ctx.MigrationPath, "R__Items_view.sql"),
+		[]byte("DROP VIEW IF EXISTS items_view;\nCREATE VIEW items_view AS SELECT id, name FROM items;"), 0644))
+
+	output, err = ctx.RunCommand("info")
+	require.NoError(t, err, "Info command failed:\n%s", output)
+	assert.Contains(t, output, "description=Items_view type=repeatable status=pending")
+
+	output, err = ctx.RunCommand("migrate")
+	require.NoError(t, err, "Migrate with a changed repeatable migration must not fail:\n%s", output)
+	AssertInfoMessage(t, output, "command: Executing repeatable migration 1/1: Items_view")
+
+	db, err := sql.Open(sqliteDriverName, ctx.DBPath)
+	require.NoError(t, err)
+	defer db.Close()
+	var name string
+	require.NoError(t, db.QueryRow("SELECT name FROM items_view").Scan(&name))
+	assert.Equal(t, "first", name, "the changed view should have been recreated")
+
+	output, err = ctx.RunCommand("info")
+	require.NoError(t, err, "Info command failed:\n%s", output)
+	assert.Contains(t, output, "description=Items_view type=repeatable status=success")
+}
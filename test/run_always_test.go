@@ -0,0 +1,67 @@
+package test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMigrate_RunAlways tests that A__ migrations run on every migrate and keep a single record
+func TestMigrate_RunAlways(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"V2__Create_runs.sql": "CREATE TABLE runs (n INTEGER);",
+		"A__Count_runs.sql":   "INSERT INTO runs SELECT COUNT(*) FROM runs_view;",
+		"R__Runs_view.sql":    "CREATE VIEW IF NOT EXISTS runs_view AS SELECT * FROM runs;",
+	})
+
+	_, err := ctx.RunCommand("baseline", "--version", "1")
+	require.NoError(t, err, "Baseline command failed")
+
+	output, err := ctx.RunCommand("migrate")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	rank, checksum := runAlwaysRecord(t, ctx)
+
+	// A changed run-always migration is not a checksum error
+	require.NoError(t, os.WriteFile(filepath.Join(ctx.MigrationPath, "A__Count_runs.sql"),
+		[]byte("-- Record every deploy\nINSERT INTO runs SELECT COUNT(*) FROM runs_view;"), 0644))
+
+	output, err = ctx.RunCommand("migrate")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	assert.Contains(t, output, "Found 1 repeatable migrations to execute")
+
+	db, err := sql.Open(sqliteDriverName, ctx.DBPath)
+	require.NoError(t, err)
+	defer db.Close()
+	var runs int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM runs").Scan(&runs))
+	assert.Equal(t, 2, runs, "run-always migration should run on every migrate")
+
+	secondRank, secondChecksum := runAlwaysRecord(t, ctx)
+	assert.Equal(t, rank, secondRank, "the record should be updated in place")
+	assert.NotEqual(t, checksum, secondChecksum, "the record should hold the checksum of the last run")
+
+	output, err = ctx.RunCommand("info")
+	require.NoError(t, err, "Info command failed:\n%s", output)
+	assert.Contains(t, output, "description=Count_runs type=always status=success")
+	assert.Contains(t, output, "description=Runs_view type=repeatable status=success")
+}
+
+// runAlwaysRecord returns the rank and checksum of the only Count_runs record
+func runAlwaysRecord(t *testing.T, ctx *TestContext) (int, int64) {
+	t.Helper()
+	db, err := sql.Open(sqliteDriverName, ctx.DBPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var count, rank int
+	var checksum int64
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*), MAX(installed_rank), MAX(checksum) FROM BLOOMDB_VERSION WHERE description = 'Count_runs'`).
+		Scan(&count, &rank, &checksum))
+	require.Equal(t, 1, count, "run-always migration should have a single record")
+	return rank, checksum
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSecretSources tests connection strings and passwords read from files, the environment and .pgpass
+func TestSecretSources(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"V2__Create_users.sql": "CREATE TABLE users (id INTEGER);",
+	})
+	secrets := t.TempDir()
+
+	// A mounted secret file provides the connection string
+	connFile := filepath.Join(secrets, "connect")
+	require.NoError(t, os.WriteFile(connFile, []byte("sqlite:"+ctx.DBPath+"\n"), 0600))
+	env := []string{"BLOOMDB_CONNECT_STRING_FILE=" + connFile}
+
+	output, err := runWithEnv(ctx, env, "baseline", "--version", "1")
+	require.NoError(t, err, "Baseline command failed:\n%s", output)
+	output, err = runWithEnv(ctx, env, "migrate")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	exists, err := ctx.TableExists("users")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// The config file references the secret file without a shell
+	configFile := filepath.Join(secrets, "bloomdb.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("connect: ${file:connect}\ntable_name: ${env:TEST_TABLE_NAME}\n"), 0644))
+	output, err = runWithEnv(ctx, []string{"TEST_TABLE_NAME=BLOOMDB_VERSION"}, "info", "--config", configFile)
+	require.NoError(t, err, "Info command failed:\n%s", output)
+	assert.Contains(t, output, "version=2 description=Create_users type=versioned status=success")
+
+	output, err = runWithEnv(ctx, nil, "info", "--config", configFile)
+	assertExitCode(t, err, 2, output)
+	AssertErrorMessage(t, output, "references environment variable TEST_TABLE_NAME, which is not set")
+
+	// Passwords are merged into the connection string and never shown
+	output, err = runWithEnv(ctx, append(env, "BLOOMDB_PASSWORD=s3cret"), "info")
+	assertExitCode(t, err, 2, output)
+	AssertErrorMessage(t, output, "sqlite connection strings have no password")
+
+	output, err = runWithEnv(ctx, append(env, "BLOOMDB_PASSWORD=s3cret"), "config", "show")
+	require.NoError(t, err, "Config show failed:\n%s", output)
+	assert.NotContains(t, output, "s3cret")
+
+	passwordFile := filepath.Join(secrets, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("s3cret\n"), 0600))
+	postgres := []string{"BLOOMDB_CONNECT_STRING=postgres://app@127.0.0.1:1/app?sslmode=disable"}
+	output, err = runWithEnv(ctx, append(postgres, "BLOOMDB_PASSWORD_FILE="+passwordFile, "BLOOMDB_PASSWORD=other"), "info")
+	assertExitCode(t, err, 2, output)
+	AssertErrorMessage(t, output, "password and password_file cannot be combined")
+
+	output, err = runWithEnv(ctx, append(postgres, "BLOOMDB_PASSWORD_FILE="+passwordFile), "info")
+	assertExitCode(t, err, 3, output)
+	assert.NotContains(t, output, "s3cret")
+
+	// .pgpass provides PostgreSQL passwords, unless others can read it
+	pgpass := filepath.Join(secrets, "pgpass")
+	require.NoError(t, os.WriteFile(pgpass, []byte("127.0.0.1:1:app:app:s3cret\n"), 0600))
+	output, err = runWithEnv(ctx, append(postgres, "PGPASSFILE="+pgpass), "info")
+	assertExitCode(t, err, 3, output)
+	AssertInfoMessage(t, output, "Using password from "+pgpass)
+	assert.NotContains(t, output, "s3cret")
+
+	require.NoError(t, os.Chmod(pgpass, 0644))
+	output, err = runWithEnv(ctx, append(postgres, "PGPASSFILE="+pgpass), "info")
+	assertExitCode(t, err, 3, output)
+	AssertWarningMessage(t, output, "has group or world access (0644) and is ignored")
+}
+
+// runWithEnv runs a bloomdb command with additional environment variables and
+// without --conn, so that the connection string comes from another source
+func runWithEnv(ctx *TestContext, env []string, args ...string) (string, error) {
+	ctx.T.Helper()
+	fullArgs := append([]string{"--path", ctx.MigrationPath}, args...)
+	cmd := exec.Command(ctx.BloomDBBinary, fullArgs...)
+	cmd.Env = append(os.Environ(), "BLOOMDB_PRINTER=test", "BLOOMDB_VERBOSE=1")
+	cmd.Env = append(cmd.Env, env...)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
@@ -0,0 +1,137 @@
+package test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSign tests that migrate --verify-key only runs migration files signed with bloomdb sign
+func TestSign(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"V2__Create_users.sql": "CREATE TABLE users (id INTEGER);",
+		"R__Users_view.sql":    "CREATE VIEW IF NOT EXISTS users_view AS SELECT * FROM users;",
+	})
+	signingKey, verifyKey := writeKeyPair(t)
+
+	_, err := ctx.RunCommand("baseline", "--version", "1")
+	require.NoError(t, err, "Baseline command failed")
+
+	// Unsigned migrations do not run
+	output, err := ctx.RunCommand("migrate", "--verify-key", verifyKey)
+	assertExitCode(t, err, 5, output)
+	assert.Contains(t, output, "bloomdb.manifest: not found, the migrations are unsigned")
+	exists, err := ctx.TableExists("users")
+	require.NoError(t, err)
+	assert.False(t, exists, "No migration should run before verification")
+
+	output, err = ctx.RunCommand("sign", "--key", signingKey)
+	require.NoError(t, err, "Sign command failed:\n%s", output)
+	assert.Contains(t, output, "Signed 2 file(s)")
+	assert.FileExists(t, filepath.Join(ctx.MigrationPath, "bloomdb.manifest"))
+
+	output, err = ctx.RunCommand("migrate", "--verify-key", verifyKey)
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	exists, err = ctx.TableExists("users")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Modified and extra files break the signature and are named
+	require.NoError(t, os.WriteFile(filepath.Join(ctx.MigrationPath, "R__Users_view.sql"), []byte("CREATE VIEW IF NOT EXISTS users_view AS SELECT id FROM users;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(ctx.MigrationPath, "V3__Drop_users.sql"), []byte("DROP TABLE users;"), 0644))
+
+	output, err = ctx.RunCommand("migrate", "--verify-key", verifyKey)
+	assertExitCode(t, err, 5, output)
+	AssertErrorMessage(t, output, "R__Users_view.sql: modified after signing")
+	AssertErrorMessage(t, output, "V3__Drop_users.sql: not in the signed manifest")
+	exists, err = ctx.TableExists("users")
+	require.NoError(t, err)
+	assert.True(t, exists, "V3 must not run")
+
+	// Without a verify key nothing is verified
+	output, err = ctx.RunCommand("validate")
+	require.NoError(t, err, "Validate command failed:\n%s", output)
+
+	output, err = ctx.RunCommand("sign")
+	assertExitCode(t, err, 2, output)
+}
+
+// TestSign_PostMigrationScript tests that the post-migration script is signed with the migrations
+func TestSign_PostMigrationScript(t *testing.T) {
+	ctx := NewTestContext(t)
+	writeMigrations(t, ctx, map[string]string{
+		"V1__Create_users.sql": "CREATE TABLE users (id INTEGER);",
+	})
+	script := filepath.Join(ctx.MigrationPath, "post.sql")
+	require.NoError(t, os.WriteFile(script, []byte("CREATE TABLE IF NOT EXISTS audit (id INTEGER);"), 0644))
+	signingKey, verifyKey := writeKeyPair(t)
+
+	_, err := ctx.RunCommand("baseline", "--version", "0")
+	require.NoError(t, err, "Baseline command failed")
+
+	output, err := ctx.RunCommand("sign", "--key", signingKey, "--post-migration-script", "missing.sql")
+	assertExitCode(t, err, 2, output)
+	AssertErrorMessage(t, output, "post-migration script not found")
+
+	// A script configured only at deploy time is not signed
+	output, err = ctx.RunCommand("sign", "--key", signingKey)
+	require.NoError(t, err, "Sign command failed:\n%s", output)
+	output, err = ctx.RunCommand("migrate", "--verify-key", verifyKey, "--post-migration-script", "post.sql")
+	assertExitCode(t, err, 5, output)
+	AssertErrorMessage(t, output, "post.sql: post-migration script not in the signed manifest")
+
+	output, err = ctx.RunCommand("sign", "--key", signingKey, "--post-migration-script", "post.sql")
+	require.NoError(t, err, "Sign command failed:\n%s", output)
+	assert.Contains(t, output, "Signed 2 file(s)")
+
+	output, err = ctx.RunCommand("migrate", "--verify-key", verifyKey, "--post-migration-script", "post.sql")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	exists, err := ctx.TableExists("audit")
+	require.NoError(t, err)
+	assert.True(t, exists, "The signed post-migration script should run")
+
+	// An edited script stops migrate before anything runs
+	require.NoError(t, os.WriteFile(script, []byte("DROP TABLE users;"), 0644))
+	output, err = ctx.RunCommand("migrate", "--verify-key", verifyKey, "--post-migration-script", "post.sql")
+	assertExitCode(t, err, 5, output)
+	AssertErrorMessage(t, output, "post.sql: post-migration script modified after signing")
+	exists, err = ctx.TableExists("users")
+	require.NoError(t, err)
+	assert.True(t, exists, "The edited post-migration script must not run")
+}
+
+// writeKeyPair writes an Ed25519 key pair as PEM files and returns their paths
+func writeKeyPair(t *testing.T) (signingKey, verifyKey string) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	signingKey = filepath.Join(dir, "signing.pem")
+	verifyKey = filepath.Join(dir, "verify.pem")
+	require.NoError(t, os.WriteFile(signingKey, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600))
+	require.NoError(t, os.WriteFile(verifyKey, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644))
+	return signingKey, verifyKey
+}
+
+// assertExitCode asserts that a command failed with the given exit code
+func assertExitCode(t *testing.T, err error, code int, output string) {
+	t.Helper()
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr), "Expected exit code %d:\n%s", code, output)
+	assert.Equal(t, code, exitErr.ExitCode(), output)
+}
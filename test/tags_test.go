@@ -0,0 +1,74 @@
+package test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMigrate_Tags tests that tagged migrations only run when selected, even out of order
+func TestMigrate_Tags(t *testing.T) {
+	ctx := NewTestContext(t)
+	ctx.MigrationPath = t.TempDir()
+	t.Setenv("BLOOMDB_TAGS", "")
+	t.Setenv("BLOOMDB_EXCLUDE_TAGS", "")
+
+	files := map[string]string{
+		"V2__Create_users.sql":    "CREATE TABLE users (id INTEGER);",
+		"V3__Seed_users+seed.sql": "INSERT INTO users VALUES (1);",
+		"V4__Create_orders.sql":   "CREATE TABLE orders (id INTEGER);",
+		"R__Stats.sql":            "-- bloomdb: tags=backfill\nCREATE VIEW IF NOT EXISTS stats AS SELECT count(*) AS n FROM users;",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(ctx.MigrationPath, name), []byte(content), 0644))
+	}
+
+	_, err := ctx.RunCommand("baseline", "--version", "1")
+	require.NoError(t, err, "Baseline command failed")
+
+	output, err := ctx.RunCommand("migrate")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	assert.Equal(t, []string{"1", "2", "4"}, appliedVersions(t, ctx))
+
+	output, err = ctx.RunCommand("info")
+	require.NoError(t, err, "Info command failed:\n%s", output)
+	assert.Contains(t, output, "version=3 description=Seed_users type=versioned status=excluded")
+	assert.Contains(t, output, "description=Stats type=repeatable status=excluded")
+
+	output, err = ctx.RunCommand("migrate", "--tags", "seed,backfill", "--exclude-tags", "backfill")
+	require.NoError(t, err, "Migrate command failed:\n%s", output)
+	assert.Equal(t, []string{"1", "2", "4", "3"}, appliedVersions(t, ctx))
+
+	records, err := ctx.GetMigrationRecords()
+	require.NoError(t, err)
+	for _, record := range records {
+		if record.Version == "3" {
+			assert.Equal(t, "seed", record.Tags.String)
+		}
+		assert.NotEqual(t, "Stats", record.Description, "excluded repeatable migration must not run")
+	}
+}
+
+// appliedVersions returns the versions of the version table in installation order
+func appliedVersions(t *testing.T, ctx *TestContext) []string {
+	t.Helper()
+	db, err := sql.Open(sqliteDriverName, ctx.DBPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT version FROM BLOOMDB_VERSION WHERE version IS NOT NULL ORDER BY installed_rank`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var versions []string
+	for rows.Next() {
+		var version string
+		require.NoError(t, rows.Scan(&version))
+		versions = append(versions, version)
+	}
+	return versions
+}